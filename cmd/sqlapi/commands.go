@@ -0,0 +1,471 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rickb777/sqlapi"
+	"github.com/rickb777/sqlapi/constraint"
+	"github.com/rickb777/sqlapi/ddl"
+	"github.com/rickb777/sqlapi/driver"
+	"github.com/rickb777/sqlapi/dump"
+	"github.com/rickb777/sqlapi/introspect"
+	"github.com/rickb777/sqlapi/migrate"
+	"github.com/rickb777/sqlapi/pgxapi"
+	pgxmigrate "github.com/rickb777/sqlapi/pgxapi/migrate"
+	"github.com/rickb777/sqlapi/schema"
+)
+
+func tablesCmd(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("sqlapi tables", "[-match regexp]")
+	match := fs.String("match", "", "only list the tables whose names match this regular expression")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var re *regexp.Regexp
+	if *match != "" {
+		var err error
+		if re, err = regexp.Compile(*match); err != nil {
+			return err
+		}
+	}
+
+	names, err := c.listTables(ctx, re)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range names {
+		fmt.Fprintln(c.stdout, n)
+	}
+	return nil
+}
+
+func (c *cli) listTables(ctx context.Context, re *regexp.Regexp) ([]string, error) {
+	if c.pgx {
+		db, err := c.connectPgx(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return pgxapi.ListTables(db, re)
+	}
+
+	db, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sqlapi.ListTables(db, re)
+}
+
+//-------------------------------------------------------------------------------------------------
+
+func describeCmd(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("sqlapi describe", "[-prefix p] [-go] [-pkg name] [table ...]")
+	prefix := fs.String("prefix", "", "the table name prefix")
+	asGo := fs.Bool("go", false, "write Go structs for the tables")
+	pkg := fs.String("pkg", "model", "the package name used with -go")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := c.needsDatabaseSQL("describe"); err != nil {
+		return err
+	}
+
+	db, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	tables, ccs, err := readTables(ctx, db, *prefix, fs.Args())
+	if err != nil {
+		return err
+	}
+
+	if *asGo {
+		return introspect.WriteGo(c.stdout, *pkg, *prefix, tables, ccs)
+	}
+
+	for i, table := range tables {
+		if i > 0 {
+			fmt.Fprintln(c.stdout)
+		}
+		if err = describe(c.stdout, table, ccs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func describe(w io.Writer, table *schema.TableDescription, cc constraint.Constraints) error {
+	fmt.Fprintf(w, "TABLE %s\n", table.Name)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, f := range table.Fields {
+		var notes []string
+		if f.Tags.Primary {
+			notes = append(notes, "primary key")
+		}
+		if f.Tags.Auto {
+			notes = append(notes, "auto-increment")
+		}
+		switch {
+		case f.Tags.DefaultSQL != "":
+			notes = append(notes, "default "+f.Tags.DefaultSQL)
+		case f.Tags.Default != "":
+			notes = append(notes, fmt.Sprintf("default %q", f.Tags.Default))
+		}
+
+		null := "not null"
+		if f.Type.IsPtr {
+			null = "null"
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", f.SqlName, f.Tags.Type, null, strings.Join(notes, ", "))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, ix := range table.Index {
+		fmt.Fprintf(w, "  %sINDEX %s (%s)\n", ix.UniqueStr(), ix.Name, ix.Fields.SqlNames().MkString(", "))
+	}
+
+	for _, fk := range cc.FkConstraints() {
+		fmt.Fprintf(w, "  FOREIGN KEY (%s) REFERENCES %s (%s)\n", fk.ForeignKeyColumn, fk.Parent.TableName, fk.Parent.Column)
+	}
+	for _, fk := range cc.CompositeFkConstraints() {
+		fmt.Fprintf(w, "  FOREIGN KEY (%s) REFERENCES %s (%s)\n",
+			strings.Join(fk.ForeignKeyColumns, ", "), fk.Parent.TableName, strings.Join(fk.Parent.Columns, ", "))
+	}
+	return nil
+}
+
+//-------------------------------------------------------------------------------------------------
+
+func ddlCmd(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("sqlapi ddl", "[-prefix p] [-dialect d] [-dir d] [table ...]")
+	prefix := fs.String("prefix", "", "the table name prefix")
+	dialectName := fs.String("dialect", "", "write the script for this dialect instead of the database's own")
+	dir := fs.String("dir", "", "write a script for each SQL syntax, or only for -dialect, into this directory, e.g. schema.postgres.sql")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := c.needsDatabaseSQL("ddl"); err != nil {
+		return err
+	}
+
+	var di driver.Dialect
+	if *dialectName != "" {
+		if di = driver.PickDialect(*dialectName); di == nil {
+			return fmt.Errorf("unknown dialect %q", *dialectName)
+		}
+	}
+
+	db, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	registry, err := readDatabase(ctx, db, *prefix, fs.Args())
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *dir != "" && di != nil:
+		return registry.WriteFiles(*dir, di)
+	case *dir != "":
+		return registry.WriteFiles(*dir)
+	case di != nil:
+		return registry.WriteSQL(c.stdout, di)
+	}
+	return registry.WriteSQL(c.stdout, db.Dialect())
+}
+
+//-------------------------------------------------------------------------------------------------
+
+func migrateCmd(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("sqlapi migrate", "-dir d [-table t] up | down [n] | to version | status")
+	dir := fs.String("dir", "", "the directory containing the migration files (required)")
+	table := fs.String("table", migrate.DefaultTableName, "the bookkeeping table")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *dir == "" || fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("migrate needs -dir and an action")
+	}
+
+	m, err := c.migrator(ctx, *dir, *table)
+	if err != nil {
+		return err
+	}
+
+	action, rest := fs.Arg(0), fs.Args()[1:]
+
+	var n int
+	switch {
+	case action == "up" && len(rest) == 0:
+		n, err = m.Up(ctx)
+
+	case action == "down" && len(rest) <= 1:
+		count := 1
+		if len(rest) == 1 {
+			if count, err = strconv.Atoi(rest[0]); err != nil {
+				return fmt.Errorf("migrate down: %w", err)
+			}
+		}
+		n, err = m.Down(ctx, count)
+
+	case action == "to" && len(rest) == 1:
+		var version int64
+		if version, err = strconv.ParseInt(rest[0], 10, 64); err != nil {
+			return fmt.Errorf("migrate to: %w", err)
+		}
+		n, err = m.To(ctx, version)
+
+	case action == "status" && len(rest) == 0:
+		return migrationStatus(ctx, c.stdout, m)
+
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown migrate action %q", strings.Join(fs.Args(), " "))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "%s: %d migrations\n", action, n)
+	return nil
+}
+
+// migrator is implemented by *migrate.Migrator and by pgxMigrator.
+type migrator interface {
+	Up(ctx context.Context) (int, error)
+	Down(ctx context.Context, n int) (int, error)
+	To(ctx context.Context, version int64) (int, error)
+	Status(ctx context.Context) ([]migrate.Status, error)
+}
+
+// pgxMigrator adapts *pgxmigrate.Migrator to the migrator interface.
+type pgxMigrator struct {
+	*pgxmigrate.Migrator
+}
+
+func (m pgxMigrator) Status(ctx context.Context) ([]migrate.Status, error) {
+	list, err := m.Migrator.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]migrate.Status, len(list))
+	for i, s := range list {
+		result[i] = migrate.Status(s)
+	}
+	return result, nil
+}
+
+func (c *cli) migrator(ctx context.Context, dir, table string) (migrator, error) {
+	if c.pgx {
+		migrations, err := pgxmigrate.Load(os.DirFS(dir))
+		if err != nil {
+			return nil, err
+		}
+
+		db, err := c.connectPgx(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return pgxMigrator{pgxmigrate.New(db, migrations).WithTableName(table)}, nil
+	}
+
+	migrations, err := migrate.Load(os.DirFS(dir))
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(db, migrations).WithTableName(table), nil
+}
+
+func migrationStatus(ctx context.Context, w io.Writer, m migrator) error {
+	list, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED\tNOTES")
+	for _, s := range list {
+		applied := "-"
+		if s.Applied {
+			applied = s.AppliedAt.Format(time.RFC3339)
+		}
+
+		var notes []string
+		if s.Modified {
+			notes = append(notes, "modified")
+		}
+		if s.Missing {
+			notes = append(notes, "missing")
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", s.Version, s.Name, applied, strings.Join(notes, ", "))
+	}
+	return tw.Flush()
+}
+
+//-------------------------------------------------------------------------------------------------
+
+func dumpCmd(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("sqlapi dump", "[-prefix p] [-dialect d] [-batch n] [-o file] [table ...]")
+	prefix := fs.String("prefix", "", "the table name prefix")
+	dialectName := fs.String("dialect", "", "write the script for this dialect instead of the database's own")
+	batch := fs.Int("batch", 1, "the number of rows per INSERT statement")
+	output := fs.String("o", "", "the output file (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := c.needsDatabaseSQL("dump"); err != nil {
+		return err
+	}
+
+	db, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	dumper := dump.New(db).WithBatchSize(*batch)
+	if *dialectName != "" {
+		di := driver.PickDialect(*dialectName)
+		if di == nil {
+			return fmt.Errorf("unknown dialect %q", *dialectName)
+		}
+		dumper = dumper.WithDialect(di)
+	}
+
+	registry, err := readDatabase(ctx, db, *prefix, fs.Args())
+	if err != nil {
+		return err
+	}
+
+	w := c.stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	return dumper.Dump(ctx, w, registry.Tables()...)
+}
+
+//-------------------------------------------------------------------------------------------------
+
+func execCmd(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("sqlapi exec", "[-f script.sql] [sql ...]")
+	file := fs.String("f", "", "the script file to execute; use - for stdin")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var script string
+	switch {
+	case *file == "-":
+		b, err := io.ReadAll(c.stdin)
+		if err != nil {
+			return err
+		}
+		script = string(b)
+
+	case *file != "":
+		b, err := os.ReadFile(*file)
+		if err != nil {
+			return err
+		}
+		script = string(b)
+
+	case fs.NArg() > 0:
+		script = strings.Join(fs.Args(), " ")
+
+	default:
+		fs.Usage()
+		return fmt.Errorf("exec needs -f or some SQL")
+	}
+
+	if c.pgx {
+		db, err := c.connectPgx(ctx)
+		if err != nil {
+			return err
+		}
+		return pgxapi.ExecScript(ctx, db, script)
+	}
+
+	db, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+	return sqlapi.ExecScript(ctx, db, script)
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// readTables introspects the named tables or, if there are none, all the tables with the
+// prefix apart from SQLite's internal tables.
+func readTables(ctx context.Context, db sqlapi.SqlDB, prefix string, names []string) ([]*schema.TableDescription, []constraint.Constraints, error) {
+	if len(names) == 0 {
+		all, err := sqlapi.ListTables(db, regexp.MustCompile("^"+regexp.QuoteMeta(prefix)))
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, n := range all {
+			if !strings.HasPrefix(n, "sqlite_") {
+				names = append(names, n)
+			}
+		}
+	}
+
+	var tables []*schema.TableDescription
+	var ccs []constraint.Constraints
+	for _, n := range names {
+		table, cc, err := introspect.ReadTable(ctx, db, sqlapi.TableName{Prefix: prefix, Name: strings.TrimPrefix(n, prefix)})
+		if err != nil {
+			return nil, nil, err
+		}
+		tables = append(tables, table)
+		ccs = append(ccs, cc)
+	}
+	return tables, ccs, nil
+}
+
+// readDatabase introspects tables as per readTables and registers them, so that they can be
+// processed in foreign-key order.
+func readDatabase(ctx context.Context, db sqlapi.SqlDB, prefix string, names []string) (*ddl.Database, error) {
+	tables, ccs, err := readTables(ctx, db, prefix, names)
+	if err != nil {
+		return nil, err
+	}
+
+	registry := &ddl.Database{}
+	for i, table := range tables {
+		name := sqlapi.TableName{Prefix: prefix, Name: strings.TrimPrefix(table.Name, prefix)}
+		registry.Add(name, ddl.Table{Description: table, Constraints: ccs[i]})
+	}
+	return registry, nil
+}
@@ -0,0 +1,9 @@
+package main
+
+// The drivers that don't need cgo.
+import (
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
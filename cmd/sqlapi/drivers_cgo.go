@@ -0,0 +1,9 @@
+//go:build cgo
+
+package main
+
+// The drivers that need cgo.
+import (
+	_ "github.com/marcboeker/go-duckdb"
+	_ "github.com/mattn/go-sqlite3"
+)
@@ -0,0 +1,165 @@
+// Command sqlapi provides everyday database operations from the command line, so that they
+// can be used without writing any Go.
+//
+//	sqlapi [-v] [-pgx] <command> [flags] [arguments]
+//
+// The commands are
+//
+//	tables   [-match regexp]                      list the tables
+//	describe [-prefix p] [-go] [-pkg name] [table ...]
+//	                                              describe the tables, or write them as Go structs
+//	ddl      [-prefix p] [-dialect d] [-dir d] [table ...]
+//	                                              write the script that creates the tables
+//	migrate  -dir d [-table t] up | down [n] | to version | status
+//	                                              apply, revert or list migrations
+//	dump     [-prefix p] [-dialect d] [-batch n] [-o file] [table ...]
+//	                                              write table data as INSERT statements
+//	exec     [-f script.sql] [sql ...]            execute a script
+//
+// Where no tables are listed, all the tables with the prefix are used, in foreign-key order.
+//
+// The database is chosen using the same environment variables as sqlapi.ConnectEnv, i.e.
+// DB_URL, DB_DRIVER and DB_QUOTE, plus DB_MAX_CONNECTIONS, DB_CONNECT_DELAY and
+// DB_CONNECT_TIMEOUT. All the drivers that sqlapi supports are included, although
+// "sqlite3" (github.com/mattn/go-sqlite3) and "duckdb" need cgo; when built with
+// CGO_ENABLED=0, use "sqlite" (modernc.org/sqlite) instead.
+//
+// With -pgx, PostgreSQL is accessed natively using pgxapi.ConnectEnv instead, which is
+// configured by the PG* variables (PGHOST, PGDATABASE etc) or DB_URL, plus PGQUOTE. Only
+// tables, migrate and exec are available this way; describe, ddl and dump rely on the
+// introspect package, which needs database/sql, so use DB_DRIVER=pgx without -pgx for them.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/jackc/pgx/v5/tracelog"
+	"github.com/rickb777/sqlapi"
+	"github.com/rickb777/sqlapi/pgxapi"
+	"github.com/rickb777/sqlapi/pgxapi/logadapter"
+)
+
+func main() {
+	err := run(context.Background(), os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "sqlapi: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// cli holds the state shared by the commands.
+type cli struct {
+	stdin          io.Reader
+	stdout, stderr io.Writer
+	verbose        bool
+	pgx            bool
+	db             sqlapi.SqlDB
+	pgxdb          pgxapi.SqlDB
+}
+
+type command func(ctx context.Context, c *cli, args []string) error
+
+var commands = map[string]command{
+	"tables":   tablesCmd,
+	"describe": describeCmd,
+	"ddl":      ddlCmd,
+	"migrate":  migrateCmd,
+	"dump":     dumpCmd,
+	"exec":     execCmd,
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	c := &cli{stdin: stdin, stdout: stdout, stderr: stderr}
+
+	fs := c.flagSet("sqlapi", "<command> [flags] [arguments]\n\nThe commands are tables, describe, ddl, migrate, dump and exec.\nUse sqlapi <command> -h for help on each command.\n")
+	fs.BoolVar(&c.verbose, "v", false, "log the connection and each statement to stderr")
+	fs.BoolVar(&c.pgx, "pgx", false, "connect to PostgreSQL using pgxapi.ConnectEnv instead of database/sql")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	cmd, exists := commands[fs.Arg(0)]
+	if !exists {
+		return fmt.Errorf("unknown command %q; expected tables, describe, ddl, migrate, dump or exec", fs.Arg(0))
+	}
+
+	defer func() {
+		if c.db != nil {
+			c.db.Close()
+		}
+		if c.pgxdb != nil {
+			c.pgxdb.Close()
+		}
+	}()
+	return cmd(ctx, c, fs.Args()[1:])
+}
+
+// connect opens the database given by the environment, unless it is already open.
+func (c *cli) connect(ctx context.Context) (sqlapi.SqlDB, error) {
+	if c.db != nil {
+		return c.db, nil
+	}
+
+	db, err := sqlapi.ConnectEnv(ctx, c.logger(), tracelog.LogLevelInfo, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	c.db = db
+	return db, nil
+}
+
+// connectPgx opens the PostgreSQL database given by the environment using pgx, unless it is
+// already open.
+func (c *cli) connectPgx(ctx context.Context) (pgxapi.SqlDB, error) {
+	if c.pgxdb != nil {
+		return c.pgxdb, nil
+	}
+
+	db, err := pgxapi.ConnectEnv(ctx, c.logger(), tracelog.LogLevelInfo, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	c.pgxdb = db
+	return db, nil
+}
+
+// needsDatabaseSQL rejects -pgx for the commands that only work via database/sql.
+func (c *cli) needsDatabaseSQL(command string) error {
+	if c.pgx {
+		return fmt.Errorf("%s is not available with -pgx; use DB_DRIVER=pgx without -pgx instead", command)
+	}
+	return nil
+}
+
+func (c *cli) logger() tracelog.Logger {
+	if c.verbose {
+		return logadapter.NewLogger(log.New(c.stderr, "", log.LstdFlags))
+	}
+	return tracelog.LoggerFunc(func(context.Context, tracelog.LogLevel, string, map[string]any) {})
+}
+
+func (c *cli) flagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "Usage: %s %s\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rickb777/expect"
+)
+
+const script = `CREATE TABLE cli_owners (id integer primary key, name varchar(50) not null);
+CREATE TABLE cli_pets (id integer primary key, owner_id integer references cli_owners (id), name text);
+INSERT INTO cli_owners VALUES (1, 'Ann');
+INSERT INTO cli_pets VALUES (10, 1, 'Rex');`
+
+func sqlapiCmd(t *testing.T, stdin string, args ...string) string {
+	t.Helper()
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	err := run(context.Background(), args, strings.NewReader(stdin), stdout, stderr)
+	expect.Error(err).Info(args).Info(stderr.String()).Not().ToHaveOccurred(t)
+	return stdout.String()
+}
+
+func TestCommands(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("DB_DRIVER", "sqlite")
+	t.Setenv("DB_URL", "file:"+filepath.Join(dir, "cli.db"))
+
+	sqlapiCmd(t, script, "exec", "-f", "-")
+
+	out := sqlapiCmd(t, "", "tables", "-match", "^cli_")
+	expect.String(out).ToBe(t, "cli_owners\ncli_pets\n")
+
+	out = sqlapiCmd(t, "", "describe", "cli_pets")
+	expect.String(out).ToContain(t, "TABLE cli_pets\n")
+	expect.String(out).ToContain(t, "FOREIGN KEY (owner_id) REFERENCES cli_owners (id)\n")
+
+	out = sqlapiCmd(t, "", "describe", "-prefix", "cli_", "-go", "-pkg", "pets")
+	expect.String(out).ToContain(t, "package pets\n")
+	expect.String(out).ToContain(t, "type Owner struct {")
+	expect.String(out).ToContain(t, "type Pet struct {")
+
+	out = sqlapiCmd(t, "", "ddl", "-prefix", "cli_")
+	expect.String(out).ToContain(t, "CREATE TABLE")
+	expect.Number(strings.Index(out, "cli_owners")).ToBeLessThan(t, strings.Index(out, "cli_pets"))
+
+	out = sqlapiCmd(t, "", "ddl", "-prefix", "cli_", "-dialect", "mysql")
+	expect.String(out).ToContain(t, "CREATE TABLE `cli_owners`")
+
+	schemas := filepath.Join(dir, "schemas")
+	expect.Error(os.Mkdir(schemas, 0o755)).Not().ToHaveOccurred(t)
+	sqlapiCmd(t, "", "ddl", "-prefix", "cli_", "-dir", schemas)
+	b, err := os.ReadFile(filepath.Join(schemas, "schema.sqlserver.sql"))
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.String(string(b)).ToContain(t, `CREATE TABLE "cli_pets"`)
+	_, err = os.Stat(filepath.Join(schemas, "schema.pgx.sql"))
+	expect.Bool(os.IsNotExist(err)).ToBeTrue(t)
+
+	only := filepath.Join(dir, "only")
+	expect.Error(os.Mkdir(only, 0o755)).Not().ToHaveOccurred(t)
+	sqlapiCmd(t, "", "ddl", "-prefix", "cli_", "-dialect", "pgx", "-dir", only)
+	entries, err := os.ReadDir(only)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Slice(entries).ToHaveLength(t, 1)
+
+	out = sqlapiCmd(t, "", "dump", "-prefix", "cli_", "-dialect", "postgres")
+	expect.String(out).ToContain(t, `INSERT INTO "cli_owners" ("id", "name") VALUES (1, 'Ann');`)
+	expect.Number(strings.Index(out, "cli_owners")).ToBeLessThan(t, strings.Index(out, "cli_pets"))
+
+	sqlapiCmd(t, "", "exec", "DELETE FROM cli_pets; DELETE FROM cli_owners")
+	sqlapiCmd(t, out, "exec", "-f", "-")
+	out = sqlapiCmd(t, "", "dump", "cli_pets")
+	expect.String(out).ToContain(t, `'Rex'`)
+}
+
+func TestMigrateCommand(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("DB_DRIVER", "sqlite")
+	t.Setenv("DB_URL", "file:"+filepath.Join(dir, "cli.db"))
+
+	migrations := filepath.Join(dir, "migrations")
+	expect.Error(os.Mkdir(migrations, 0o755)).Not().ToHaveOccurred(t)
+	for name, content := range map[string]string{
+		"1_create.up.sql":   "CREATE TABLE cli_things (id integer primary key)",
+		"1_create.down.sql": "DROP TABLE cli_things",
+		"2_insert.up.sql":   "INSERT INTO cli_things VALUES (1); INSERT INTO cli_things VALUES (2);",
+		"2_insert.down.sql": "DELETE FROM cli_things",
+	} {
+		err := os.WriteFile(filepath.Join(migrations, name), []byte(content), 0o644)
+		expect.Error(err).Not().ToHaveOccurred(t)
+	}
+
+	out := sqlapiCmd(t, "", "migrate", "-dir", migrations, "up")
+	expect.String(out).ToBe(t, "up: 2 migrations\n")
+
+	err := run(context.Background(), []string{"migrate", "-dir", migrations, "down", "-1"}, strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{})
+	expect.Error(err).ToContain(t, "at least 1")
+
+	out = sqlapiCmd(t, "", "migrate", "-dir", migrations, "status")
+	expect.String(out).ToContain(t, "VERSION")
+	expect.String(out).ToContain(t, "insert")
+
+	out = sqlapiCmd(t, "", "migrate", "-dir", migrations, "to", "1")
+	expect.String(out).ToBe(t, "to: 1 migrations\n")
+
+	out = sqlapiCmd(t, "", "dump", "cli_things")
+	expect.String(out).ToBe(t, "\n-- cli_things\n")
+}
+
+func TestUsageErrors(t *testing.T) {
+	cases := [][]string{
+		{},
+		{"frobnicate"},
+		{"migrate", "up"},
+		{"exec"},
+		{"tables", "-match", "("},
+	}
+
+	for _, args := range cases {
+		err := run(context.Background(), args, strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{})
+		expect.Error(err).Info(args).ToHaveOccurred(t)
+	}
+}
+
+func TestPgxNeedsDatabaseSQL(t *testing.T) {
+	for _, cmd := range []string{"describe", "ddl", "dump"} {
+		err := run(context.Background(), []string{"-pgx", cmd}, strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{})
+		expect.Error(err).Info(cmd).ToContain(t, "not available with -pgx")
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/cenkalti/backoff/v4"
 	"github.com/jackc/pgx/v5/tracelog"
 	"github.com/rickb777/sqlapi/driver"
+	"github.com/rickb777/where/dialect"
 	"github.com/rickb777/where/quote"
 )
 
@@ -30,7 +31,9 @@ func MustConnectEnv(ctx context.Context, lgr tracelog.Logger, logLevel tracelog.
 const sqliteInMemory = "file::memory:?mode=memory&cache=shared"
 
 // ConnectEnv connects to the database server using environment variables:
-// DB_URL, DB_DRIVER and DB_QUOTE.
+// DB_URL, DB_DRIVER and DB_QUOTE. DB_DRIVER defaults to "sqlite3"; use "sqlite" for the
+// pure-Go driver modernc.org/sqlite. For both of these, DB_URL defaults to an in-memory database.
+// For "duckdb", a blank DB_URL also gives an in-memory database.
 // Also available are DB_MAX_CONNECTIONS, DB_CONNECT_DELAY and DB_CONNECT_TIMEOUT.
 // Use DB_QUOTE to set "ansi", "mysql" or "none" as the policy for quoting identifiers (the default
 // is none).
@@ -58,6 +61,10 @@ func ConnectEnv(ctx context.Context, lgr tracelog.Logger, logLevel tracelog.LogL
 		di = driver.Sqlite()
 	}
 
+	if dbUrl == "" && di.Index() == dialect.Sqlite {
+		dbUrl = sqliteInMemory // also for the pure-Go driver, "sqlite"
+	}
+
 	quoter := quote.PickQuoter(os.Getenv("DB_QUOTE"))
 	if quoter != nil {
 		di = di.WithQuoter(quoter)
@@ -90,7 +97,7 @@ func MustConnect(ctx context.Context, driverName, dataSourceName string, di driv
 // If the connection fails, it is retried using an exponential backoff.
 // the maximum number of (re-)tries can be specified; if this is zero, there is no limit.
 func Connect(ctx context.Context, driver, dsn string, di driver.Dialect, lgr Logger, tries int) (SqlDB, error) {
-	if dsn == "" {
+	if dsn == "" && driver != "duckdb" { // a blank DSN is an in-memory DuckDB database
 		return nil, fmt.Errorf("DB connect to %s failed: DSN is blank", driver)
 	}
 
@@ -153,8 +160,12 @@ func Connect(ctx context.Context, driver, dsn string, di driver.Dialect, lgr Log
 		}
 	}()
 
-	// ping the connection using an empty statement
-	_, err = db.ExecContext(ctx, ";")
+	// ping the connection using an empty statement (DuckDB rejects these)
+	ping := ";"
+	if driver == "duckdb" {
+		ping = "SELECT 1"
+	}
+	_, err = db.ExecContext(ctx, ping)
 	if err != nil {
 		return nil, fmt.Errorf("%w - unable to communicate with to the database.", err)
 	}
@@ -0,0 +1,209 @@
+package constraint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rickb777/sqlapi"
+	"github.com/rickb777/sqlapi/support"
+	"github.com/rickb777/where/quote"
+)
+
+// CompositeReference holds a table + columns reference used by composite constraints.
+// The table name should not include any schema or other prefix.
+// The columns may be omitted, in which case the parent table's primary key is implied,
+// but the functionality is then reduced (there will be insufficient metadata to use
+// CompositeRelationship methods).
+type CompositeReference struct {
+	TableName string
+	Columns   []string
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// CompositeFkConstraint holds a pair of references and their update/delete consequences, for
+// a foreign key that spans several columns, typically referring to a composite primary key.
+// ForeignKeyColumns is the 'owner' of the constraint; its columns correspond in order to
+// the parent's columns.
+type CompositeFkConstraint struct {
+	ForeignKeyColumns []string
+	Parent            CompositeReference
+	Update, Delete    Consequence
+}
+
+// CompositeFkConstraintOn constructs a composite foreign key constraint in a fluent style.
+func CompositeFkConstraintOn(columns ...string) CompositeFkConstraint {
+	return CompositeFkConstraint{ForeignKeyColumns: columns}
+}
+
+// RefersTo sets the parent reference. The columns may be omitted.
+func (c CompositeFkConstraint) RefersTo(tableName string, columns ...string) CompositeFkConstraint {
+	c.Parent = CompositeReference{tableName, columns}
+	return c
+}
+
+// OnUpdate sets the update consequence.
+func (c CompositeFkConstraint) OnUpdate(consequence Consequence) CompositeFkConstraint {
+	c.Update = consequence
+	return c
+}
+
+// OnDelete sets the delete consequence.
+func (c CompositeFkConstraint) OnDelete(consequence Consequence) CompositeFkConstraint {
+	c.Delete = consequence
+	return c
+}
+
+// NoCascade changes both the Update and Delete consequences to NoAction.
+func (c CompositeFkConstraint) NoCascade() CompositeFkConstraint {
+	c.Update = NoAction
+	c.Delete = NoAction
+	return c
+}
+
+// ConstraintSql constructs the CONSTRAINT clause to be included in the CREATE TABLE.
+func (c CompositeFkConstraint) ConstraintSql(q quote.Quoter, name sqlapi.TableName, index int) string {
+	return baseConstraintSql(q, name, c, c.sql(q, name.Prefix), "", "")
+}
+
+func (c CompositeFkConstraint) sql(q quote.Quoter, prefix string) string {
+	columns := ""
+	if len(c.Parent.Columns) > 0 {
+		columns = " (" + quotedList(q, c.Parent.Columns) + ")"
+	}
+	return fmt.Sprintf("foreign key (%s) references %s%s%s%s",
+		quotedList(q, c.ForeignKeyColumns), q.Quote(prefix+c.Parent.TableName), columns,
+		c.Update.Apply(" ", "update"),
+		c.Delete.Apply(" ", "delete"))
+}
+
+func (c CompositeFkConstraint) GoString() string {
+	return fmt.Sprintf(`constraint.CompositeFkConstraint{%#v, constraint.CompositeReference{"%s", %#v}, "%s", "%s"}`,
+		c.ForeignKeyColumns, c.Parent.TableName, c.Parent.Columns, c.Update, c.Delete)
+}
+
+// RelationshipWith constructs the CompositeRelationship that is expressed by the parent reference
+// in the CompositeFkConstraint and the child's foreign key.
+//
+// The table names do not include any prefix.
+func (c CompositeFkConstraint) RelationshipWith(child sqlapi.TableName) CompositeRelationship {
+	return CompositeRelationship{
+		Parent: c.Parent,
+		Child:  CompositeReference{child.Name, c.ForeignKeyColumns},
+	}
+}
+
+func quotedList(q quote.Quoter, columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = q.Quote(c)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// Tuple holds the values of the columns in a composite key, in column order. The values
+// are of whatever types are provided by the database driver, except that text is always
+// a string.
+type Tuple []interface{}
+
+// CompositeRelationship represents a parent-child relationship using composite keys.
+type CompositeRelationship struct {
+	Parent, Child CompositeReference
+}
+
+// KeysUnusedAsForeignKeys finds all the primary keys in the parent table that have no foreign key
+// in the dependent (child) table. The table tbl provides the database or transaction handle; either
+// the parent or the child table can be used for this purpose.
+func (rel CompositeRelationship) KeysUnusedAsForeignKeys(tbl sqlapi.Table) ([]Tuple, error) {
+	if err := rel.check(tbl, "KeysUnusedAsForeignKeys"); err != nil {
+		return nil, err
+	}
+
+	pfx := tbl.Name().Prefix
+	s := fmt.Sprintf(
+		`SELECT %s
+			FROM %s%s a
+			LEFT OUTER JOIN %s%s b ON %s
+			WHERE b.%s IS null`,
+		rel.selection(),
+		pfx, rel.Parent.TableName,
+		pfx, rel.Child.TableName,
+		rel.join(),
+		rel.Child.Columns[0])
+	return fetchTuples(tbl, s, len(rel.Parent.Columns))
+}
+
+// KeysUsedAsForeignKeys finds all the primary keys in the parent table that have at least one foreign key
+// in the dependent (child) table.
+func (rel CompositeRelationship) KeysUsedAsForeignKeys(tbl sqlapi.Table) ([]Tuple, error) {
+	if err := rel.check(tbl, "KeysUsedAsForeignKeys"); err != nil {
+		return nil, err
+	}
+
+	pfx := tbl.Name().Prefix
+	s := fmt.Sprintf(
+		`SELECT DISTINCT %s
+			FROM %s%s a
+			INNER JOIN %s%s b ON %s`,
+		rel.selection(),
+		pfx, rel.Parent.TableName,
+		pfx, rel.Child.TableName,
+		rel.join())
+	return fetchTuples(tbl, s, len(rel.Parent.Columns))
+}
+
+func (rel CompositeRelationship) check(tbl sqlapi.Table, method string) error {
+	if len(rel.Parent.Columns) == 0 || len(rel.Child.Columns) == 0 {
+		return fmt.Errorf("%s: %s requires the column names to be specified", tbl.Name(), method)
+	}
+	if len(rel.Parent.Columns) != len(rel.Child.Columns) {
+		return fmt.Errorf("%s: %s requires the same number of parent and child columns (%d, %d)",
+			tbl.Name(), method, len(rel.Parent.Columns), len(rel.Child.Columns))
+	}
+	return nil
+}
+
+func (rel CompositeRelationship) selection() string {
+	cols := make([]string, len(rel.Parent.Columns))
+	for i, c := range rel.Parent.Columns {
+		cols[i] = "a." + c
+	}
+	return strings.Join(cols, ", ")
+}
+
+func (rel CompositeRelationship) join() string {
+	terms := make([]string, len(rel.Parent.Columns))
+	for i, c := range rel.Parent.Columns {
+		terms[i] = fmt.Sprintf("a.%s = b.%s", c, rel.Child.Columns[i])
+	}
+	return strings.Join(terms, " AND ")
+}
+
+func fetchTuples(tbl sqlapi.Table, query string, width int) ([]Tuple, error) {
+	rows, err := support.Query(tbl, query)
+	if err != nil {
+		return nil, err // already logged
+	}
+	defer rows.Close()
+
+	var list []Tuple
+	for rows.Next() {
+		tuple := make(Tuple, width)
+		ptrs := make([]interface{}, width)
+		for i := range tuple {
+			ptrs[i] = &tuple[i]
+		}
+		if err = rows.Scan(ptrs...); err != nil {
+			return nil, tbl.Logger().LogError(tbl.Ctx(), err)
+		}
+		for i, v := range tuple {
+			if b, ok := v.([]byte); ok {
+				tuple[i] = string(b) // some drivers, e.g. MySQL, provide text as bytes
+			}
+		}
+		list = append(list, tuple)
+	}
+	return list, tbl.Logger().LogIfError(tbl.Ctx(), rows.Err())
+}
@@ -1,20 +1,24 @@
-// Package constraint provides types and methods to support foreign-key relationshipd between database
-// tables.
+// Package constraint provides types and methods to support check constraints and foreign-key
+// relationships between database tables.
 //
-// Only simple keys are supported, which can be integers, strings or any other suitable type.
-// Compound keys are not supported.
+// Simple keys can be integers, strings or any other suitable type. Composite keys, which span
+// several columns, are supported by CompositeFkConstraint.
 package constraint
 
 import (
 	"fmt"
+	"hash/fnv"
+	"io"
 
 	"github.com/rickb777/sqlapi"
+	"github.com/rickb777/sqlapi/schema"
 	"github.com/rickb777/where/quote"
 )
 
 // Constraint represents data that augments the data-definition SQL statements such as CREATE TABLE.
 type Constraint interface {
 	// ConstraintSql constructs the CONSTRAINT clause to be included in the CREATE TABLE.
+	// The constraint is named by ConstraintName; the index is not used.
 	ConstraintSql(q quote.Quoter, name sqlapi.TableName, index int) string
 
 	// Expresses the constraint as a constructor + literals for the API type.
@@ -26,6 +30,22 @@ type Constraint interface {
 // Constraints holds constraints.
 type Constraints []Constraint
 
+// ConstraintsOf gets the foreign key and check constraints declared in the tags
+// of the table's fields.
+func ConstraintsOf(table *schema.TableDescription) Constraints {
+	var cc Constraints
+	for _, field := range table.Fields {
+		tags := field.GetTags()
+		if tags.ForeignKey != "" {
+			cc = append(cc, FkConstraintOfField(field))
+		}
+		if tags.Check != "" {
+			cc = append(cc, CheckConstraintOfField(field))
+		}
+	}
+	return cc
+}
+
 // FkConstraints returns only the foreign key constraints in the Constraints slice.
 func (cc Constraints) FkConstraints() FkConstraints {
 	list := make(FkConstraints, 0, len(cc))
@@ -37,24 +57,51 @@ func (cc Constraints) FkConstraints() FkConstraints {
 	return list
 }
 
+// CompositeFkConstraints returns only the composite foreign key constraints in the Constraints slice.
+func (cc Constraints) CompositeFkConstraints() []CompositeFkConstraint {
+	list := make([]CompositeFkConstraint, 0, len(cc))
+	for _, c := range cc {
+		if fkc, ok := c.(CompositeFkConstraint); ok {
+			list = append(list, fkc)
+		}
+	}
+	return list
+}
+
 //-------------------------------------------------------------------------------------------------
 
 // CheckConstraint holds an expression that refers to table columns and is applied as a precondition
 // whenever a table insert, update or delete is attempted. The CheckConstraint expression is in SQL.
+//
+// All the supported dialects accept check constraints, but note that MySQL before 8.0.16
+// parses them and then silently ignores them.
 type CheckConstraint struct {
 	Expression string
 }
 
+// CheckConstraintOfField constructs a check constraint from a struct field.
+func CheckConstraintOfField(field *schema.Field) CheckConstraint {
+	return CheckConstraint{Expression: field.GetTags().Check}
+}
+
 // ConstraintSql constructs the CONSTRAINT clause to be included in the CREATE TABLE.
 func (c CheckConstraint) ConstraintSql(q quote.Quoter, name sqlapi.TableName, index int) string {
-	return baseConstraintSql(q, name, index, "CHECK (", c.Expression, ")")
+	return baseConstraintSql(q, name, c, "CHECK (", c.Expression, ")")
+}
+
+func baseConstraintSql(q quote.Quoter, name sqlapi.TableName, c Constraint, exp1, exp2, exp3 string) string {
+	return fmt.Sprintf("CONSTRAINT %s %s%s%s", q.Quote(ConstraintName(name, c)), exp1, exp2, exp3)
 }
 
-func baseConstraintSql(q quote.Quoter, name sqlapi.TableName, index int, exp1, exp2, exp3 string) string {
-	id := fmt.Sprintf("%s_c%d", name, index)
-	return fmt.Sprintf("CONSTRAINT %s %s%s%s", q.Quote(id), exp1, exp2, exp3)
+// ConstraintName gets the name given by ConstraintSql to a constraint of a table. This is
+// derived from a hash of the constraint's GoString, so it does not depend on the other
+// constraints and stays the same as they are added or removed.
+func ConstraintName(name sqlapi.TableName, c Constraint) string {
+	h := fnv.New32a()
+	io.WriteString(h, c.GoString())
+	return fmt.Sprintf("%s_c%08x", name, h.Sum32())
 }
 
 func (c CheckConstraint) GoString() string {
-	panic("not implemented")
+	return fmt.Sprintf(`constraint.CheckConstraint{%q}`, c.Expression)
 }
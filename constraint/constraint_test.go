@@ -1,7 +1,12 @@
 package constraint_test
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -12,11 +17,14 @@ import (
 	"github.com/rickb777/expect"
 	"github.com/rickb777/sqlapi"
 	"github.com/rickb777/sqlapi/constraint"
+	"github.com/rickb777/sqlapi/driver"
+	"github.com/rickb777/sqlapi/pgxapi/logadapter"
 	"github.com/rickb777/sqlapi/schema"
 	"github.com/rickb777/sqlapi/support/testenv"
 	"github.com/rickb777/sqlapi/types"
 	"github.com/rickb777/sqlapi/vanilla"
 	"github.com/rickb777/where/quote"
+	_ "modernc.org/sqlite"
 )
 
 var gdb sqlapi.SqlDB
@@ -29,7 +37,28 @@ func TestCheckConstraint(t *testing.T) {
 	persons := vanilla.NewRecordTable("persons", gdb).WithPrefix("constraint_").WithConstraint(cc0)
 	fkc := persons.Constraints()[0]
 	s := fkc.ConstraintSql(quote.AnsiQuoter, persons.Name(), 0)
-	expect.String(s).Info(s).ToBe(t, `CONSTRAINT "constraint_persons_c0" CHECK (role < 3)`)
+	expect.String(s).Info(s).ToBe(t, `CONSTRAINT "constraint_persons_cc8b13ec6" CHECK (role < 3)`)
+}
+
+func TestCheckConstraint_allDialects(t *testing.T) {
+	cc0 := constraint.CheckConstraint{
+		Expression: "status in ('a', 'b')",
+	}
+
+	name := sqlapi.TableName{Prefix: "constraint_", Name: "persons"}
+	for _, di := range driver.AllDialects {
+		s := cc0.ConstraintSql(di.Quoter(), name, 1)
+		expected := "CONSTRAINT " + di.Quoter().Quote("constraint_persons_cadd36308") + " CHECK (status in ('a', 'b'))"
+		expect.String(s).I(di.Name()).ToBe(t, expected)
+	}
+}
+
+func TestCheckConstraint_GoString(t *testing.T) {
+	cc0 := constraint.CheckConstraint{
+		Expression: `name <> "x"`,
+	}
+
+	expect.String(cc0.GoString()).ToBe(t, `constraint.CheckConstraint{"name <> \"x\""}`)
 }
 
 func TestForeignKeyConstraint_withParentColumn(t *testing.T) {
@@ -42,7 +71,7 @@ func TestForeignKeyConstraint_withParentColumn(t *testing.T) {
 	persons := vanilla.NewRecordTable("persons", gdb).WithPrefix("constraint_").WithConstraint(fkc0)
 	fkc := persons.Constraints()[0]
 	s := fkc.ConstraintSql(quote.AnsiQuoter, persons.Name(), 0)
-	expect.String(s).Info(s).ToBe(t, `CONSTRAINT "constraint_persons_c0" foreign key ("addresspk") references "constraint_addresses" ("identity") on update restrict on delete cascade`)
+	expect.String(s).Info(s).ToBe(t, `CONSTRAINT "constraint_persons_c2cc55c95" foreign key ("addresspk") references "constraint_addresses" ("identity") on update restrict on delete cascade`)
 }
 
 func TestForeignKeyConstraint_withoutParentColumn_withoutQuotes(t *testing.T) {
@@ -55,7 +84,7 @@ func TestForeignKeyConstraint_withoutParentColumn_withoutQuotes(t *testing.T) {
 	persons := vanilla.NewRecordTable("persons", gdb).WithPrefix("constraint_").WithConstraint(fkc0)
 	fkc := persons.Constraints().FkConstraints()[0]
 	s := fkc.ConstraintSql(quote.NoQuoter, persons.Name(), 0)
-	expect.String(s).Info(s).ToBe(t, `CONSTRAINT constraint_persons_c0 foreign key (addresspk) references constraint_addresses on update restrict on delete cascade`)
+	expect.String(s).Info(s).ToBe(t, `CONSTRAINT constraint_persons_c43b1d68b foreign key (addresspk) references constraint_addresses on update restrict on delete cascade`)
 }
 
 func TestIdsUsedAsForeignKeys(t *testing.T) {
@@ -82,6 +111,64 @@ func TestIdsUsedAsForeignKeys(t *testing.T) {
 	expect.Slice(m2.Slice()).ToContainAll(t, aid3, aid4)
 }
 
+func TestCompositeFkConstraint(t *testing.T) {
+	fkc0 := constraint.CompositeFkConstraintOn("org", "code").
+		RefersTo("groups", "org", "code").
+		OnDelete(constraint.Cascade)
+
+	members := vanilla.NewRecordTable("members", gdb).WithPrefix("constraint_").WithConstraint(fkc0)
+	fkc := members.Constraints().CompositeFkConstraints()[0]
+	s := fkc.ConstraintSql(quote.AnsiQuoter, members.Name(), 0)
+	expect.String(s).Info(s).ToBe(t, `CONSTRAINT "constraint_members_c9ef0782e" foreign key ("org", "code") references "constraint_groups" ("org", "code") on delete cascade`)
+
+	expect.String(fkc.GoString()).ToBe(t, `constraint.CompositeFkConstraint{[]string{"org", "code"}, constraint.CompositeReference{"groups", []string{"org", "code"}}, "", "cascade"}`)
+}
+
+func TestKeysUsedAsForeignKeys(t *testing.T) {
+	insertCompositeFixtures(t, gdb)
+
+	fkc0 := constraint.CompositeFkConstraintOn("org", "code").RefersTo("groups", "org", "code")
+	members := vanilla.NewRecordTable("members", gdb).WithPrefix("constraint_").WithConstraint(fkc0)
+	rel := members.Constraints().CompositeFkConstraints()[0].RelationshipWith(members.Name())
+
+	used, err := rel.KeysUsedAsForeignKeys(members)
+	expect.Error(err).ToBeNil(t)
+	expect.Slice(tupleStrings(used)).ToContainAll(t, "1/2", "2/1")
+	expect.Slice(used).ToHaveLength(t, 2)
+
+	unused, err := rel.KeysUnusedAsForeignKeys(members)
+	expect.Error(err).ToBeNil(t)
+	expect.Slice(tupleStrings(unused)).ToBe(t, "1/1")
+
+	_, err = constraint.CompositeRelationship{}.KeysUsedAsForeignKeys(members)
+	expect.Error(err).ToContain(t, "requires the column names to be specified")
+}
+
+func TestKeysUsedAsForeignKeys_errorLoggedOnce(t *testing.T) {
+	ctx := context.Background()
+	buf := &bytes.Buffer{}
+	lgr := sqlapi.NewLogger(logadapter.NewLogger(log.New(buf, "", 0)))
+	db, err := sqlapi.Connect(ctx, "sqlite3", filepath.Join(t.TempDir(), "fk.db"), driver.Sqlite(), lgr, 1)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	defer db.Close()
+
+	fkc0 := constraint.CompositeFkConstraintOn("org", "code").RefersTo("groups", "org", "code")
+	members := vanilla.NewRecordTable("members", db).WithPrefix("constraint_").WithConstraint(fkc0)
+	rel := members.Constraints().CompositeFkConstraints()[0].RelationshipWith(members.Name())
+
+	_, err = rel.KeysUsedAsForeignKeys(members)
+	expect.Error(err).ToContain(t, "no such table")
+	expect.Number(strings.Count(buf.String(), "no such table")).Info(buf.String()).ToBe(t, 1)
+}
+
+func tupleStrings(tuples []constraint.Tuple) []string {
+	ss := make([]string, len(tuples))
+	for i, tuple := range tuples {
+		ss[i] = fmt.Sprintf("%v/%v", tuple...)
+	}
+	return ss
+}
+
 func TestFkConstraintOfField(t *testing.T) {
 	i64 := schema.Type{Name: "int64", Base: types.Int64}
 	field := &schema.Field{
@@ -106,6 +193,25 @@ func TestFkConstraintOfField(t *testing.T) {
 	})
 }
 
+func TestConstraintsOf(t *testing.T) {
+	i64 := schema.Type{Name: "int64", Base: types.Int64}
+	table := &schema.TableDescription{
+		Name: "persons",
+		Fields: schema.FieldList{
+			{Node: schema.Node{Name: "Id", Type: i64}, SqlName: "id", Tags: &types.Tag{Primary: true}},
+			{Node: schema.Node{Name: "Age", Type: i64}, SqlName: "age", Tags: &types.Tag{Check: "age >= 0"}},
+			{Node: schema.Node{Name: "Cat", Type: i64}, SqlName: "cat", Tags: &types.Tag{ForeignKey: "something", Check: "cat > 0"}},
+		},
+	}
+
+	cc := constraint.ConstraintsOf(table)
+	expect.Slice(cc).ToBe(t,
+		constraint.CheckConstraint{Expression: "age >= 0"},
+		constraint.FkConstraint{ForeignKeyColumn: "cat", Parent: constraint.Reference{TableName: "something"}},
+		constraint.CheckConstraint{Expression: "cat > 0"},
+	)
+}
+
 //-------------------------------------------------------------------------------------------------
 
 func TestMain(m *testing.M) {
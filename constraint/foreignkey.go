@@ -85,7 +85,7 @@ func (c FkConstraint) OnDelete(consequence Consequence) FkConstraint {
 
 // ConstraintSql constructs the CONSTRAINT clause to be included in the CREATE TABLE.
 func (c FkConstraint) ConstraintSql(q quote.Quoter, name sqlapi.TableName, index int) string {
-	return baseConstraintSql(q, name, index, c.sql(q, name.Prefix), "", "")
+	return baseConstraintSql(q, name, c, c.sql(q, name.Prefix), "", "")
 }
 
 // Column constructs the foreign key clause needed to configure the database.
@@ -130,7 +130,7 @@ func (c FkConstraint) RelationshipWith(child sqlapi.TableName) Relationship {
 //-------------------------------------------------------------------------------------------------
 
 // Relationship represents a parent-child relationship.
-// Only simple keys are supported (compound keys are not supported).
+// Only simple keys are supported; see CompositeRelationship for composite keys.
 type Relationship struct {
 	Parent, Child Reference
 }
@@ -3,6 +3,7 @@ package sqlapi
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"log"
@@ -14,10 +15,15 @@ import (
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jackc/pgx/v5/tracelog"
 	_ "github.com/lib/pq"
+	_ "github.com/marcboeker/go-duckdb"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/rickb777/expect"
+	"github.com/rickb777/sqlapi/driver"
 	"github.com/rickb777/sqlapi/pgxapi/logadapter"
+	"github.com/rickb777/sqlapi/schema"
 	"github.com/rickb777/sqlapi/support/testenv"
+	"github.com/rickb777/sqlapi/types"
+	_ "modernc.org/sqlite"
 )
 
 var gdb SqlDB
@@ -96,6 +102,40 @@ func TestQueryContext(t *testing.T) {
 	expect.Bool(rows.Next()).Not().ToBeTrue(t)
 }
 
+func TestQueryRowNamedParameters(t *testing.T) {
+	_, aid2, _, _ := insertFixtures(t, gdb)
+
+	row := gdb.QueryRow(context.Background(), "select xlines from pfx_addresses where id=:id or id=:id", sql.Named("id", aid2))
+
+	var xlines string
+	err := row.Scan(&xlines)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.String(xlines).ToBe(t, "2 Nutmeg Lane")
+
+	row = gdb.QueryRow(context.Background(), "select xlines from pfx_addresses where id=:id", map[string]any{"x": aid2})
+	err = row.Scan(&xlines)
+	expect.Error(err).ToContain(t, `named parameter "id" has no value`)
+}
+
+func TestQueryPlaceholderCount(t *testing.T) {
+	_, aid2, _, _ := insertFixtures(t, gdb)
+
+	q := gdb.Dialect().ReplacePlaceholders("select xlines from pfx_addresses where id=? and xlines <> '?'", nil)
+	_, err := gdb.Query(context.Background(), q, aid2, "extra")
+
+	var pce *PlaceholderCountError
+	expect.Bool(errors.As(err, &pce)).ToBeTrue(t)
+	expect.Number(pce.Expected).ToBe(t, 1)
+	expect.Number(pce.Actual).ToBe(t, 2)
+	expect.String(pce.Query).ToBe(t, q)
+
+	rows, err := gdb.CheckArgCount(false).Query(context.Background(), q, aid2, "extra")
+	expect.Bool(errors.As(err, &pce)).Not().ToBeTrue(t)
+	if err == nil {
+		rows.Close() // some drivers ignore the extra argument
+	}
+}
+
 func TestSingleConnQuery(t *testing.T) {
 	ctx := context.Background()
 	_, aid2, _, _ := insertFixtures(t, gdb)
@@ -117,6 +157,38 @@ func TestSingleConnQuery(t *testing.T) {
 	expect.Error(e2).Not().ToHaveOccurred(t)
 }
 
+func TestExecScript(t *testing.T) {
+	ctx := context.Background()
+	insertFixtures(t, gdb)
+
+	const script = `-- two more addresses
+INSERT INTO pfx_addresses (xlines, postcode) VALUES ('1 Semi;Colon Row', 'SC1 1AA');
+
+/* the second; */
+INSERT INTO pfx_addresses (xlines, postcode) VALUES ('2 Semi;Colon Row', 'SC1 1AB');
+`
+	err := ExecScript(ctx, gdb, script)
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	var count int
+	err = gdb.QueryRow(ctx, "select count(1) from pfx_addresses where postcode like 'SC1%'").Scan(&count)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Number(count).ToBe(t, 2)
+
+	err = ExecScript(ctx, gdb, "DELETE FROM pfx_addresses WHERE postcode = 'SC1 1AA';\n\nDELETE FROM pfx_no_such_table;\nSELECT 1;")
+	var se *ScriptError
+	expect.Bool(errors.As(err, &se)).ToBeTrue(t)
+	expect.Number(se.Index).ToBe(t, 2)
+	expect.Number(se.Line).ToBe(t, 3)
+	expect.String(se.Statement).ToBe(t, "DELETE FROM pfx_no_such_table")
+	expect.Error(se.Err).ToHaveOccurred(t)
+
+	// the statements are executed verbatim, so a '?' is not checked against the arguments
+	err = ExecScript(ctx, gdb, "SELECT 1 WHERE ? IS NULL")
+	var pce *PlaceholderCountError
+	expect.Bool(errors.As(err, &pce)).Info(err).ToBeFalse(t)
+}
+
 func TestTransactCommitUsingInsert(t *testing.T) {
 	ctx := context.Background()
 	insertFixtures(t, gdb)
@@ -180,6 +252,31 @@ func TestTransactRollback(t *testing.T) {
 	expect.Number(count).ToBe(t, 4)
 }
 
+func TestCompositePrimaryKey(t *testing.T) {
+	ctx := context.Background()
+	i64 := schema.Type{Name: "int64", Base: types.Int64}
+	userId := &schema.Field{Node: schema.Node{Name: "UserId", Type: i64}, SqlName: "user_id", Tags: &types.Tag{Primary: true}}
+	groupId := &schema.Field{Node: schema.Node{Name: "GroupId", Type: i64}, SqlName: "group_id", Tags: &types.Tag{Primary: true}}
+	table := &schema.TableDescription{Name: "pfx_memberships", Fields: schema.FieldList{userId, groupId}}
+	table.SetPrimaryKey(userId, groupId)
+
+	q := gdb.Dialect().Quoter()
+	_, err := gdb.Exec(ctx, "DROP TABLE IF EXISTS "+q.Quote(table.Name))
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	ddl := fmt.Sprintf("CREATE TABLE %s (%s)", q.Quote(table.Name), strings.Join(driver.ColumnDefinitions(gdb.Dialect(), table.Name, table), ", "))
+	_, err = gdb.Exec(ctx, ddl)
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	insert := gdb.Dialect().ReplacePlaceholders("INSERT INTO pfx_memberships (user_id, group_id) VALUES (?, ?)", nil)
+	_, err = gdb.Exec(ctx, insert, 1, 1)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	_, err = gdb.Exec(ctx, insert, 1, 2)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	_, err = gdb.Exec(ctx, insert, 1, 2)
+	expect.Error(err).ToHaveOccurred(t)
+}
+
 func TestUserItemWrapper(t *testing.T) {
 	d2 := gdb.With("hello")
 	expect.Any(gdb.UserItem()).ToBeNil(t)
@@ -0,0 +1,222 @@
+package ddl
+
+import (
+	"strings"
+
+	"github.com/rickb777/sqlapi"
+	"github.com/rickb777/sqlapi/driver"
+	"github.com/rickb777/sqlapi/schema"
+	"github.com/rickb777/where/dialect"
+)
+
+// Alter gets the statements that migrate a table from one description to another. Either
+// description may have been read from the live database using the introspect package.
+//
+// Columns are matched by their SQL names; a renamed column is treated as one column dropped
+// and another added. Indexes are matched by their columns and uniqueness. Constraints are
+// matched by their GoString representations and are named by constraint.ConstraintName,
+// i.e. they are assumed to have been created by ConstraintSql. The names depend only on the
+// constraints themselves, so a series of Alters can be applied one after another.
+//
+// The statements are, in order: constraints and indexes that are no longer needed are
+// dropped, then columns are dropped, added and altered, then the new indexes and constraints
+// are added. Dropping a column and changing its type are marked as destructive.
+//
+// SQLite supports few forms of ALTER TABLE, so any change except adding nullable columns,
+// columns with default values and indexes is made by rebuilding the table: a new table is
+// created, the data is copied into it, the old table is dropped and the new table is renamed.
+// This also happens for DuckDB when constraints are changed and for all dialects when the
+// primary key is changed. For SQLite, the rebuild disables foreign key enforcement while it
+// runs; this has no effect inside a transaction, so it must be run outside one, e.g. using
+// Steps.Exec with an SqlDB rather than an SqlTx. Inside a transaction, dropping the old table
+// fails if other tables have rows that refer to it. Note that the migrate package runs each
+// SQLite migration in a transaction.
+//
+// The result is empty if there is nothing to change.
+func Alter(di driver.Dialect, name sqlapi.TableName, from, to Table) Steps {
+	ch := compare(di, name, from, to)
+	if ch.needsRebuild(di, name, to) {
+		return rebuild(di, name, from, to, ch)
+	}
+
+	a := &alteration{di: di, name: name, table: di.Quoter().Quote(name.String())}
+
+	for _, i := range ch.droppedConstraints {
+		a.dropConstraint(from, i)
+	}
+	for _, ix := range ch.droppedIndexes {
+		a.steps = append(a.steps, DropIndex(di, name, ix))
+	}
+	for _, f := range ch.dropped {
+		a.dropColumn(from.Description, f)
+	}
+	for _, f := range ch.added {
+		a.addColumn(to.Description, f)
+	}
+	for _, c := range ch.altered {
+		a.alterColumn(c)
+	}
+	for _, ix := range ch.addedIndexes {
+		a.steps = append(a.steps, CreateIndex(di, name, ix))
+	}
+	for _, i := range ch.addedConstraints {
+		a.addConstraint(to, i)
+	}
+
+	return a.steps
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// changes lists the differences between two table descriptions.
+type changes struct {
+	dropped, added               []*schema.Field
+	altered                      []columnChange
+	droppedIndexes, addedIndexes []*schema.Index
+	droppedConstraints           []int // positions in the old list of constraints
+	addedConstraints             []int // positions in the new list of constraints
+	primaryKey                   bool  // the primary key has changed
+}
+
+type columnChange struct {
+	from, to        *schema.Field
+	fromCol, toCol  driver.Column
+	typ, null, dflt bool
+}
+
+func (ch *changes) hasTypeChanges() bool {
+	for _, c := range ch.altered {
+		if c.typ {
+			return true
+		}
+	}
+	return false
+}
+
+func (ch *changes) needsRebuild(di driver.Dialect, name sqlapi.TableName, to Table) bool {
+	if ch.primaryKey {
+		return true
+	}
+
+	constraintsChanged := len(ch.droppedConstraints) > 0 || len(ch.addedConstraints) > 0
+	if driver.IsDuckDB(di) {
+		return constraintsChanged // DuckDB cannot add or drop constraints
+	}
+
+	if di.Index() == dialect.Sqlite {
+		if constraintsChanged || len(ch.dropped) > 0 || len(ch.altered) > 0 {
+			return true
+		}
+		for _, f := range ch.added {
+			col := columnOf(di, name, to.Description, f)
+			if !isNullable(col) && col.Default == "" {
+				return true // SQLite cannot add a NOT NULL column without a default value
+			}
+		}
+	}
+	return false
+}
+
+func compare(di driver.Dialect, name sqlapi.TableName, from, to Table) *changes {
+	ch := &changes{}
+
+	ch.primaryKey = from.Description.PrimaryKeyFields().SqlNames().MkString(",") !=
+		to.Description.PrimaryKeyFields().SqlNames().MkString(",")
+
+	oldFields := fieldsBySqlName(from.Description)
+	newFields := fieldsBySqlName(to.Description)
+
+	for _, f := range from.Description.Fields {
+		if _, exists := newFields[f.SqlName]; !exists && !f.Skip() {
+			ch.dropped = append(ch.dropped, f)
+		}
+	}
+
+	for _, f := range to.Description.Fields {
+		if f.Skip() {
+			continue
+		}
+		old, exists := oldFields[f.SqlName]
+		if !exists {
+			ch.added = append(ch.added, f)
+			continue
+		}
+
+		c := columnChange{from: old, to: f, fromCol: columnOf(di, name, from.Description, old), toCol: columnOf(di, name, to.Description, f)}
+		c.typ = !driver.SameType(di, c.fromCol.Type, c.toCol.Type)
+		c.null = isNullable(c.fromCol) != isNullable(c.toCol)
+		c.dflt = c.fromCol.Default != c.toCol.Default
+		if c.typ || c.null || c.dflt {
+			ch.altered = append(ch.altered, c)
+		}
+	}
+
+	ch.droppedIndexes = indexesNotIn(from.Description.Index, to.Description.Index)
+	ch.addedIndexes = indexesNotIn(to.Description.Index, from.Description.Index)
+
+	//---------- constraints ----------
+
+	newConstraints := make(map[string]bool)
+	for _, c := range to.Constraints {
+		newConstraints[c.GoString()] = true
+	}
+
+	oldConstraints := make(map[string]bool)
+	for i, c := range from.Constraints {
+		oldConstraints[c.GoString()] = true
+		if !newConstraints[c.GoString()] {
+			ch.droppedConstraints = append(ch.droppedConstraints, i)
+		}
+	}
+
+	for i, c := range to.Constraints {
+		if !oldConstraints[c.GoString()] {
+			ch.addedConstraints = append(ch.addedConstraints, i)
+		}
+	}
+
+	return ch
+}
+
+func fieldsBySqlName(table *schema.TableDescription) map[string]*schema.Field {
+	m := make(map[string]*schema.Field)
+	for _, f := range table.Fields {
+		m[f.SqlName] = f
+	}
+	return m
+}
+
+// indexesNotIn finds the indexes in a that have no counterpart in b.
+func indexesNotIn(a, b []*schema.Index) []*schema.Index {
+	var list []*schema.Index
+	for _, ix := range a {
+		found := false
+		for _, other := range b {
+			if ix.Unique == other.Unique && ix.Columns() == other.Columns() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, ix)
+		}
+	}
+	return list
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// columnOf gets the column for a field, without any inline primary key declaration if the
+// primary key is composite.
+func columnOf(di driver.Dialect, name sqlapi.TableName, table *schema.TableDescription, f *schema.Field) driver.Column {
+	return driver.ColumnOf(di, name.String(), table, f)
+}
+
+// isNullable is true if the column can hold null; a primary key column cannot.
+func isNullable(col driver.Column) bool {
+	return col.Nullable && !col.PrimaryKey
+}
+
+func ansiString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
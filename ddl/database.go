@@ -0,0 +1,317 @@
+package ddl
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rickb777/sqlapi"
+	"github.com/rickb777/sqlapi/constraint"
+	"github.com/rickb777/sqlapi/driver"
+	"github.com/rickb777/where/dialect"
+)
+
+// Execer executes statements. Both sqlapi.Execer and pgxapi.Execer satisfy it.
+type Execer interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (int64, error)
+	Dialect() driver.Dialect
+}
+
+// Exec executes the steps in order, stopping at the first that fails.
+func (ss Steps) Exec(ctx context.Context, ex Execer) error {
+	for _, s := range ss {
+		if _, err := ex.Exec(ctx, s.SQL); err != nil {
+			return fmt.Errorf("%w: %s", err, s.SQL)
+		}
+	}
+	return nil
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// Database is a registry of tables that are created, dropped and truncated together. The
+// tables are ordered so that each table follows the tables its foreign keys refer to, which
+// is determined from the parent table names in the FkConstraint and CompositeFkConstraint
+// constraints. A parent is matched using the child's prefix, as for ConstraintSql; parents
+// that are not registered are ignored, as are self-references.
+//
+// If the foreign keys form a cycle, the tables cannot all be created in order. Then, the
+// foreign keys that close the cycle are omitted from CREATE TABLE and added afterwards with
+// ALTER TABLE. SQLite does not need this because it allows a foreign key to refer to a table
+// that does not yet exist; DuckDB does not allow either, so cycles are an error for DuckDB.
+//
+// The zero value is an empty registry.
+type Database struct {
+	tables []entry
+}
+
+type entry struct {
+	name  sqlapi.TableName
+	table Table
+}
+
+// Add registers a table, replacing any table already registered with the same name.
+func (db *Database) Add(name sqlapi.TableName, table Table) *Database {
+	for i, e := range db.tables {
+		if e.name == name {
+			db.tables[i].table = table
+			return db
+		}
+	}
+	db.tables = append(db.tables, entry{name: name, table: table})
+	return db
+}
+
+// Tables gets the names of the tables in the order in which they are created, i.e. parent
+// tables before their children. Where the order is not constrained by foreign keys, the
+// tables are in the order they were added.
+func (db *Database) Tables() []sqlapi.TableName {
+	order, _ := db.plan()
+	names := make([]sqlapi.TableName, len(order))
+	for i, t := range order {
+		names[i] = db.tables[t].name
+	}
+	return names
+}
+
+// CreateSteps gets the statements that create all the tables and their indexes. An error
+// is returned if any table is not valid (see schema.TableDescription.Validate).
+func (db *Database) CreateSteps(di driver.Dialect) (Steps, error) {
+	for _, e := range db.tables {
+		if err := e.table.Description.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	order, deferred := db.plan()
+	if di.Index() == dialect.Sqlite {
+		deferred = nil
+	} else if len(deferred) > 0 && driver.IsDuckDB(di) {
+		return nil, fmt.Errorf("the foreign keys of %s form a cycle, which DuckDB does not support", db.cyclic(deferred))
+	}
+
+	var steps Steps
+	for _, t := range order {
+		e := db.tables[t]
+		steps = append(steps, createTable(di, e.name, e.table, deferred[t])...)
+	}
+
+	for _, t := range order {
+		e := db.tables[t]
+		a := &alteration{di: di, name: e.name, table: di.Quoter().Quote(e.name.String())}
+		for _, i := range sortedKeys(deferred[t]) {
+			a.addConstraint(e.table, i)
+		}
+		steps = append(steps, a.steps...)
+	}
+	return steps, nil
+}
+
+// DropSteps gets the statements that drop all the tables, children before their parents.
+// Any foreign keys that were added after the tables were created are dropped first; this
+// expects the tables to exist.
+func (db *Database) DropSteps(di driver.Dialect) Steps {
+	order, deferred := db.plan()
+	if di.Index() == dialect.Sqlite || driver.IsDuckDB(di) {
+		deferred = nil
+	}
+
+	var steps Steps
+	for _, t := range order {
+		e := db.tables[t]
+		a := &alteration{di: di, name: e.name, table: di.Quoter().Quote(e.name.String())}
+		for _, i := range sortedKeys(deferred[t]) {
+			a.dropConstraint(e.table, i)
+		}
+		steps = append(steps, a.steps...)
+	}
+
+	for i := len(order) - 1; i >= 0; i-- {
+		steps = append(steps, DropTable(di, db.tables[order[i]].name))
+	}
+	return steps
+}
+
+// TruncateSteps gets the statements that empty all the tables, children before their parents.
+// For PostgreSQL, this is a single TRUNCATE statement because it cannot truncate a table
+// that other tables refer to unless they are truncated by the same statement. For the other
+// dialects, the statements are given by driver.Dialect.TruncateDDL; MySQL and SQL Server
+// cannot truncate tables that are referred to by foreign keys unless force is true.
+func (db *Database) TruncateSteps(di driver.Dialect, force bool) Steps {
+	order, _ := db.plan()
+	var steps Steps
+
+	if di.Index() == dialect.Postgres && !driver.IsDuckDB(di) {
+		if len(order) == 0 {
+			return nil
+		}
+		names := make([]string, len(order))
+		for i, t := range order {
+			names[i] = di.Quoter().Quote(db.tables[t].name.String())
+		}
+		option := " RESTRICT"
+		if force {
+			option = " CASCADE"
+		}
+		return Steps{{SQL: "TRUNCATE " + strings.Join(names, ", ") + option, Destructive: true}}
+	}
+
+	for i := len(order) - 1; i >= 0; i-- {
+		for _, s := range di.TruncateDDL(db.tables[order[i]].name.String(), force) {
+			steps = append(steps, Step{SQL: s, Destructive: true})
+		}
+	}
+	return steps
+}
+
+// CreateAll creates all the tables and their indexes.
+func (db *Database) CreateAll(ctx context.Context, ex Execer) error {
+	steps, err := db.CreateSteps(ex.Dialect())
+	if err != nil {
+		return err
+	}
+	return steps.Exec(ctx, ex)
+}
+
+// DropAll drops all the tables.
+func (db *Database) DropAll(ctx context.Context, ex Execer) error {
+	return db.DropSteps(ex.Dialect()).Exec(ctx, ex)
+}
+
+// TruncateAll empties all the tables. See TruncateSteps.
+func (db *Database) TruncateAll(ctx context.Context, ex Execer, force bool) error {
+	return db.TruncateSteps(ex.Dialect(), force).Exec(ctx, ex)
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// dependency is a foreign key from one registered table to another.
+type dependency struct {
+	constraint int // position in the child's constraints
+	parent     int // position in db.tables
+}
+
+func (db *Database) dependencies() [][]dependency {
+	index := make(map[sqlapi.TableName]int, len(db.tables))
+	for i, e := range db.tables {
+		index[e.name] = i
+	}
+
+	deps := make([][]dependency, len(db.tables))
+	for i, e := range db.tables {
+		for j, c := range e.table.Constraints {
+			var parent string
+			switch fk := c.(type) {
+			case constraint.FkConstraint:
+				parent = fk.Parent.TableName
+			case constraint.CompositeFkConstraint:
+				parent = fk.Parent.TableName
+			default:
+				continue
+			}
+
+			if p, exists := index[sqlapi.TableName{Prefix: e.name.Prefix, Name: parent}]; exists && p != i {
+				deps[i] = append(deps[i], dependency{constraint: j, parent: p})
+			}
+		}
+	}
+	return deps
+}
+
+// plan orders the tables so that parents precede their children, preferring the order in
+// which they were added. If there is a cycle, the foreign keys of the first table in the
+// cycle that lead back to it are deferred, which breaks the cycle; a table is only placed
+// once all its parents have been placed or their foreign keys deferred. The
+// deferred constraints are keyed by table then constraint position.
+func (db *Database) plan() (order []int, deferred map[int]map[int]bool) {
+	deps := db.dependencies()
+	placed := make([]bool, len(db.tables))
+	deferred = make(map[int]map[int]bool)
+
+	ready := func(t int) bool {
+		for _, d := range deps[t] {
+			if !placed[d.parent] && !deferred[t][d.constraint] {
+				return false
+			}
+		}
+		return true
+	}
+
+	// reaches tests whether a table depends on another, directly or indirectly, via
+	// foreign keys between tables not yet placed
+	reaches := func(from, to int) bool {
+		seen := make([]bool, len(db.tables))
+		stack := []int{from}
+		for len(stack) > 0 {
+			t := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for _, d := range deps[t] {
+				if placed[d.parent] || deferred[t][d.constraint] {
+					continue
+				}
+				if d.parent == to {
+					return true
+				}
+				if !seen[d.parent] {
+					seen[d.parent] = true
+					stack = append(stack, d.parent)
+				}
+			}
+		}
+		return false
+	}
+
+	for len(order) < len(db.tables) {
+		next := -1
+		for t := range db.tables {
+			if !placed[t] && ready(t) {
+				next = t
+				break
+			}
+		}
+
+		if next < 0 {
+			// every remaining table is in, or depends on, a cycle; the first table that is
+			// in a cycle defers the foreign keys that lead back to it. It is not placed yet
+			// because it may also depend on tables outside the cycle, including other cycles.
+			for t := range db.tables {
+				if !placed[t] && reaches(t, t) {
+					next = t
+					break
+				}
+			}
+			if deferred[next] == nil {
+				deferred[next] = make(map[int]bool)
+			}
+			for _, d := range deps[next] {
+				if !placed[d.parent] && reaches(d.parent, next) {
+					deferred[next][d.constraint] = true
+				}
+			}
+			continue
+		}
+
+		placed[next] = true
+		order = append(order, next)
+	}
+
+	return order, deferred
+}
+
+func (db *Database) cyclic(deferred map[int]map[int]bool) string {
+	var names []string
+	for _, t := range sortedKeys(deferred) {
+		names = append(names, db.tables[t].name.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+func sortedKeys[V any](m map[int]V) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
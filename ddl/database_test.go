@@ -0,0 +1,188 @@
+package ddl_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/rickb777/expect"
+	"github.com/rickb777/sqlapi"
+	"github.com/rickb777/sqlapi/constraint"
+	"github.com/rickb777/sqlapi/ddl"
+	"github.com/rickb777/sqlapi/driver"
+	"github.com/rickb777/sqlapi/schema"
+	"github.com/rickb777/sqlapi/types"
+)
+
+// simpleTable has an id primary key and int64 columns with foreign keys to the named tables.
+func simpleTable(name string, parents ...string) ddl.Table {
+	id := field("id", i64, types.Tag{Primary: true})
+	table := ddl.Table{Description: &schema.TableDescription{Name: name, Primary: id, Fields: schema.FieldList{id}}}
+	for _, p := range parents {
+		table.Description.Fields = append(table.Description.Fields, field(p+"_id", i64, types.Tag{}))
+		table.Constraints = append(table.Constraints, constraint.FkConstraintOn(p+"_id").RefersTo(p, "id"))
+	}
+	return table
+}
+
+func shopDatabase(prefix string) *ddl.Database {
+	db := &ddl.Database{}
+	return db.
+		Add(sqlapi.TableName{Prefix: prefix, Name: "items"}, simpleTable("items", "orders", "products")).
+		Add(sqlapi.TableName{Prefix: prefix, Name: "orders"}, simpleTable("orders", "customers")).
+		Add(sqlapi.TableName{Prefix: prefix, Name: "products"}, simpleTable("products")).
+		Add(sqlapi.TableName{Prefix: prefix, Name: "customers"}, simpleTable("customers", "regions"))
+}
+
+func names(list []sqlapi.TableName) []string {
+	ss := make([]string, len(list))
+	for i, n := range list {
+		ss[i] = n.String()
+	}
+	return ss
+}
+
+func TestDatabase_order(t *testing.T) {
+	db := shopDatabase("p_")
+	expect.Slice(names(db.Tables())).ToBe(t, "p_products", "p_customers", "p_orders", "p_items")
+
+	steps := db.DropSteps(driver.Postgres())
+	expect.Slice(steps.Statements()).ToBe(t,
+		`DROP TABLE IF EXISTS "p_items"`,
+		`DROP TABLE IF EXISTS "p_orders"`,
+		`DROP TABLE IF EXISTS "p_customers"`,
+		`DROP TABLE IF EXISTS "p_products"`)
+
+	steps = db.TruncateSteps(driver.Postgres(), false)
+	expect.Slice(steps.Statements()).ToBe(t, `TRUNCATE "p_products", "p_customers", "p_orders", "p_items" RESTRICT`)
+
+	steps = db.TruncateSteps(driver.Mysql(), true)
+	expect.Slice(steps.Statements()).ToHaveLength(t, 12)
+	expect.String(steps[1].SQL).ToBe(t, "TRUNCATE `p_items`")
+}
+
+func TestDatabase_cycle(t *testing.T) {
+	db := &ddl.Database{}
+	db.Add(sqlapi.TableName{Prefix: "p_", Name: "offices"}, simpleTable("offices", "employees")).
+		Add(sqlapi.TableName{Prefix: "p_", Name: "employees"}, simpleTable("employees", "depts")).
+		Add(sqlapi.TableName{Prefix: "p_", Name: "depts"}, simpleTable("depts", "employees", "depts"))
+
+	expect.Slice(names(db.Tables())).ToBe(t, "p_employees", "p_offices", "p_depts")
+
+	steps, err := db.CreateSteps(driver.Postgres())
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Slice(steps.Statements()).ToBe(t,
+		`CREATE TABLE "p_employees" (
+	"id" bigint not null primary key,
+	"depts_id" bigint not null
+)`,
+		`CREATE TABLE "p_offices" (
+	"id" bigint not null primary key,
+	"employees_id" bigint not null,
+	CONSTRAINT "p_offices_c0c97afb9" foreign key ("employees_id") references "p_employees" ("id")
+)`,
+		`CREATE TABLE "p_depts" (
+	"id" bigint not null primary key,
+	"employees_id" bigint not null,
+	"depts_id" bigint not null,
+	CONSTRAINT "p_depts_c0c97afb9" foreign key ("employees_id") references "p_employees" ("id"),
+	CONSTRAINT "p_depts_c5412278b" foreign key ("depts_id") references "p_depts" ("id")
+)`,
+		`ALTER TABLE "p_employees" ADD CONSTRAINT "p_employees_c5412278b" foreign key ("depts_id") references "p_depts" ("id")`)
+
+	expect.Slice(db.DropSteps(driver.Postgres()).Statements()).ToBe(t,
+		`ALTER TABLE "p_employees" DROP CONSTRAINT "p_employees_c5412278b"`,
+		`DROP TABLE IF EXISTS "p_depts"`,
+		`DROP TABLE IF EXISTS "p_offices"`,
+		`DROP TABLE IF EXISTS "p_employees"`)
+
+	steps, err = db.CreateSteps(driver.Sqlite())
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Slice(steps).ToHaveLength(t, 3)
+
+	_, err = db.CreateSteps(driver.DuckDB())
+	expect.Error(err).ToContain(t, "p_employees")
+}
+
+func TestDatabase_cycleWithOtherParents(t *testing.T) {
+	// a is in a cycle with b and also depends on c, which is in a separate cycle with d
+	db := &ddl.Database{}
+	db.Add(sqlapi.TableName{Name: "a"}, simpleTable("a", "b", "c")).
+		Add(sqlapi.TableName{Name: "b"}, simpleTable("b", "a")).
+		Add(sqlapi.TableName{Name: "c"}, simpleTable("c", "d")).
+		Add(sqlapi.TableName{Name: "d"}, simpleTable("d", "c"))
+
+	expect.Slice(names(db.Tables())).ToBe(t, "c", "a", "b", "d")
+
+	steps, err := db.CreateSteps(driver.Postgres())
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	// every foreign key refers to a table that has already been created
+	created := map[string]bool{}
+	createRe := regexp.MustCompile(`^CREATE TABLE "(\w+)"`)
+	referencesRe := regexp.MustCompile(`references "(\w+)"`)
+	for _, s := range steps.Statements() {
+		if m := createRe.FindStringSubmatch(s); m != nil {
+			created[m[1]] = true
+		}
+		for _, m := range referencesRe.FindAllStringSubmatch(s, -1) {
+			expect.Bool(created[m[1]]).Info(s).ToBeTrue(t)
+		}
+	}
+	expect.Slice(steps.Statements()).ToHaveLength(t, 6)
+	expect.String(steps[4].SQL).ToBe(t, `ALTER TABLE "c" ADD CONSTRAINT "c_c44f57947" foreign key ("d_id") references "d" ("id")`)
+	expect.String(steps[5].SQL).ToBe(t, `ALTER TABLE "a" ADD CONSTRAINT "a_c46865cc3" foreign key ("b_id") references "b" ("id")`)
+}
+
+func TestDatabase_invalidDefault(t *testing.T) {
+	table := simpleTable("things")
+	table.Description.Fields = append(table.Description.Fields, field("count", i64, types.Tag{Default: "1.5"}))
+
+	db := (&ddl.Database{}).Add(sqlapi.TableName{Name: "things"}, table)
+	_, err := db.CreateSteps(driver.Postgres())
+	expect.Error(err).ToContain(t, "table things: count: default")
+}
+
+func TestDatabase_database(t *testing.T) {
+	ctx := context.Background()
+	db := shopDatabase("ddl_db_")
+	db.Add(sqlapi.TableName{Prefix: "ddl_db_", Name: "regions"}, simpleTable("regions"))
+
+	db.DropAll(ctx, gdb)
+	err := db.CreateAll(ctx, gdb)
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	tables, err := sqlapi.ListTables(gdb, regexp.MustCompile("^ddl_db_"))
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Slice(tables).ToHaveLength(t, 5)
+
+	for _, s := range []string{
+		"INSERT INTO ddl_db_regions (id) VALUES (1)",
+		"INSERT INTO ddl_db_products (id) VALUES (1)",
+		"INSERT INTO ddl_db_customers (id, regions_id) VALUES (1, 1)",
+		"INSERT INTO ddl_db_orders (id, customers_id) VALUES (1, 1)",
+		"INSERT INTO ddl_db_items (id, orders_id, products_id) VALUES (1, 1, 1)",
+	} {
+		_, err = gdb.Exec(ctx, s)
+		expect.Error(err).Info(s).Not().ToHaveOccurred(t)
+	}
+
+	err = db.TruncateAll(ctx, gdb, false)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Number(count(t, "SELECT count(*) FROM ddl_db_regions")).ToBe(t, 0)
+
+	err = db.DropAll(ctx, gdb)
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	tables, err = sqlapi.ListTables(gdb, regexp.MustCompile("^ddl_db_"))
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Slice(tables).ToBeEmpty(t)
+}
+
+func count(t *testing.T, query string) int64 {
+	t.Helper()
+	var n int64
+	err := gdb.QueryRow(context.Background(), query).Scan(&n)
+	expect.Error(err).Info(query).Not().ToHaveOccurred(t)
+	return n
+}
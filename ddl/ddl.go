@@ -0,0 +1,143 @@
+// Package ddl generates data-definition statements for tables described by schema.TableDescription
+// and their constraints. This includes the CREATE TABLE and CREATE INDEX statements for a table
+// and the ALTER TABLE statements needed to migrate a table from one description to another.
+//
+// The statements are generated for a particular dialect. They can be executed using Steps.Exec
+// or written to migration files.
+//
+// Database is a registry of related tables, which are created, dropped and truncated in an
+// order that satisfies their foreign keys. Its WriteFiles method writes the whole schema as a
+// script for each dialect; calling this from a small program run by go generate keeps the
+// scripts up to date, e.g.
+//
+//	db := ddl.DatabaseOf("", user.UserTableDescription, order.OrderTableDescription)
+//	err := db.WriteFiles("schema")
+package ddl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rickb777/sqlapi"
+	"github.com/rickb777/sqlapi/constraint"
+	"github.com/rickb777/sqlapi/driver"
+	"github.com/rickb777/sqlapi/schema"
+	"github.com/rickb777/where/dialect"
+)
+
+// Table is a table description together with its constraints. The constraints are typically
+// those given by constraint.ConstraintsOf plus any others that the application adds.
+type Table struct {
+	Description *schema.TableDescription
+	Constraints constraint.Constraints
+}
+
+// Step is one statement in a migration.
+type Step struct {
+	SQL         string
+	Destructive bool // true if the step can lose data, e.g. by dropping a column
+}
+
+// Steps is an ordered list of statements.
+type Steps []Step
+
+// Statements gets the SQL of all the steps.
+func (ss Steps) Statements() []string {
+	list := make([]string, len(ss))
+	for i, s := range ss {
+		list[i] = s.SQL
+	}
+	return list
+}
+
+// IsDestructive is true if any of the steps can lose data.
+func (ss Steps) IsDestructive() bool {
+	for _, s := range ss {
+		if s.Destructive {
+			return true
+		}
+	}
+	return false
+}
+
+// String gets the steps as a script, with each destructive step preceded by a comment.
+func (ss Steps) String() string {
+	b := &strings.Builder{}
+	for _, s := range ss {
+		if s.Destructive {
+			b.WriteString("-- destructive\n")
+		}
+		b.WriteString(s.SQL)
+		b.WriteString(";\n")
+	}
+	return b.String()
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// CreateTable gets the statements that create a table and its indexes. The index names are
+// used as they are, i.e. without the table prefix. The table should have been validated
+// using schema.TableDescription.Validate; a default value that is not valid for its column
+// type is written as a string.
+//
+// For DuckDB, the sequences needed by auto-increment columns are created first.
+func CreateTable(di driver.Dialect, name sqlapi.TableName, table Table) Steps {
+	return createTable(di, name, table, nil)
+}
+
+// createTable gets the statements that create a table, omitting the constraints at some positions.
+func createTable(di driver.Dialect, name sqlapi.TableName, table Table, omit map[int]bool) Steps {
+	var steps Steps
+	if driver.IsDuckDB(di) {
+		for _, f := range table.Description.Fields {
+			if f.AutoIncrement() {
+				steps = append(steps, Step{SQL: "CREATE SEQUENCE IF NOT EXISTS " + driver.SequenceName(name.String(), f.SqlName)})
+			}
+		}
+	}
+
+	steps = append(steps, Step{SQL: createTableSql(di, di.Quoter().Quote(name.String()), name, table, omit)})
+
+	for _, ix := range table.Description.Index {
+		steps = append(steps, CreateIndex(di, name, ix))
+	}
+	return steps
+}
+
+// createTableSql gets the CREATE TABLE statement. The table being created may differ from
+// the name used for its constraints, which allows a table to be rebuilt under a temporary name.
+// The omitted constraints do not alter the names of the others.
+func createTableSql(di driver.Dialect, quotedTable string, name sqlapi.TableName, table Table, omit map[int]bool) string {
+	defs := driver.ColumnDefinitions(di, name.String(), table.Description)
+	for i, c := range table.Constraints {
+		if !omit[i] {
+			defs = append(defs, c.ConstraintSql(di.Quoter(), name, i))
+		}
+	}
+	return fmt.Sprintf("CREATE TABLE %s (\n\t%s\n)%s", quotedTable, strings.Join(defs, ",\n\t"), di.CreateTableSettings())
+}
+
+// DropTable gets the statement that drops a table. This is always destructive.
+func DropTable(di driver.Dialect, name sqlapi.TableName) Step {
+	return Step{SQL: "DROP TABLE IF EXISTS " + di.Quoter().Quote(name.String()), Destructive: true}
+}
+
+// CreateIndex gets the statement that creates an index.
+func CreateIndex(di driver.Dialect, name sqlapi.TableName, ix *schema.Index) Step {
+	q := di.Quoter()
+	w := &strings.Builder{}
+	fmt.Fprintf(w, "CREATE %sINDEX %s ON %s (", ix.UniqueStr(), q.Quote(ix.Name), q.Quote(name.String()))
+	ix.Fields.SqlNames().Quoted(w, q.Quote)
+	w.WriteString(")")
+	return Step{SQL: w.String()}
+}
+
+// DropIndex gets the statement that drops an index.
+func DropIndex(di driver.Dialect, name sqlapi.TableName, ix *schema.Index) Step {
+	q := di.Quoter()
+	switch di.Index() {
+	case dialect.Mysql, dialect.SqlServer:
+		return Step{SQL: fmt.Sprintf("DROP INDEX %s ON %s", q.Quote(ix.Name), q.Quote(name.String()))}
+	}
+	return Step{SQL: "DROP INDEX " + q.Quote(ix.Name)}
+}
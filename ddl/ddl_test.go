@@ -0,0 +1,255 @@
+package ddl_test
+
+import (
+	"context"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5/tracelog"
+	_ "github.com/lib/pq"
+	_ "github.com/marcboeker/go-duckdb"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/rickb777/expect"
+	"github.com/rickb777/sqlapi"
+	"github.com/rickb777/sqlapi/constraint"
+	"github.com/rickb777/sqlapi/ddl"
+	"github.com/rickb777/sqlapi/driver"
+	"github.com/rickb777/sqlapi/introspect"
+	"github.com/rickb777/sqlapi/schema"
+	"github.com/rickb777/sqlapi/support/testenv"
+	"github.com/rickb777/sqlapi/types"
+	_ "modernc.org/sqlite"
+)
+
+var gdb sqlapi.SqlDB
+
+var (
+	i32 = schema.Type{Name: "int32", Base: types.Int32}
+	i64 = schema.Type{Name: "int64", Base: types.Int64}
+	str = schema.Type{Name: "string", Base: types.String}
+	spt = schema.Type{Name: "string", Base: types.String, IsPtr: true}
+)
+
+func field(name string, typ schema.Type, tag types.Tag) *schema.Field {
+	return &schema.Field{Node: schema.Node{Name: name, Type: typ}, SqlName: name, Tags: &tag}
+}
+
+// peopleV1 and peopleV2 are two versions of a table. Between them, a column is dropped, a column
+// is added, a type is changed, a default is added and a unique index is added.
+func peopleV1() ddl.Table {
+	id := field("id", i64, types.Tag{Primary: true})
+	return ddl.Table{Description: &schema.TableDescription{Name: "people", Primary: id, Fields: schema.FieldList{
+		id,
+		field("name", str, types.Tag{}),
+		field("age", i32, types.Tag{}),
+		field("note", spt, types.Tag{}),
+	}}}
+}
+
+func peopleV2() ddl.Table {
+	id := field("id", i64, types.Tag{Primary: true})
+	table := &schema.TableDescription{Name: "people", Primary: id, Fields: schema.FieldList{
+		id,
+		field("name", str, types.Tag{Size: 100, Default: "x"}),
+		field("age", i64, types.Tag{}),
+		field("email", spt, types.Tag{}),
+	}}
+	table.Index = []*schema.Index{{Name: "people_name", Unique: true, Fields: table.Fields[1:2]}}
+	return ddl.Table{Description: table}
+}
+
+func TestCreateTable(t *testing.T) {
+	table := peopleV2()
+	table.Constraints = constraint.Constraints{constraint.CheckConstraint{Expression: "age >= 0"}}
+
+	steps := ddl.CreateTable(driver.Postgres(), sqlapi.TableName{Prefix: "p_", Name: "people"}, table)
+
+	expect.Slice(steps.Statements()).ToBe(t,
+		`CREATE TABLE "p_people" (
+	"id" bigint not null primary key,
+	"name" text not null default 'x',
+	"age" bigint not null,
+	"email" text default null,
+	CONSTRAINT "p_people_c47a59c3b" CHECK (age >= 0)
+)`,
+		`CREATE UNIQUE INDEX "people_name" ON "p_people" ("name")`)
+	expect.Bool(steps.IsDestructive()).ToBeFalse(t)
+}
+
+func TestCreateTable_duckDBSequence(t *testing.T) {
+	id := field("id", i64, types.Tag{Primary: true, Auto: true})
+	table := ddl.Table{Description: &schema.TableDescription{Name: "people", Primary: id, Fields: schema.FieldList{id}}}
+
+	steps := ddl.CreateTable(driver.DuckDB(), sqlapi.TableName{Prefix: "p_", Name: "people"}, table)
+
+	expect.Slice(steps.Statements()).ToBe(t,
+		`CREATE SEQUENCE IF NOT EXISTS p_people_id_seq`,
+		`CREATE TABLE "p_people" (
+	"id" bigint default nextval('p_people_id_seq') not null primary key
+)`)
+}
+
+func TestAlter(t *testing.T) {
+	name := sqlapi.TableName{Prefix: "p_", Name: "people"}
+	to := peopleV2()
+	to.Constraints = constraint.Constraints{constraint.FkConstraintOn("age").RefersTo("ages", "id")}
+
+	cases := []struct {
+		di       driver.Dialect
+		expected []string
+	}{
+		{
+			di: driver.Postgres(),
+			expected: []string{
+				`ALTER TABLE "p_people" DROP COLUMN "note"`,
+				`ALTER TABLE "p_people" ADD COLUMN "email" text default null`,
+				`ALTER TABLE "p_people" ALTER COLUMN "name" SET DEFAULT 'x'`,
+				`ALTER TABLE "p_people" ALTER COLUMN "age" TYPE bigint USING "age"::bigint`,
+				`CREATE UNIQUE INDEX "people_name" ON "p_people" ("name")`,
+				`ALTER TABLE "p_people" ADD CONSTRAINT "p_people_c41ec7c4a" foreign key ("age") references "p_ages" ("id")`,
+			},
+		},
+		{
+			di: driver.Mysql(),
+			expected: []string{
+				"ALTER TABLE `p_people` DROP COLUMN `note`",
+				"ALTER TABLE `p_people` ADD COLUMN `email` text default null",
+				"ALTER TABLE `p_people` MODIFY COLUMN `name` varchar(100) not null default 'x'",
+				"ALTER TABLE `p_people` MODIFY COLUMN `age` bigint not null",
+				"CREATE UNIQUE INDEX `people_name` ON `p_people` (`name`)",
+				"ALTER TABLE `p_people` ADD CONSTRAINT `p_people_c41ec7c4a` foreign key (`age`) references `p_ages` (`id`)",
+			},
+		},
+		{
+			di: driver.SqlServer(),
+			expected: []string{
+				`ALTER TABLE "p_people" DROP COLUMN "note"`,
+				`ALTER TABLE "p_people" ADD "email" nvarchar(max) default null`,
+				`ALTER TABLE "p_people" ALTER COLUMN "name" nvarchar(100) NOT NULL`,
+				`ALTER TABLE "p_people" ADD DEFAULT N'x' FOR "name"`,
+				`ALTER TABLE "p_people" ALTER COLUMN "age" bigint NOT NULL`,
+				`CREATE UNIQUE INDEX "people_name" ON "p_people" ("name")`,
+				`ALTER TABLE "p_people" ADD CONSTRAINT "p_people_c41ec7c4a" foreign key ("age") references "p_ages" ("id")`,
+			},
+		},
+		{
+			di: driver.Sqlite(),
+			expected: []string{
+				`PRAGMA foreign_keys = OFF`,
+				`CREATE TABLE "p_people__new" (
+	"id" bigint not null primary key,
+	"name" text not null default 'x',
+	"age" bigint not null,
+	"email" text default null,
+	CONSTRAINT "p_people_c41ec7c4a" foreign key ("age") references "p_ages" ("id")
+)`,
+				`INSERT INTO "p_people__new" ("id","name","age") SELECT "id","name","age" FROM "p_people"`,
+				`DROP TABLE "p_people"`,
+				`ALTER TABLE "p_people__new" RENAME TO "p_people"`,
+				`CREATE UNIQUE INDEX "people_name" ON "p_people" ("name")`,
+				`PRAGMA foreign_key_check("p_people")`,
+				`PRAGMA foreign_keys = ON`,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		steps := ddl.Alter(c.di, name, peopleV1(), to)
+		expect.Slice(steps.Statements()).I(c.di).ToBe(t, c.expected...)
+		expect.Bool(steps.IsDestructive()).I(c.di).ToBeTrue(t)
+	}
+}
+
+func TestAlter_twiceInARow(t *testing.T) {
+	name := sqlapi.TableName{Prefix: "p_", Name: "people"}
+	a := constraint.CheckConstraint{Expression: "age >= 0"}
+	b := constraint.CheckConstraint{Expression: "age < 200"}
+	c := constraint.CheckConstraint{Expression: "name <> ''"}
+
+	v1, v2, v3 := peopleV1(), peopleV1(), peopleV1()
+	v1.Constraints = constraint.Constraints{a, b}
+	v2.Constraints = constraint.Constraints{b, c}
+	v3.Constraints = constraint.Constraints{c}
+
+	steps := ddl.Alter(driver.Postgres(), name, v1, v2)
+	expect.Slice(steps.Statements()).ToBe(t,
+		`ALTER TABLE "p_people" DROP CONSTRAINT "`+constraint.ConstraintName(name, a)+`"`,
+		`ALTER TABLE "p_people" ADD CONSTRAINT "`+constraint.ConstraintName(name, c)+`" CHECK (name <> '')`)
+
+	// the second alteration drops the constraint that the first one kept, by the same name
+	steps = ddl.Alter(driver.Postgres(), name, v2, v3)
+	expect.Slice(steps.Statements()).ToBe(t,
+		`ALTER TABLE "p_people" DROP CONSTRAINT "`+constraint.ConstraintName(name, b)+`"`)
+
+	// the names are the same as if the table had been created afresh
+	create := ddl.CreateTable(driver.Postgres(), name, v2).Statements()[0]
+	expect.String(create).ToContain(t, `CONSTRAINT "`+constraint.ConstraintName(name, b)+`" CHECK (age < 200)`)
+	expect.String(create).ToContain(t, `CONSTRAINT "`+constraint.ConstraintName(name, c)+`" CHECK (name <> '')`)
+	expect.String(constraint.ConstraintName(name, a)).Not().ToBe(t, constraint.ConstraintName(name, b))
+}
+
+func TestAlter_noChange(t *testing.T) {
+	for _, di := range driver.AllDialects {
+		steps := ddl.Alter(di, sqlapi.TableName{Name: "people"}, peopleV2(), peopleV2())
+		expect.Slice(steps).I(di).ToBeEmpty(t)
+	}
+}
+
+func TestAlter_sqliteAddColumn(t *testing.T) {
+	to := peopleV1()
+	to.Description.Fields = append(to.Description.Fields, field("email", spt, types.Tag{}))
+
+	steps := ddl.Alter(driver.Sqlite(), sqlapi.TableName{Name: "people"}, peopleV1(), to)
+
+	expect.Slice(steps.Statements()).ToBe(t, `ALTER TABLE "people" ADD COLUMN "email" text default null`)
+	expect.Bool(steps.IsDestructive()).ToBeFalse(t)
+}
+
+func TestAlter_database(t *testing.T) {
+	ctx := context.Background()
+	di := gdb.Dialect()
+	name := sqlapi.TableName{Prefix: "ddl_", Name: "people"}
+
+	gdb.Exec(ctx, "DROP TABLE IF EXISTS "+di.Quoter().Quote(name.String()))
+	exec(t, ddl.CreateTable(di, name, peopleV1()))
+
+	_, err := gdb.Exec(ctx, di.ReplacePlaceholders("INSERT INTO ddl_people (id, name, age, note) VALUES (?, ?, ?, ?), (?, ?, ?, ?)", nil),
+		1, "Alice", 30, "a", 2, "Bob", 40, nil)
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	exec(t, ddl.Alter(di, name, peopleV1(), peopleV2()))
+
+	drift, err := introspect.CheckTable(ctx, gdb, name, introspect.Expected{Table: peopleV2().Description})
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Slice(drift).ToBeEmpty(t)
+
+	var names []string
+	rows, err := gdb.Query(ctx, "SELECT name FROM ddl_people WHERE age > 20 ORDER BY id")
+	expect.Error(err).Not().ToHaveOccurred(t)
+	for rows.Next() {
+		var n string
+		expect.Error(rows.Scan(&n)).Not().ToHaveOccurred(t)
+		names = append(names, n)
+	}
+	rows.Close()
+	expect.Slice(names).ToBe(t, "Alice", "Bob")
+}
+
+func exec(t *testing.T, steps ddl.Steps) {
+	t.Helper()
+	for _, s := range steps.Statements() {
+		_, err := gdb.Exec(context.Background(), s)
+		expect.Error(err).Info(s).Not().ToHaveOccurred(t)
+	}
+}
+
+//-------------------------------------------------------------------------------------------------
+
+func TestMain(m *testing.M) {
+	testenv.SetDefaultDbDriver("sqlite3")
+	testenv.Shebang(m, func(lgr tracelog.Logger, logLevel tracelog.LogLevel, tries int) (err error) {
+		gdb, err = sqlapi.ConnectEnv(context.Background(), lgr, logLevel, tries)
+		return err
+	})
+}
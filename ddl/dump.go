@@ -0,0 +1,84 @@
+package ddl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rickb777/sqlapi"
+	"github.com/rickb777/sqlapi/constraint"
+	"github.com/rickb777/sqlapi/driver"
+	"github.com/rickb777/sqlapi/schema"
+)
+
+// DatabaseOf builds a registry of tables from their descriptions, with the constraints declared
+// in the tags of their fields (see constraint.ConstraintsOf). The table names have a common prefix.
+func DatabaseOf(prefix string, tables ...*schema.TableDescription) *Database {
+	db := &Database{}
+	for _, td := range tables {
+		db.Add(sqlapi.TableName{Prefix: prefix, Name: td.Name}, Table{Description: td, Constraints: constraint.ConstraintsOf(td)})
+	}
+	return db
+}
+
+// WriteSQL writes a script containing the statements that create all the tables and their
+// indexes. The output is deterministic, so the scripts can be kept in version control and
+// changes to the schema can be reviewed as changes to the scripts.
+func (db *Database) WriteSQL(w io.Writer, di driver.Dialect) error {
+	steps, err := db.CreateSteps(di)
+	if err != nil {
+		return err
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "-- %s schema generated by github.com/rickb777/sqlapi/ddl; DO NOT EDIT.\n", di.Name())
+	for _, s := range steps {
+		b.WriteString("\n")
+		b.WriteString(s.SQL)
+		b.WriteString(";\n")
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// WriteFiles writes a script for each dialect (see WriteSQL) into a directory, which must
+// exist. The files are named like "schema.postgres.sql". If no dialects are specified, there
+// is one file for each distinct SQL syntax, i.e. SQLite, MySQL, PostgreSQL, SQL Server and
+// DuckDB. Pgx and Modernc are left out because their scripts are the same as those for
+// PostgreSQL and SQLite; they can be requested explicitly if needed.
+//
+// A failure for one dialect does not prevent the files for the others being written; for
+// example, DuckDB cannot create tables whose foreign keys form a cycle. The errors for all
+// the dialects that failed are returned together, each prefixed by the dialect name.
+func (db *Database) WriteFiles(dir string, dialects ...driver.Dialect) error {
+	if len(dialects) == 0 {
+		dialects = distinctDialects
+	}
+
+	var errs []error
+	for _, di := range dialects {
+		if err := db.writeFile(dir, di); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", di.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// distinctDialects has one dialect for each SQL syntax. Pgx and Modernc differ from Postgres
+// and Sqlite only in their Go drivers.
+var distinctDialects = []driver.Dialect{driver.Sqlite(), driver.Mysql(), driver.Postgres(), driver.SqlServer(), driver.DuckDB()}
+
+func (db *Database) writeFile(dir string, di driver.Dialect) error {
+	buf := &bytes.Buffer{}
+	if err := db.WriteSQL(buf, di); err != nil {
+		return err
+	}
+
+	file := filepath.Join(dir, "schema."+strings.ToLower(di.Name())+".sql")
+	return os.WriteFile(file, buf.Bytes(), 0o644)
+}
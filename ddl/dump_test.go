@@ -0,0 +1,106 @@
+package ddl_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rickb777/expect"
+	"github.com/rickb777/sqlapi"
+	"github.com/rickb777/sqlapi/ddl"
+	"github.com/rickb777/sqlapi/driver"
+	"github.com/rickb777/sqlapi/schema"
+	"github.com/rickb777/sqlapi/types"
+)
+
+func shopDescriptions() []*schema.TableDescription {
+	orderId := field("id", i64, types.Tag{Primary: true, Auto: true})
+	orders := &schema.TableDescription{Name: "orders", Primary: orderId, Fields: schema.FieldList{
+		orderId,
+		field("customer", i64, types.Tag{ForeignKey: "customers.id", OnDelete: "cascade"}),
+	}}
+
+	customerId := field("id", i64, types.Tag{Primary: true})
+	customers := &schema.TableDescription{Name: "customers", Primary: customerId, Fields: schema.FieldList{
+		customerId,
+		field("name", str, types.Tag{Size: 60}),
+	}}
+	customers.Index = []*schema.Index{{Name: "customers_name", Fields: customers.Fields[1:2]}}
+
+	return []*schema.TableDescription{orders, customers}
+}
+
+func TestWriteSQL(t *testing.T) {
+	db := ddl.DatabaseOf("s_", shopDescriptions()...)
+
+	b := &strings.Builder{}
+	err := db.WriteSQL(b, driver.Postgres())
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.String(b.String()).ToBe(t, `-- Postgres schema generated by github.com/rickb777/sqlapi/ddl; DO NOT EDIT.
+
+CREATE TABLE "s_customers" (
+	"id" bigint not null primary key,
+	"name" text not null
+);
+
+CREATE INDEX "customers_name" ON "s_customers" ("name");
+
+CREATE TABLE "s_orders" (
+	"id" bigserial not null primary key,
+	"customer" bigint not null,
+	CONSTRAINT "s_orders_c733592d0" foreign key ("customer") references "s_customers" ("id") on delete cascade
+);
+`)
+}
+
+func TestWriteFiles(t *testing.T) {
+	db := ddl.DatabaseOf("s_", shopDescriptions()...)
+	dir := t.TempDir()
+
+	err := db.WriteFiles(dir)
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	for _, di := range driver.AllDialects {
+		b, err := os.ReadFile(filepath.Join(dir, "schema."+strings.ToLower(di.Name())+".sql"))
+		if di == driver.Pgx() || di == driver.Modernc() {
+			// these have the same syntax as Postgres and Sqlite
+			expect.Bool(os.IsNotExist(err)).I(di.Name()).ToBeTrue(t)
+			continue
+		}
+		expect.Error(err).I(di.Name()).Not().ToHaveOccurred(t)
+		expect.String(string(b)).I(di.Name()).ToContain(t, "CREATE TABLE")
+	}
+}
+
+func TestWriteFiles_chosenDialects(t *testing.T) {
+	db := ddl.DatabaseOf("s_", shopDescriptions()...)
+	dir := t.TempDir()
+
+	err := db.WriteFiles(dir, driver.Pgx(), driver.Mysql())
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	entries, err := os.ReadDir(dir)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	expect.Slice(names).ToBe(t, "schema.mysql.sql", "schema.pgx.sql")
+}
+
+func TestWriteFiles_cycle(t *testing.T) {
+	db := &ddl.Database{}
+	db.Add(sqlapi.TableName{Prefix: "p_", Name: "employees"}, simpleTable("employees", "depts")).
+		Add(sqlapi.TableName{Prefix: "p_", Name: "depts"}, simpleTable("depts", "employees"))
+	dir := t.TempDir()
+
+	err := db.WriteFiles(dir)
+	expect.Error(err).ToContain(t, "DuckDB: ")
+	expect.Error(err).Not().ToContain(t, "Postgres: ")
+
+	for _, di := range []driver.Dialect{driver.Sqlite(), driver.Postgres(), driver.DuckDB()} {
+		_, err := os.Stat(filepath.Join(dir, "schema."+strings.ToLower(di.Name())+".sql"))
+		expect.Bool(err == nil).I(di.Name()).ToBe(t, !driver.IsDuckDB(di))
+	}
+}
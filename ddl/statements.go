@@ -0,0 +1,211 @@
+package ddl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rickb777/sqlapi"
+	"github.com/rickb777/sqlapi/constraint"
+	"github.com/rickb777/sqlapi/driver"
+	"github.com/rickb777/sqlapi/schema"
+	"github.com/rickb777/where/dialect"
+)
+
+// alteration accumulates the ALTER TABLE statements for one table.
+type alteration struct {
+	di    driver.Dialect
+	name  sqlapi.TableName
+	table string // quoted
+	steps Steps
+}
+
+func (a *alteration) add(sql string, destructive bool) {
+	a.steps = append(a.steps, Step{SQL: sql, Destructive: destructive})
+}
+
+func (a *alteration) quote(column string) string {
+	return a.di.Quoter().Quote(column)
+}
+
+func (a *alteration) addColumn(table *schema.TableDescription, f *schema.Field) {
+	keyword := "ADD COLUMN"
+	if a.di.Index() == dialect.SqlServer {
+		keyword = "ADD"
+	}
+	a.add(fmt.Sprintf("ALTER TABLE %s %s %s %s", a.table, keyword, a.quote(f.SqlName), columnOf(a.di, a.name, table, f)), false)
+}
+
+func (a *alteration) dropColumn(table *schema.TableDescription, f *schema.Field) {
+	if a.di.Index() == dialect.SqlServer && columnOf(a.di, a.name, table, f).Default != "" {
+		a.dropSqlServerDefault(f.SqlName) // otherwise the column cannot be dropped
+	}
+	a.add(fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", a.table, a.quote(f.SqlName)), true)
+}
+
+func (a *alteration) alterColumn(c columnChange) {
+	column := a.quote(c.to.SqlName)
+	toType := c.toCol.Type
+	toDefault := c.toCol.Default
+
+	switch {
+	case a.di.Index() == dialect.Mysql:
+		// MODIFY restates the whole column; the primary key is unchanged so is omitted here
+		a.add(fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s", a.table, column, a.di.CompositeKeyFieldAsColumn(c.to)), c.typ)
+
+	case a.di.Index() == dialect.SqlServer:
+		if c.typ || c.null {
+			null := " NOT NULL"
+			if isNullable(c.toCol) {
+				null = " NULL"
+			}
+			a.add(fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s%s", a.table, column, toType, null), c.typ)
+		}
+		if c.dflt {
+			if c.fromCol.Default != "" {
+				a.dropSqlServerDefault(c.to.SqlName)
+			}
+			if toDefault != "" {
+				a.add(fmt.Sprintf("ALTER TABLE %s ADD DEFAULT %s FOR %s", a.table, toDefault, column), false)
+			}
+		}
+
+	default: // PostgreSQL and DuckDB
+		if c.typ {
+			using := ""
+			if !driver.IsDuckDB(a.di) {
+				using = fmt.Sprintf(" USING %s::%s", column, toType)
+			}
+			a.add(fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s%s", a.table, column, toType, using), true)
+		}
+		if c.null {
+			action := "SET NOT NULL"
+			if isNullable(c.toCol) {
+				action = "DROP NOT NULL"
+			}
+			a.add(fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s", a.table, column, action), false)
+		}
+		if c.dflt {
+			action := "DROP DEFAULT"
+			if toDefault != "" {
+				action = "SET DEFAULT " + toDefault
+			}
+			a.add(fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s", a.table, column, action), false)
+		}
+	}
+}
+
+// dropSqlServerDefault drops a default value in SQL Server, which holds it as a constraint
+// whose name is usually generated and so has to be looked up.
+func (a *alteration) dropSqlServerDefault(column string) {
+	table := ansiString(a.name.String())
+	a.add(fmt.Sprintf(`DECLARE @df sysname = (SELECT name FROM sys.default_constraints
+	WHERE parent_object_id = OBJECT_ID(%s) AND parent_column_id = COLUMNPROPERTY(OBJECT_ID(%s), %s, 'ColumnId'));
+IF @df IS NOT NULL EXEC(%s + @df)`,
+		table, table, ansiString(column), ansiString("ALTER TABLE "+a.table+" DROP CONSTRAINT ")), false)
+}
+
+func (a *alteration) addConstraint(table Table, i int) {
+	a.add(fmt.Sprintf("ALTER TABLE %s ADD %s", a.table, table.Constraints[i].ConstraintSql(a.di.Quoter(), a.name, i)), false)
+}
+
+func (a *alteration) dropConstraint(table Table, i int) {
+	c := table.Constraints[i]
+	name := a.quote(constraint.ConstraintName(a.name, c))
+
+	if a.di.Index() == dialect.Mysql {
+		switch c.(type) {
+		case constraint.FkConstraint, constraint.CompositeFkConstraint:
+			a.add(fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s", a.table, name), false)
+		default:
+			a.add(fmt.Sprintf("ALTER TABLE %s DROP CHECK %s", a.table, name), false)
+		}
+		return
+	}
+
+	a.add(fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", a.table, name), false)
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// rebuild migrates a table by creating a new table, copying the data into it, dropping the old
+// table and renaming the new one. This follows https://www.sqlite.org/lang_altertable.html#otheralter
+func rebuild(di driver.Dialect, name sqlapi.TableName, from, to Table, ch *changes) Steps {
+	q := di.Quoter()
+	a := &alteration{di: di, name: name, table: q.Quote(name.String())}
+	temp := q.Quote(name.String() + "__new")
+	sqlite := di.Index() == dialect.Sqlite
+
+	if sqlite {
+		// This is ignored inside a transaction (see Alter). PRAGMA defer_foreign_keys would
+		// not help: the rows that refer to the dropped table are still counted as violations
+		// when the transaction commits.
+		a.add("PRAGMA foreign_keys = OFF", false)
+	}
+
+	// MySQL and SQL Server require constraint names to be unique across the schema
+	if di.Index() == dialect.Mysql || di.Index() == dialect.SqlServer {
+		for i := range from.Constraints {
+			a.dropConstraint(from, i)
+		}
+	}
+
+	if driver.IsDuckDB(di) {
+		for _, f := range to.Description.Fields {
+			if f.AutoIncrement() {
+				a.add("CREATE SEQUENCE IF NOT EXISTS "+driver.SequenceName(name.String(), f.SqlName), false)
+			}
+		}
+	}
+
+	a.add(createTableSql(di, temp, name, to, nil), false)
+
+	var common schema.FieldList
+	oldFields := fieldsBySqlName(from.Description)
+	for _, f := range to.Description.Fields {
+		if _, exists := oldFields[f.SqlName]; exists && !f.Skip() {
+			common = append(common, f)
+		}
+	}
+
+	identity := di.Index() == dialect.SqlServer && len(common.Filter((*schema.Field).AutoIncrement)) > 0
+	if identity {
+		a.add("SET IDENTITY_INSERT "+temp+" ON", false)
+	}
+
+	columns := &strings.Builder{}
+	common.SqlNames().Quoted(columns, q.Quote)
+	a.add(fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", temp, columns, columns, a.table), false)
+
+	if identity {
+		a.add("SET IDENTITY_INSERT "+temp+" OFF", false)
+	}
+
+	// the data in any dropped columns is lost here
+	a.add("DROP TABLE "+a.table, len(ch.dropped) > 0 || ch.hasTypeChanges())
+
+	if di.Index() == dialect.SqlServer {
+		a.add(fmt.Sprintf("EXEC sp_rename %s, %s", ansiString(name.String()+"__new"), ansiString(name.String())), false)
+	} else {
+		a.add(fmt.Sprintf("ALTER TABLE %s RENAME TO %s", temp, a.table), false)
+	}
+
+	if di.Index() == dialect.Postgres && !driver.IsDuckDB(di) {
+		// the serial sequences now belong to the new table, so must continue from the old values
+		for _, f := range common.Filter((*schema.Field).AutoIncrement) {
+			column := q.Quote(f.SqlName)
+			a.add(fmt.Sprintf("SELECT setval(pg_get_serial_sequence(%s, %s), COALESCE(MAX(%s), 0) + 1, false) FROM %s",
+				ansiString(a.table), ansiString(f.SqlName), column, a.table), false)
+		}
+	}
+
+	for _, ix := range to.Description.Index {
+		a.steps = append(a.steps, CreateIndex(di, name, ix))
+	}
+
+	if sqlite {
+		a.add(fmt.Sprintf("PRAGMA foreign_key_check(%s)", a.table), false)
+		a.add("PRAGMA foreign_keys = ON", false)
+	}
+
+	return a.steps
+}
@@ -1,6 +1,13 @@
 package driver
 
-import "strings"
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rickb777/sqlapi/schema"
+	"github.com/rickb777/sqlapi/types"
+	"github.com/rickb777/where/dialect"
+)
 
 const placeholders = "?,?,?,?,?,?,?,?,?,?"
 
@@ -14,6 +21,142 @@ func simpleQueryPlaceholders(n int) string {
 	return strings.Repeat("?,", n-1) + "?"
 }
 
+// explicitType gets the column type given explicitly in the tags for a dialect, if any.
+// This overrides the column type that would otherwise be inferred from the field's Go type.
+func explicitType(tags types.Tag, di Dialect) string {
+	return tags.TypeFor(di.Name(), di.Alias())
+}
+
+// literals determines how a dialect writes literal values.
+type literals struct {
+	quote      func(string) string // writes a string literal
+	bitBools   bool                // booleans are written as 1 or 0
+	blob       func([]byte) string // writes a binary literal
+	timeLayout string              // the layout of timestamp literals
+	utc        bool                // timestamps are written in UTC because the layout has no offset
+	parenExpr  bool                // default expressions other than simple values need parentheses
+}
+
+// ansiString writes a string literal in standard SQL, i.e. with single quotes doubled.
+func ansiString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// defaultValue renders the default value for a field, if any. A raw SQL expression is used
+// verbatim, except that it is parenthesised for dialects such as SQLite and MySQL that require
+// this for function calls and other expressions. A literal value is written according to the
+// field's type; if it is not valid for that type, it is written as a string so that it cannot
+// alter the surrounding SQL (schema.TableDescription.Validate reports this as an error).
+func (lit literals) defaultValue(field *schema.Field) string {
+	dv, err := field.Default()
+	switch {
+	case dv == nil:
+		return ""
+	case dv.Expr:
+		if lit.parenExpr && !simpleValue(dv.Value) {
+			return "(" + dv.Value + ")"
+		}
+		return dv.Value
+	case err != nil:
+		return lit.quote(dv.Value)
+	}
+
+	base := field.Type.Base
+	switch {
+	case base == types.Bool:
+		b, _ := strconv.ParseBool(dv.Value)
+		if lit.bitBools {
+			if b {
+				return "1"
+			}
+			return "0"
+		}
+		return strconv.FormatBool(b)
+	case base.IsInteger(), base == types.Float32, base == types.Float64:
+		return dv.Value
+	}
+	return lit.quote(dv.Value)
+}
+
+// simpleValue tests whether a default expression is a keyword such as CURRENT_TIMESTAMP or
+// NULL, a number, a string literal or already parenthesised, none of which need parentheses.
+func simpleValue(expr string) bool {
+	switch {
+	case expr == "":
+		return true
+	case len(expr) >= 2 && expr[0] == '\'' && expr[len(expr)-1] == '\'':
+		return !strings.Contains(strings.ReplaceAll(expr[1:len(expr)-1], "''", ""), "'")
+	case strings.HasPrefix(expr, "("):
+		depth := 0
+		for i, c := range expr {
+			switch c {
+			case '(':
+				depth++
+			case ')':
+				depth--
+				if depth == 0 {
+					return i == len(expr)-1
+				}
+			}
+		}
+		return false
+	}
+
+	if _, err := strconv.ParseFloat(expr, 64); err == nil {
+		return true
+	}
+	return strings.IndexFunc(expr, func(r rune) bool {
+		return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || r == '_')
+	}) < 0
+}
+
+// ColumnDefinitions gets the column definitions for a CREATE TABLE statement, in the order of
+// the table's fields. If the table has a composite primary key, this is followed by a
+// PRIMARY KEY table constraint listing its columns; otherwise, any primary key is declared
+// inline with its column. The name is the full name of the table, including any prefix.
+func ColumnDefinitions(di Dialect, name string, table *schema.TableDescription) []string {
+	q := di.Quoter()
+	composite := table.HasCompositePrimaryKey()
+	defs := make([]string, 0, len(table.Fields)+1)
+
+	for _, field := range table.Fields {
+		defs = append(defs, q.Quote(field.SqlName)+" "+ColumnDefinition(di, name, table, field))
+	}
+
+	if composite {
+		w := &strings.Builder{}
+		w.WriteString("PRIMARY KEY (")
+		table.PrimaryKeyFields().SqlNames().Quoted(w, q.Quote)
+		w.WriteString(")")
+		defs = append(defs, w.String())
+	}
+
+	return defs
+}
+
+// ColumnDefinition gets the column type and modifiers for one of a table's fields. This is
+// FieldAsColumn, or CompositeKeyFieldAsColumn if the table has a composite primary key, except
+// that for DuckDB an auto-increment column also gets its default from the table's sequence
+// (see SequenceName). The name is the full name of the table, including any prefix.
+func ColumnDefinition(di Dialect, name string, table *schema.TableDescription, field *schema.Field) string {
+	return ColumnOf(di, name, table, field).String()
+}
+
+// ColumnOf gets the column for one of a table's fields; its String is the same as
+// ColumnDefinition. The name is the full name of the table, including any prefix.
+func ColumnOf(di Dialect, name string, table *schema.TableDescription, field *schema.Field) Column {
+	inlinePk := !table.HasCompositePrimaryKey()
+	if d, ok := di.(columnar); ok {
+		return d.column(field, inlinePk, name)
+	}
+
+	// a dialect from elsewhere only provides the definition
+	if inlinePk {
+		return bareColumn(di.FieldAsColumn(field))
+	}
+	return bareColumn(di.CompositeKeyFieldAsColumn(field))
+}
+
 func baseFieldAsColumn(w StringWriter, name, field string) {
 	w.WriteString("\t\"")
 	w.WriteString(name)
@@ -21,3 +164,125 @@ func baseFieldAsColumn(w StringWriter, name, field string) {
 	w.WriteString(field)
 	w.WriteString("\"")
 }
+
+//-------------------------------------------------------------------------------------------------
+
+// Column describes how a field is declared as a column in some dialect. This is derived from
+// the field's type and tags, and is what FieldAsColumn writes.
+type Column struct {
+	Type       string // the column type without any modifiers, e.g. "bigint" or "varchar(255)"
+	Nullable   bool
+	Default    string // the default value as written for the dialect, or "" if there is none
+	PrimaryKey bool   // the column is declared as the primary key inline
+
+	identity string // a phrase after the type for auto-increment columns
+	suffix   string // a phrase after the primary key declaration for auto-increment columns
+	bare     bool   // the definition is just the type, without any modifiers
+}
+
+// columnar is implemented by the dialects in this package.
+type columnar interface {
+	// column gets the column for a field. The table name is needed only by DuckDB, for
+	// auto-increment columns; if it is blank, their default is omitted.
+	column(field *schema.Field, inlinePk bool, table string) Column
+}
+
+// newColumn gets the column with a given type and default value for a field. It is nullable
+// if the field is a pointer, in which case it has no other default. The primary key is declared
+// inline unless the column is part of a composite key.
+func newColumn(field *schema.Field, inlinePk bool, typ, dflt string) Column {
+	c := Column{Type: typ, Nullable: field.Type.IsPtr, PrimaryKey: field.GetTags().Primary && inlinePk}
+	if !c.Nullable {
+		c.Default = dflt
+	}
+	return c
+}
+
+// bareColumn gets a nullable column that is declared without any modifiers, e.g. for
+// fields that are encoded as JSON.
+func bareColumn(typ string) Column {
+	return Column{Type: typ, Nullable: true, bare: true}
+}
+
+// String gets the column definition, i.e. the type followed by its modifiers.
+func (c Column) String() string {
+	if c.bare {
+		return c.Type
+	}
+
+	w := &strings.Builder{}
+	w.WriteString(c.Type)
+	if c.identity != "" {
+		w.WriteString(" " + c.identity)
+	}
+	if c.Nullable {
+		w.WriteString(" default null")
+	} else {
+		w.WriteString(" not null")
+		if c.Default != "" {
+			w.WriteString(" default " + c.Default)
+		}
+	}
+	if c.PrimaryKey {
+		w.WriteString(" primary key")
+	}
+	if c.suffix != "" {
+		w.WriteString(" " + c.suffix)
+	}
+	return w.String()
+}
+
+// typeSynonyms maps alternative names of types onto the names used in the catalogs. Only the
+// first word of a type is mapped.
+var typeSynonyms = map[string]string{
+	"int":         "integer",
+	"int4":        "integer",
+	"serial":      "integer",
+	"int2":        "smallint",
+	"smallserial": "smallint",
+	"int8":        "bigint",
+	"bigserial":   "bigint",
+	"float8":      "double precision",
+	"float4":      "real",
+	"bool":        "boolean",
+	"varchar":     "character varying",
+	"decimal":     "numeric",
+	"timestamptz": "timestamp with time zone",
+}
+
+// SameType tests whether two column types are equivalent in a dialect, allowing for the different
+// names that the database catalogs use for some types. For example, in PostgreSQL "varchar(40)"
+// is the same type as "character varying(40)".
+func SameType(di Dialect, a, b string) bool {
+	return normaliseType(di, a) == normaliseType(di, b)
+}
+
+// normaliseType puts a column type into a canonical form.
+func normaliseType(di Dialect, sqlType string) string {
+	t := strings.Join(strings.Fields(strings.ToLower(sqlType)), " ")
+
+	head, rest := t, ""
+	if i := strings.IndexAny(t, " ("); i >= 0 {
+		head, rest = t[:i], t[i:]
+	}
+
+	if di.Index() == dialect.Mysql {
+		switch {
+		case head == "boolean" || head == "bool":
+			return "tinyint(1)"
+		case head == "tinyint" && strings.HasPrefix(rest, "(1)"):
+			return t
+		case strings.HasSuffix(head, "int") && strings.HasPrefix(rest, "("):
+			// MySQL before 8.0.19 shows integer display widths, e.g. "int(11)"
+			rest = rest[strings.IndexByte(rest, ')')+1:]
+		}
+	}
+
+	if head == "double" && rest == "" {
+		return "double precision"
+	}
+	if synonym, exists := typeSynonyms[head]; exists {
+		head = synonym
+	}
+	return head + rest
+}
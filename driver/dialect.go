@@ -25,14 +25,37 @@ type Dialect interface {
 	// WithQuoter returns a modified Dialect with a given quoter.
 	WithQuoter(q quote.Quoter) Dialect
 
+	// FieldAsColumn gets the column type and modifiers for a field. A primary key field is
+	// declared as the primary key here.
 	FieldAsColumn(field *schema.Field) string
+	// CompositeKeyFieldAsColumn is like FieldAsColumn except that a primary key field is not
+	// declared as the primary key, because it is part of a composite key. See ColumnDefinitions.
+	CompositeKeyFieldAsColumn(field *schema.Field) string
 	TruncateDDL(tableName string, force bool) []string
 	CreateTableSettings() string
 	ShowTables() string
 
 	// ReplacePlaceholders alters a query string by replacing the '?' placeholders with the appropriate
 	// placeholders needed by this dialect. For MySQL and SQlite3, the string is returned unchanged.
+	// Question marks inside string literals, quoted identifiers and comments are not placeholders.
+	// For dialects with numbered placeholders, '??' is an escaped literal '?'.
 	ReplacePlaceholders(sql string, args []interface{}) string
+	// ReplaceNamedPlaceholders alters a query string by replacing ':name' and '@name' placeholders
+	// with the positional placeholders needed by this dialect. The names are returned in the order
+	// that their values are needed. Named and positional placeholders cannot be mixed.
+	ReplaceNamedPlaceholders(sql string) (string, []string, error)
+	// CountPlaceholders counts the positional arguments needed by a query, using the same rules as
+	// ReplacePlaceholders. For numbered placeholders, this is the highest number used. If the query
+	// has named placeholders, -1 is returned because the number of arguments is not fixed.
+	CountPlaceholders(sql string) int
+	// SplitStatements splits a script into its statements. Delimiters within string literals,
+	// quoted identifiers, comments and dollar-quoted bodies are ignored. For MySQL, the
+	// DELIMITER command is supported; for SQL Server, GO lines separate batches.
+	SplitStatements(script string) []Statement
+	// Literal writes a value as an SQL literal, e.g. for an INSERT statement in a script.
+	// Strings, byte slices (which are binary data), booleans, numbers, times and values
+	// that implement driver.Valuer are supported, as are pointers to them; nil is NULL.
+	Literal(value any) (string, error)
 	// Placeholders returns a comma-separated list of n placeholders.
 	Placeholders(n int) string
 	// HasNumberedPlaceholders returns true for dialects such as PostgreSQL that use numbered placeholders.
@@ -45,16 +68,27 @@ type Dialect interface {
 	// obtain the last-insert ID after each INSERT.
 	// It is the inverse of HasLastInsertId.
 	InsertHasReturningPhrase() bool
+	// InsertReturningId alters an INSERT statement so that it returns the primary key of the inserted
+	// row. This is used by dialects for which InsertHasReturningPhrase is true; for other dialects,
+	// the query is returned unchanged.
+	InsertReturningId(query, pk string) string
 }
 
 //-------------------------------------------------------------------------------------------------
 
 // AllDialects lists all currently-supported dialects.
-var AllDialects = []Dialect{Sqlite(), Mysql(), Postgres(), Pgx()}
+var AllDialects = []Dialect{Sqlite(), Mysql(), Postgres(), Pgx(), SqlServer(), Modernc(), DuckDB()}
 
 // PickDialect finds a dialect that matches by name, ignoring letter case.
+// An exact match is preferred, so that driver names such as "sqlite" (for modernc.org/sqlite)
+// pick the corresponding dialect variant.
 // It returns nil if not found.
 func PickDialect(name string) Dialect {
+	for _, d := range AllDialects {
+		if name == d.Name() || name == d.Alias() {
+			return d
+		}
+	}
 	for _, d := range AllDialects {
 		if strings.EqualFold(name, d.Name()) || strings.EqualFold(name, d.Alias()) {
 			return d
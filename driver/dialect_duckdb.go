@@ -0,0 +1,160 @@
+package driver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rickb777/sqlapi/schema"
+	"github.com/rickb777/sqlapi/types"
+	"github.com/rickb777/where/dialect"
+	"github.com/rickb777/where/quote"
+)
+
+// duckDB is the dialect for DuckDB, which runs in-process. Its SQL is largely compatible
+// with PostgreSQL, including '$n' placeholders and the RETURNING phrase.
+type duckDB struct {
+	postgres
+}
+
+func DuckDB(d ...dialect.DialectConfig) Dialect {
+	return duckDB{postgres: postgres{of(dialect.PostgresConfig, d...)}}
+}
+
+// IsDuckDB is true if the dialect is DuckDB. This is needed because DuckDB's Index is the
+// same as PostgreSQL's.
+func IsDuckDB(di Dialect) bool {
+	_, ok := di.(duckDB)
+	return ok
+}
+
+// SequenceName gets the name of the sequence that provides the values of a DuckDB
+// auto-increment column. The table name includes any prefix.
+func SequenceName(table, column string) string {
+	return table + "_" + column + "_seq"
+}
+
+func (d duckDB) String() string {
+	if d.d.Quoter != nil {
+		return fmt.Sprintf("DuckDB/%s", d.d.Quoter)
+	}
+	return "DuckDB"
+}
+
+func (d duckDB) Name() string {
+	return "DuckDB"
+}
+
+func (d duckDB) Alias() string {
+	return "duckdb"
+}
+
+func (d duckDB) WithQuoter(q quote.Quoter) Dialect {
+	d.d.Quoter = q
+	return d
+}
+
+// https://duckdb.org/docs/sql/data_types/overview
+
+// FieldAsColumn uses DuckDB's native unsigned integer types. Fields of type big.Int are
+// stored as 128-bit integers unless they are encoded otherwise.
+//
+// DuckDB has no serial types, so auto-increment columns take their default from a
+// sequence, which must be created before the table, e.g. "CREATE SEQUENCE users_id_seq".
+// The sequence is named after the table, which is not known here, so this default is only
+// included by ColumnDefinition and ColumnDefinitions.
+func (dialect duckDB) FieldAsColumn(field *schema.Field) string {
+	return dialect.column(field, true, "").String()
+}
+
+func (dialect duckDB) CompositeKeyFieldAsColumn(field *schema.Field) string {
+	return dialect.column(field, false, "").String()
+}
+
+func (dialect duckDB) column(field *schema.Field, inlinePk bool, table string) Column {
+	tags := field.GetTags()
+	explicit := explicitType(tags, dialect)
+
+	if explicit == "" {
+		switch field.Encode {
+		case schema.ENCJSON:
+			return bareColumn("json")
+		case schema.ENCTEXT:
+			return bareColumn("varchar")
+		}
+	}
+
+	column := "blob"
+	dflt := duckDBLiterals.defaultValue(field)
+
+	switch field.Type.Base {
+	case types.Int, types.Int64:
+		column = "bigint"
+	case types.Int8:
+		column = "tinyint"
+	case types.Int16:
+		column = "smallint"
+	case types.Int32:
+		column = "integer"
+	case types.Uint, types.Uint64:
+		column = "ubigint"
+	case types.Uint8:
+		column = "utinyint"
+	case types.Uint16:
+		column = "usmallint"
+	case types.Uint32:
+		column = "uinteger"
+	case types.Float32:
+		column = "float"
+	case types.Float64:
+		column = "double"
+	case types.Bool:
+		column = "boolean"
+	case types.String:
+		column = "varchar"
+	case types.Struct:
+		if field.Type.PkgPath == "math/big" && field.Type.Name == "Int" {
+			column = "hugeint"
+		}
+	}
+
+	if explicit != "" {
+		column = explicit
+	}
+
+	c := newColumn(field, inlinePk, column, dflt)
+	if tags.Auto && table != "" {
+		c.identity = fmt.Sprintf("default nextval('%s')", SequenceName(table, field.SqlName))
+	}
+	return c
+}
+
+// DuckDB writes each byte of a blob literal as an escape, and its timestamps have no offset.
+var duckDBLiterals = literals{
+	quote: ansiString,
+	blob: func(b []byte) string {
+		w := &strings.Builder{}
+		w.WriteByte('\'')
+		for _, c := range b {
+			fmt.Fprintf(w, `\x%02X`, c)
+		}
+		w.WriteString("'::BLOB")
+		return w.String()
+	},
+	timeLayout: "2006-01-02 15:04:05.999999",
+	utc:        true,
+}
+
+// TruncateDDL uses TRUNCATE, which DuckDB permits only if no other table refers to this
+// one; there is no CASCADE option, so force makes no difference.
+func (dialect duckDB) TruncateDDL(tableName string, _ bool) []string {
+	return []string{fmt.Sprintf("TRUNCATE %s", dialect.Quoter().Quote(tableName))}
+}
+
+// Literal writes a value as an SQL literal.
+func (dialect duckDB) Literal(value any) (string, error) {
+	return duckDBLiterals.literal(value)
+}
+
+func (dialect duckDB) ShowTables() string {
+	return `SELECT table_name FROM information_schema.tables WHERE table_type = 'BASE TABLE'`
+}
@@ -0,0 +1,39 @@
+package driver
+
+import (
+	"fmt"
+
+	"github.com/rickb777/where/dialect"
+	"github.com/rickb777/where/quote"
+)
+
+// modernc is the SQLite dialect for the pure-Go driver modernc.org/sqlite, which needs no cgo.
+// Its SQL is identical to that for github.com/mattn/go-sqlite3; only the driver name differs.
+type modernc struct {
+	sqlite
+}
+
+func Modernc(d ...dialect.DialectConfig) Dialect {
+	return modernc{sqlite: sqlite{of(dialect.SqliteConfig, d...)}}
+}
+
+func (d modernc) String() string {
+	if d.d.Quoter != nil {
+		return fmt.Sprintf("Modernc/%s", d.d.Quoter)
+	}
+	return "Modernc"
+}
+
+func (d modernc) Name() string {
+	return "Modernc"
+}
+
+// Alias returns the driver name used by modernc.org/sqlite.
+func (d modernc) Alias() string {
+	return "sqlite"
+}
+
+func (d modernc) WithQuoter(q quote.Quoter) Dialect {
+	d.d.Quoter = q
+	return d
+}
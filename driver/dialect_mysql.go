@@ -2,6 +2,7 @@ package driver
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/rickb777/sqlapi/schema"
 	"github.com/rickb777/sqlapi/types"
@@ -59,18 +60,29 @@ func (d mysql) WithQuoter(q quote.Quoter) Dialect {
 // see https://dev.mysql.com/doc/refman/5.7/en/data-types.html
 
 func (dialect mysql) FieldAsColumn(field *schema.Field) string {
-	tags := field.GetTags()
-	indexed := len(tags.Index) > 0 || len(tags.Unique) > 0
+	return dialect.column(field, true, "").String()
+}
 
-	switch field.Encode {
-	case schema.ENCJSON:
-		return "json"
-	case schema.ENCTEXT:
-		return varchar(tags.Size, indexed)
+func (dialect mysql) CompositeKeyFieldAsColumn(field *schema.Field) string {
+	return dialect.column(field, false, "").String()
+}
+
+func (dialect mysql) column(field *schema.Field, inlinePk bool, _ string) Column {
+	tags := field.GetTags()
+	indexed := len(tags.Index) > 0 || len(tags.Unique) > 0 || tags.Primary
+	explicit := explicitType(tags, dialect)
+
+	if explicit == "" {
+		switch field.Encode {
+		case schema.ENCJSON:
+			return bareColumn("json")
+		case schema.ENCTEXT:
+			return bareColumn(varchar(tags.Size, indexed))
+		}
 	}
 
 	column := "mediumblob"
-	dflt := tags.Default
+	dflt := mysqlLiterals.defaultValue(field)
 
 	switch field.Type.Base {
 	case types.Int, types.Int64:
@@ -97,16 +109,28 @@ func (dialect mysql) FieldAsColumn(field *schema.Field) string {
 		column = "boolean"
 	case types.String:
 		column = varchar(tags.Size, indexed)
-		dflt = fmt.Sprintf("'%s'", tags.Default)
 	}
 
-	column = fieldTags(field.Type.IsPtr, tags, column, dflt)
+	if explicit != "" {
+		column = explicit
+	}
 
+	c := newColumn(field, inlinePk, column, dflt)
 	if tags.Auto {
-		column += " auto_increment"
+		c.suffix = "auto_increment"
 	}
+	return c
+}
 
-	return column
+// MySQL treats backslash as an escape character in strings, by default.
+var mysqlLiterals = literals{
+	quote: func(s string) string {
+		return ansiString(strings.ReplaceAll(s, `\`, `\\`))
+	},
+	blob:       hexBlob,
+	timeLayout: "2006-01-02 15:04:05.999999",
+	utc:        true,
+	parenExpr:  true,
 }
 
 func varchar(size int, indexed bool) string {
@@ -132,6 +156,10 @@ func (dialect mysql) InsertHasReturningPhrase() bool {
 	return false
 }
 
+func (dialect mysql) InsertReturningId(query, pk string) string {
+	return query
+}
+
 func (dialect mysql) TruncateDDL(tableName string, force bool) []string {
 	truncate := fmt.Sprintf("TRUNCATE %s", dialect.Quoter().Quote(tableName))
 	if !force {
@@ -169,6 +197,27 @@ func (dialect mysql) ReplacePlaceholders(sql string, _ []interface{}) string {
 	return sql
 }
 
+// ReplaceNamedPlaceholders converts a string containing ':name' or '@name' placeholders
+// to the positional form used by MySQL.
+func (dialect mysql) ReplaceNamedPlaceholders(sql string) (string, []string, error) {
+	return mysqlSyntax.replaceNamed(sql)
+}
+
+// CountPlaceholders counts the positional arguments needed by a query.
+func (dialect mysql) CountPlaceholders(sql string) int {
+	return mysqlSyntax.countPlaceholders(sql)
+}
+
+// SplitStatements splits a script into its statements.
+func (dialect mysql) SplitStatements(script string) []Statement {
+	return mysqlSyntax.splitStatements(script)
+}
+
+// Literal writes a value as an SQL literal.
+func (dialect mysql) Literal(value any) (string, error) {
+	return mysqlLiterals.literal(value)
+}
+
 func (dialect mysql) CreateTableSettings() string {
 	return " ENGINE=InnoDB DEFAULT CHARSET=utf8"
 }
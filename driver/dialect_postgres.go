@@ -2,6 +2,7 @@ package driver
 
 import (
 	"bytes"
+	"encoding/hex"
 	"fmt"
 	"strconv"
 
@@ -55,17 +56,28 @@ func (d postgres) WithQuoter(q quote.Quoter) Dialect {
 // https://www.convert-in.com/mysql-to-postgres-types-mapping.htm
 
 func (dialect postgres) FieldAsColumn(field *schema.Field) string {
-	tags := field.GetTags()
+	return dialect.column(field, true, "").String()
+}
+
+func (dialect postgres) CompositeKeyFieldAsColumn(field *schema.Field) string {
+	return dialect.column(field, false, "").String()
+}
 
-	switch field.Encode {
-	case schema.ENCJSON:
-		return "json"
-	case schema.ENCTEXT:
-		return "text"
+func (dialect postgres) column(field *schema.Field, inlinePk bool, _ string) Column {
+	tags := field.GetTags()
+	explicit := explicitType(tags, dialect)
+
+	if explicit == "" {
+		switch field.Encode {
+		case schema.ENCJSON:
+			return bareColumn("json")
+		case schema.ENCTEXT:
+			return bareColumn("text")
+		}
 	}
 
 	column := "bytea"
-	dflt := tags.Default
+	dflt := postgresLiterals.defaultValue(field)
 
 	switch field.Type.Base {
 	case types.Int, types.Int64:
@@ -97,7 +109,6 @@ func (dialect postgres) FieldAsColumn(field *schema.Field) string {
 		column = "boolean"
 	case types.String:
 		column = "text"
-		dflt = fmt.Sprintf("'%s'", tags.Default)
 	}
 
 	// postgres uses a special column type
@@ -111,13 +122,30 @@ func (dialect postgres) FieldAsColumn(field *schema.Field) string {
 		}
 	}
 
-	return fieldTags(field.Type.IsPtr, tags, column, dflt)
+	if explicit != "" {
+		column = explicit
+	}
+
+	return newColumn(field, inlinePk, column, dflt)
 }
 
 func (dialect postgres) InsertHasReturningPhrase() bool {
 	return true
 }
 
+// InsertReturningId adds a RETURNING phrase to an INSERT statement.
+func (dialect postgres) InsertReturningId(query, pk string) string {
+	return fmt.Sprintf("%s RETURNING %s", query, pk)
+}
+
+var postgresLiterals = literals{
+	quote: ansiString,
+	blob: func(b []byte) string {
+		return `'\x` + hex.EncodeToString(b) + "'::bytea"
+	},
+	timeLayout: "2006-01-02 15:04:05.999999-07:00",
+}
+
 func (dialect postgres) TruncateDDL(tableName string, force bool) []string {
 	if force {
 		return []string{fmt.Sprintf("TRUNCATE %s CASCADE", dialect.Quoter().Quote(tableName))}
@@ -163,20 +191,32 @@ func (dialect postgres) Placeholders(n int) string {
 const postgresPlaceholders = "$1,$2,$3,$4,$5,$6,$7,$8,$9"
 
 // ReplacePlaceholders converts a string containing '?' placeholders to
-// the form used by PostgreSQL.
+// the form used by PostgreSQL. String literals, quoted identifiers, comments and
+// dollar-quoted bodies are left unchanged. An escaped '??' becomes a literal '?',
+// which is needed for the JSONB operators '?', '?|' and '?&'.
 func (dialect postgres) ReplacePlaceholders(sql string, _ []interface{}) string {
-	buf := &bytes.Buffer{}
-	idx := 1
-	for _, r := range sql {
-		if r == '?' {
-			buf.WriteByte('$')
-			buf.WriteString(strconv.Itoa(idx))
-			idx++
-		} else {
-			buf.WriteRune(r)
-		}
-	}
-	return buf.String()
+	return postgresSyntax.replaceWithNumbers(sql)
+}
+
+// ReplaceNamedPlaceholders converts a string containing ':name' or '@name' placeholders
+// to the positional form used by PostgreSQL.
+func (dialect postgres) ReplaceNamedPlaceholders(sql string) (string, []string, error) {
+	return postgresSyntax.replaceNamed(sql)
+}
+
+// CountPlaceholders counts the positional arguments needed by a query.
+func (dialect postgres) CountPlaceholders(sql string) int {
+	return postgresSyntax.countPlaceholders(sql)
+}
+
+// SplitStatements splits a script into its statements.
+func (dialect postgres) SplitStatements(script string) []Statement {
+	return postgresSyntax.splitStatements(script)
+}
+
+// Literal writes a value as an SQL literal.
+func (dialect postgres) Literal(value any) (string, error) {
+	return postgresLiterals.literal(value)
 }
 
 func (dialect postgres) CreateTableSettings() string {
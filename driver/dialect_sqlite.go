@@ -53,27 +53,42 @@ func (d sqlite) WithQuoter(q quote.Quoter) Dialect {
 // For reals, the value is a floating point value, stored as an 8-byte IEEE floating point number.
 
 func (dialect sqlite) FieldAsColumn(field *schema.Field) string {
+	return dialect.column(field, true, "").String()
+}
+
+func (dialect sqlite) CompositeKeyFieldAsColumn(field *schema.Field) string {
+	return dialect.column(field, false, "").String()
+}
+
+func (dialect sqlite) column(field *schema.Field, inlinePk bool, _ string) Column {
 	tags := field.GetTags()
-	if tags.Auto {
+	explicit := explicitType(tags, dialect)
+
+	if tags.Auto && inlinePk {
 		// In sqlite, "autoincrement" is less efficient than built-in "rowid"
 		// and the datatype must be "integer" (https://sqlite.org/autoinc.html).
-		return "integer not null primary key autoincrement"
+		column := "integer"
+		if explicit != "" {
+			column = explicit
+		}
+		return Column{Type: column, PrimaryKey: true, suffix: "autoincrement"}
 	}
 
-	switch field.Encode {
-	case schema.ENCJSON:
-		return "text"
-	case schema.ENCTEXT:
-		return "text"
+	if explicit == "" {
+		switch field.Encode {
+		case schema.ENCJSON:
+			return bareColumn("text")
+		case schema.ENCTEXT:
+			return bareColumn("text")
+		}
 	}
 
 	column := "blob"
-	dflt := tags.Default
+	dflt := sqliteLiterals.defaultValue(field)
 
 	switch field.Type.Base {
 	case types.Int, types.Int64:
 		column = "bigint"
-		dflt = tags.Default
 	case types.Int8:
 		column = "tinyint"
 	case types.Int16:
@@ -96,35 +111,31 @@ func (dialect sqlite) FieldAsColumn(field *schema.Field) string {
 		column = "boolean"
 	case types.String:
 		column = "text"
-		dflt = fmt.Sprintf("'%s'", tags.Default)
-	}
-
-	return fieldTags(field.Type.IsPtr, tags, column, dflt)
-}
-
-func fieldTags(fieldIsPtr bool, tags types.Tag, column, dflt string) string {
-	if fieldIsPtr {
-		column += " default null"
-	} else {
-		column += " not null"
-
-		if tags.Default != "" {
-			column += " default " + dflt
-		}
-
 	}
 
-	if tags.Primary {
-		column += " primary key"
+	if explicit != "" {
+		column = explicit
 	}
 
-	return column
+	return newColumn(field, inlinePk, column, dflt)
 }
 
 func (dialect sqlite) InsertHasReturningPhrase() bool {
 	return false
 }
 
+func (dialect sqlite) InsertReturningId(query, pk string) string {
+	return query
+}
+
+// SQLite stores timestamps as text, in the same form as the go-sqlite3 driver.
+var sqliteLiterals = literals{
+	quote:      ansiString,
+	blob:       hexBlob,
+	timeLayout: "2006-01-02 15:04:05.999999999-07:00",
+	parenExpr:  true,
+}
+
 func (dialect sqlite) TruncateDDL(tableName string, force bool) []string {
 	truncate := fmt.Sprintf("DELETE FROM %s", dialect.Quoter().Quote(tableName))
 	return []string{truncate}
@@ -154,6 +165,27 @@ func (dialect sqlite) ReplacePlaceholders(sql string, _ []interface{}) string {
 	return sql
 }
 
+// ReplaceNamedPlaceholders converts a string containing ':name' or '@name' placeholders
+// to the positional form used by SQLite.
+func (dialect sqlite) ReplaceNamedPlaceholders(sql string) (string, []string, error) {
+	return sqliteSyntax.replaceNamed(sql)
+}
+
+// CountPlaceholders counts the positional arguments needed by a query.
+func (dialect sqlite) CountPlaceholders(sql string) int {
+	return sqliteSyntax.countPlaceholders(sql)
+}
+
+// SplitStatements splits a script into its statements.
+func (dialect sqlite) SplitStatements(script string) []Statement {
+	return sqliteSyntax.splitStatements(script)
+}
+
+// Literal writes a value as an SQL literal.
+func (dialect sqlite) Literal(value any) (string, error) {
+	return sqliteLiterals.literal(value)
+}
+
 func (dialect sqlite) CreateTableSettings() string {
 	return ""
 }
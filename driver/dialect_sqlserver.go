@@ -0,0 +1,242 @@
+package driver
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rickb777/sqlapi/schema"
+	"github.com/rickb777/sqlapi/types"
+	"github.com/rickb777/where/dialect"
+	"github.com/rickb777/where/quote"
+)
+
+type sqlServer struct {
+	d dialect.DialectConfig
+}
+
+func SqlServer(d ...dialect.DialectConfig) Dialect {
+	return sqlServer{d: of(dialect.SqlServerConfig, d...)}
+}
+
+func (d sqlServer) Index() dialect.Dialect {
+	return dialect.SqlServer
+}
+
+func (d sqlServer) String() string {
+	if d.d.Quoter != nil {
+		return fmt.Sprintf("SqlServer/%s", d.d.Quoter)
+	}
+	return "SqlServer"
+}
+
+func (d sqlServer) Name() string {
+	return "SqlServer"
+}
+
+func (d sqlServer) Alias() string {
+	return "MSSQL"
+}
+
+func (d sqlServer) Config() dialect.DialectConfig {
+	return d.d
+}
+
+func (d sqlServer) Quoter() quote.Quoter {
+	return d.d.Quoter
+}
+
+func (d sqlServer) WithQuoter(q quote.Quoter) Dialect {
+	d.d.Quoter = q
+	return d
+}
+
+// https://learn.microsoft.com/en-us/sql/t-sql/data-types/data-types-transact-sql
+
+func (dialect sqlServer) FieldAsColumn(field *schema.Field) string {
+	return dialect.column(field, true, "").String()
+}
+
+func (dialect sqlServer) CompositeKeyFieldAsColumn(field *schema.Field) string {
+	return dialect.column(field, false, "").String()
+}
+
+func (dialect sqlServer) column(field *schema.Field, inlinePk bool, _ string) Column {
+	tags := field.GetTags()
+	indexed := len(tags.Index) > 0 || len(tags.Unique) > 0 || tags.Primary
+	explicit := explicitType(tags, dialect)
+
+	if explicit == "" {
+		switch field.Encode {
+		case schema.ENCJSON:
+			return bareColumn("nvarchar(max)")
+		case schema.ENCTEXT:
+			return bareColumn(nvarchar(tags.Size, indexed))
+		}
+	}
+
+	column := "varbinary(max)"
+	dflt := sqlServerLiterals.defaultValue(field)
+
+	switch field.Type.Base {
+	case types.Int, types.Int64:
+		column = "bigint"
+	case types.Int8:
+		column = "smallint" // tinyint is unsigned in SQL Server
+	case types.Int16:
+		column = "smallint"
+	case types.Int32:
+		column = "int"
+	case types.Uint, types.Uint64:
+		column = "bigint" // incomplete number range; SQL Server has no unsigned bigint
+	case types.Uint8:
+		column = "tinyint"
+	case types.Uint16:
+		column = "int"
+	case types.Uint32:
+		column = "bigint"
+	case types.Float32:
+		column = "real"
+	case types.Float64:
+		column = "float"
+	case types.Bool:
+		column = "bit"
+	case types.String:
+		column = nvarchar(tags.Size, indexed)
+	}
+
+	// SQL Server uses an identity property
+	// for autoincrementing keys.
+	identity := ""
+	if tags.Auto {
+		switch field.Type.Base {
+		case types.Int, types.Int64, types.Uint, types.Uint64, types.Uint32:
+			column = "bigint"
+		default:
+			column = "int"
+		}
+		identity = "identity(1,1)"
+	}
+
+	if explicit != "" {
+		column = explicit
+	}
+
+	c := newColumn(field, inlinePk, column, dflt)
+	c.identity = identity
+	return c
+}
+
+// nvarchar chooses a Unicode string column. Index keys are limited to 900 bytes, so indexed
+// columns cannot use nvarchar(max).
+func nvarchar(size int, indexed bool) string {
+	if size == 0 { // unspecified
+		if indexed {
+			return "nvarchar(450)"
+		}
+		return "nvarchar(max)"
+	}
+	if size > 4000 {
+		return "nvarchar(max)"
+	}
+	return fmt.Sprintf("nvarchar(%d)", size)
+}
+
+// SQL Server writes Unicode string literals as N'...' and has no boolean literals.
+var sqlServerLiterals = literals{
+	quote: func(s string) string {
+		return "N" + ansiString(s)
+	},
+	bitBools: true,
+	blob: func(b []byte) string {
+		return "0x" + hex.EncodeToString(b)
+	},
+	timeLayout: "2006-01-02T15:04:05.9999999",
+	utc:        true,
+}
+
+func (dialect sqlServer) InsertHasReturningPhrase() bool {
+	return true
+}
+
+// InsertReturningId adds an OUTPUT INSERTED clause to an INSERT statement. This must come
+// before the VALUES, SELECT or DEFAULT VALUES part of the statement.
+func (dialect sqlServer) InsertReturningId(query, pk string) string {
+	output := fmt.Sprintf("OUTPUT INSERTED.%s ", pk)
+	if i := sqlServerSyntax.indexOfKeyword(query, "VALUES", "SELECT", "DEFAULT"); i >= 0 {
+		return query[:i] + output + query[i:]
+	}
+	return query + " " + strings.TrimSpace(output)
+}
+
+// TruncateDDL uses TRUNCATE TABLE, which is not allowed for tables referenced by foreign keys.
+// So when forced, DELETE is used instead.
+func (dialect sqlServer) TruncateDDL(tableName string, force bool) []string {
+	if force {
+		return []string{fmt.Sprintf("DELETE FROM %s", dialect.Quoter().Quote(tableName))}
+	}
+
+	return []string{fmt.Sprintf("TRUNCATE TABLE %s", dialect.Quoter().Quote(tableName))}
+}
+
+func (dialect sqlServer) ShowTables() string {
+	return `SELECT TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_TYPE = 'BASE TABLE'`
+}
+
+//-------------------------------------------------------------------------------------------------
+
+func (dialect sqlServer) HasNumberedPlaceholders() bool {
+	return true
+}
+
+func (dialect sqlServer) HasLastInsertId() bool {
+	return false
+}
+
+func (dialect sqlServer) Placeholders(n int) string {
+	if n == 0 {
+		return ""
+	}
+	buf := &strings.Builder{}
+	for idx := 1; idx <= n; idx++ {
+		if idx > 1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString("@p")
+		buf.WriteString(strconv.Itoa(idx))
+	}
+	return buf.String()
+}
+
+// ReplacePlaceholders converts a string containing '?' placeholders to
+// the form used by SQL Server, i.e. '@p1', '@p2' etc. String literals, quoted identifiers
+// and comments are left unchanged. An escaped '??' becomes a literal '?'.
+func (dialect sqlServer) ReplacePlaceholders(sql string, _ []interface{}) string {
+	return sqlServerSyntax.replaceWithNumbers(sql)
+}
+
+// ReplaceNamedPlaceholders converts a string containing ':name' or '@name' placeholders
+// to the positional form used by SQL Server.
+func (dialect sqlServer) ReplaceNamedPlaceholders(sql string) (string, []string, error) {
+	return sqlServerSyntax.replaceNamed(sql)
+}
+
+// CountPlaceholders counts the positional arguments needed by a query.
+func (dialect sqlServer) CountPlaceholders(sql string) int {
+	return sqlServerSyntax.countPlaceholders(sql)
+}
+
+// SplitStatements splits a script into its statements.
+func (dialect sqlServer) SplitStatements(script string) []Statement {
+	return sqlServerSyntax.splitStatements(script)
+}
+
+// Literal writes a value as an SQL literal.
+func (dialect sqlServer) Literal(value any) (string, error) {
+	return sqlServerLiterals.literal(value)
+}
+
+func (dialect sqlServer) CreateTableSettings() string {
+	return ""
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/rickb777/expect"
 	"github.com/rickb777/sqlapi/schema"
+	"github.com/rickb777/sqlapi/types"
 	"github.com/rickb777/where/quote"
 )
 
@@ -61,6 +62,10 @@ func TestPlaceholders(t *testing.T) {
 		{Postgres(), 1, "$1"},
 		{Postgres(), 3, "$1,$2,$3"},
 		{Postgres(), 11, "$1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11"},
+
+		{SqlServer(), 0, ""},
+		{SqlServer(), 1, "@p1"},
+		{SqlServer(), 3, "@p1,@p2,@p3"},
 	}
 	for _, c := range cases {
 		s := c.di.Placeholders(c.n)
@@ -74,6 +79,9 @@ func TestReplacePlaceholders(t *testing.T) {
 
 	s = Postgres().ReplacePlaceholders("?,?,?,?,?,?,?,?,?,?,?", nil)
 	expect.String(s).ToBe(t, "$1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11")
+
+	s = SqlServer().ReplacePlaceholders("SELECT a FROM [t?] WHERE b=? AND c='?' AND d=?", nil)
+	expect.String(s).ToBe(t, "SELECT a FROM [t?] WHERE b=@p1 AND c='?' AND d=@p2")
 }
 
 func TestPickDialect(t *testing.T) {
@@ -86,6 +94,10 @@ func TestPickDialect(t *testing.T) {
 		{Postgres(), "PostgreSQL"},
 		{Sqlite(), "SQLite"},
 		{Sqlite(), "sqlite3"},
+		{Modernc(), "sqlite"},
+		{DuckDB(), "duckdb"},
+		{SqlServer(), "sqlserver"},
+		{SqlServer(), "MSSQL"},
 	}
 	for _, c := range cases {
 		s := PickDialect(c.name)
@@ -119,9 +131,238 @@ func TestFieldAsColumn(t *testing.T) {
 		{Sqlite(), age, "int unsigned default null"},
 		{Sqlite(), bmi, "float default null"},
 		{Sqlite(), labels, "text"},
+
+		{Modernc(), id, "integer not null primary key autoincrement"},
+		{Modernc(), name, "text not null"},
+
+		{DuckDB(), id, "bigint not null primary key"},
+		{DuckDB(), name, "varchar not null"},
+		{DuckDB(), active, "boolean not null"},
+		{DuckDB(), age, "uinteger default null"},
+		{DuckDB(), bmi, "float default null"},
+		{DuckDB(), labels, "json"},
+		{DuckDB(), fave, "json"},
+		{DuckDB(), huge, "hugeint not null"},
+
+		{SqlServer(), id, "bigint identity(1,1) not null primary key"},
+		{SqlServer(), name, "nvarchar(2048) not null"},
+		{SqlServer(), active, "bit not null"},
+		{SqlServer(), age, "bigint default null"},
+		{SqlServer(), bmi, "real default null"},
+		{SqlServer(), labels, "nvarchar(max)"},
+		{SqlServer(), avatar, "varbinary(max) not null"},
+		{SqlServer(), category, "int not null"},
 	}
 	for _, c := range cases {
 		s := c.di.FieldAsColumn(c.field)
 		expect.String(s).I(c.di.Name()).ToBe(t, c.expected)
 	}
 }
+
+func TestColumnDefinitions(t *testing.T) {
+	userId := &schema.Field{Node: schema.Node{Name: "UserId", Type: i64}, SqlName: "user_id", Tags: &types.Tag{Primary: true}}
+	groupId := &schema.Field{Node: schema.Node{Name: "GroupId", Type: str}, SqlName: "group_id", Tags: &types.Tag{Primary: true}}
+	joined := &schema.TableDescription{Name: "memberships", Fields: schema.FieldList{userId, groupId, active}}
+	joined.SetPrimaryKey(userId, groupId)
+
+	cases := []struct {
+		di       Dialect
+		expected []string
+	}{
+		{Sqlite(), []string{`"user_id" bigint not null`, `"group_id" text not null`, `"active" boolean not null`, `PRIMARY KEY ("user_id","group_id")`}},
+		{Mysql(), []string{"`user_id` bigint not null", "`group_id` varchar(255) not null", "`active` boolean not null", "PRIMARY KEY (`user_id`,`group_id`)"}},
+		{Postgres(), []string{`"user_id" bigint not null`, `"group_id" text not null`, `"active" boolean not null`, `PRIMARY KEY ("user_id","group_id")`}},
+		{DuckDB(), []string{`"user_id" bigint not null`, `"group_id" varchar not null`, `"active" boolean not null`, `PRIMARY KEY ("user_id","group_id")`}},
+		{SqlServer(), []string{`"user_id" bigint not null`, `"group_id" nvarchar(450) not null`, `"active" bit not null`, `PRIMARY KEY ("user_id","group_id")`}},
+	}
+	for _, c := range cases {
+		defs := ColumnDefinitions(c.di, "memberships", joined)
+		expect.Slice(defs).I(c.di.Name()).ToBe(t, c.expected...)
+	}
+
+	single := &schema.TableDescription{Name: "people", Fields: schema.FieldList{id, active}, Primary: id}
+	defs := ColumnDefinitions(Postgres(), "people", single)
+	expect.Slice(defs).ToBe(t, `"id" bigserial not null primary key`, `"active" boolean not null`)
+
+	defs = ColumnDefinitions(DuckDB(), "pfx_people", single)
+	expect.Slice(defs).ToBe(t, `"id" bigint default nextval('pfx_people_id_seq') not null primary key`, `"active" boolean not null`)
+}
+
+func TestColumnOf(t *testing.T) {
+	score := &schema.Field{Node: schema.Node{Name: "Score", Type: i64}, SqlName: "score", Tags: &types.Tag{Default: "7"}}
+	people := &schema.TableDescription{Name: "people", Fields: schema.FieldList{id, score, age, labels}, Primary: id}
+
+	cases := []struct {
+		di       Dialect
+		field    *schema.Field
+		expected Column
+	}{
+		{Postgres(), id, Column{Type: "bigserial", PrimaryKey: true}},
+		{SqlServer(), id, Column{Type: "bigint", PrimaryKey: true, identity: "identity(1,1)"}},
+		{Mysql(), id, Column{Type: "bigint", PrimaryKey: true, suffix: "auto_increment"}},
+		{Sqlite(), id, Column{Type: "integer", PrimaryKey: true, suffix: "autoincrement"}},
+		{DuckDB(), id, Column{Type: "bigint", PrimaryKey: true, identity: "default nextval('p_people_id_seq')"}},
+		{Postgres(), score, Column{Type: "bigint", Default: "7"}},
+		{Postgres(), age, Column{Type: "bigint", Nullable: true}},
+		{Postgres(), labels, Column{Type: "json", Nullable: true, bare: true}},
+	}
+	for _, c := range cases {
+		col := ColumnOf(c.di, "p_people", people, c.field)
+		expect.Any(col).I(c.di.Name()).I(c.field.SqlName).ToBe(t, c.expected)
+		if !IsDuckDB(c.di) {
+			expect.String(col.String()).I(c.di.Name()).I(c.field.SqlName).ToBe(t, c.di.FieldAsColumn(c.field))
+		}
+	}
+}
+
+func TestSameType(t *testing.T) {
+	cases := []struct {
+		di             Dialect
+		expected, live string
+	}{
+		{di: Postgres(), expected: "bigserial", live: "bigint"},
+		{di: Postgres(), expected: "varchar(40)", live: "character varying(40)"},
+		{di: Postgres(), expected: "double precision", live: "double precision"},
+		{di: Postgres(), expected: "timestamptz", live: "timestamp with time zone"},
+		{di: Mysql(), expected: "int unsigned", live: "int(10) unsigned"},
+		{di: Mysql(), expected: "bigint", live: "bigint(20)"},
+		{di: Mysql(), expected: "boolean", live: "tinyint(1)"},
+		{di: Sqlite(), expected: "double", live: "DOUBLE"},
+		{di: DuckDB(), expected: "varchar", live: "varchar"},
+	}
+
+	for _, c := range cases {
+		expect.Bool(SameType(c.di, c.expected, c.live)).I(c.expected).ToBeTrue(t)
+	}
+
+	expect.Bool(SameType(Mysql(), "tinyint(4)", "boolean")).ToBeFalse(t)
+	expect.Bool(SameType(Postgres(), "text", "varchar(40)")).ToBeFalse(t)
+}
+
+func TestInsertReturningId(t *testing.T) {
+	cases := []struct {
+		di       Dialect
+		input    string
+		expected string
+	}{
+		{Sqlite(), "INSERT INTO t (a,b) VALUES (?,?)", "INSERT INTO t (a,b) VALUES (?,?)"},
+		{Mysql(), "INSERT INTO t (a,b) VALUES (?,?)", "INSERT INTO t (a,b) VALUES (?,?)"},
+		{Postgres(), "INSERT INTO t (a,b) VALUES ($1,$2)", "INSERT INTO t (a,b) VALUES ($1,$2) RETURNING id"},
+		{DuckDB(), "INSERT INTO t (a,b) VALUES ($1,$2)", "INSERT INTO t (a,b) VALUES ($1,$2) RETURNING id"},
+		{SqlServer(), "INSERT INTO t (a,b) VALUES (@p1,@p2)", "INSERT INTO t (a,b) OUTPUT INSERTED.id VALUES (@p1,@p2)"},
+		{SqlServer(), "INSERT INTO [values] (a,[select]) values (@p1,'VALUES')", "INSERT INTO [values] (a,[select]) OUTPUT INSERTED.id values (@p1,'VALUES')"},
+		{SqlServer(), "INSERT INTO t (a) SELECT a FROM u", "INSERT INTO t (a) OUTPUT INSERTED.id SELECT a FROM u"},
+		{SqlServer(), "INSERT INTO t DEFAULT VALUES", "INSERT INTO t OUTPUT INSERTED.id DEFAULT VALUES"},
+	}
+	for _, c := range cases {
+		s := c.di.InsertReturningId(c.input, "id")
+		expect.String(s).I(c.di.Name()).ToBe(t, c.expected)
+	}
+}
+
+func TestSqlServerDDL(t *testing.T) {
+	di := SqlServer()
+	expect.Slice(di.TruncateDDL("foo", false)).ToBe(t, `TRUNCATE TABLE "foo"`)
+	expect.Slice(di.TruncateDDL("foo", true)).ToBe(t, `DELETE FROM "foo"`)
+	expect.String(di.ShowTables()).ToBe(t, `SELECT TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_TYPE = 'BASE TABLE'`)
+	expect.Bool(di.HasLastInsertId()).Not().ToBeTrue(t)
+	expect.Bool(di.InsertHasReturningPhrase()).ToBeTrue(t)
+}
+
+func TestFieldAsColumn_explicitType(t *testing.T) {
+	price := &schema.Field{Node: schema.Node{Name: "Price", Type: fpt}, SqlName: "price",
+		Tags: &types.Tag{Type: "numeric(12,2)", Default: "0"}}
+	meta := &schema.Field{Node: schema.Node{Name: "Meta", Type: sli}, SqlName: "meta", Encode: schema.ENCJSON,
+		Tags: &types.Tag{Encode: "json", Types: types.Types{"postgres": "jsonb", "MySQL": "json"}}}
+	email := &schema.Field{Node: schema.Node{Name: "Email", Type: str}, SqlName: "email",
+		Tags: &types.Tag{Primary: true, Types: types.Types{"postgresql": "citext"}}}
+	serial := &schema.Field{Node: schema.Node{Name: "Id", Type: i64}, SqlName: "id",
+		Tags: &types.Tag{Primary: true, Auto: true, Type: "numeric(18,0)"}}
+
+	cases := []struct {
+		di       Dialect
+		field    *schema.Field
+		expected string
+	}{
+		{Sqlite(), price, "numeric(12,2) default null"},
+		{Mysql(), price, "numeric(12,2) default null"},
+		{Postgres(), price, "numeric(12,2) default null"},
+		{SqlServer(), price, "numeric(12,2) default null"},
+		{DuckDB(), price, "numeric(12,2) default null"},
+
+		{Sqlite(), meta, "text"},
+		{Mysql(), meta, "json not null"},
+		{Postgres(), meta, "jsonb not null"},
+		{Pgx(), meta, "jsonb not null"},
+
+		{Sqlite(), email, "text not null primary key"},
+		{Postgres(), email, "citext not null primary key"},
+
+		{SqlServer(), serial, "numeric(18,0) identity(1,1) not null primary key"},
+		{Mysql(), serial, "numeric(18,0) not null primary key auto_increment"},
+	}
+	for _, c := range cases {
+		s := c.di.FieldAsColumn(c.field)
+		expect.String(s).I(c.di.Name()+" "+c.field.Name).ToBe(t, c.expected)
+	}
+}
+
+func TestSimpleValue(t *testing.T) {
+	for _, s := range []string{"CURRENT_TIMESTAMP", "NULL", "0", "-1.5", "'a''b'", "(uuid())", "(1 + (2))"} {
+		expect.Bool(simpleValue(s)).I(s).ToBeTrue(t)
+	}
+	for _, s := range []string{"uuid()", "gen_random_uuid()", "1 + 2", "'a' || 'b'", "(1) + (2)", "lower('X')"} {
+		expect.Bool(simpleValue(s)).I(s).ToBeFalse(t)
+	}
+}
+
+func TestFieldAsColumn_defaults(t *testing.T) {
+	motto := &schema.Field{Node: schema.Node{Name: "Motto", Type: str}, SqlName: "motto",
+		Tags: &types.Tag{Default: `it's a \ thing`}}
+	flag := &schema.Field{Node: schema.Node{Name: "Flag", Type: boo}, SqlName: "flag",
+		Tags: &types.Tag{Default: "T"}}
+	count := &schema.Field{Node: schema.Node{Name: "Count", Type: i64}, SqlName: "count",
+		Tags: &types.Tag{Default: "0); DROP TABLE x; --"}}
+	created := &schema.Field{Node: schema.Node{Name: "Created", Type: tim}, SqlName: "created", Encode: schema.ENCTEXT,
+		Tags: &types.Tag{Encode: "text", Type: "timestamp", DefaultSQL: "CURRENT_TIMESTAMP"}}
+	uid := &schema.Field{Node: schema.Node{Name: "Uid", Type: str}, SqlName: "uid",
+		Tags: &types.Tag{Type: "varchar(36)", DefaultSQL: "uuid()"}}
+	updated := &schema.Field{Node: schema.Node{Name: "Updated", Type: tim}, SqlName: "updated",
+		Tags: &types.Tag{Type: "timestamp", Default: "CURRENT_TIMESTAMP"}}
+
+	cases := []struct {
+		di       Dialect
+		field    *schema.Field
+		expected string
+	}{
+		{Sqlite(), motto, `text not null default 'it''s a \ thing'`},
+		{Mysql(), motto, `text not null default 'it''s a \\ thing'`},
+		{Postgres(), motto, `text not null default 'it''s a \ thing'`},
+		{SqlServer(), motto, `nvarchar(max) not null default N'it''s a \ thing'`},
+		{DuckDB(), motto, `varchar not null default 'it''s a \ thing'`},
+
+		{Sqlite(), flag, "boolean not null default true"},
+		{Postgres(), flag, "boolean not null default true"},
+		{SqlServer(), flag, "bit not null default 1"},
+
+		{Mysql(), count, "bigint not null default '0); DROP TABLE x; --'"},
+
+		{Sqlite(), created, "timestamp not null default CURRENT_TIMESTAMP"},
+		{Postgres(), created, "timestamp not null default CURRENT_TIMESTAMP"},
+		{Mysql(), created, "timestamp not null default CURRENT_TIMESTAMP"},
+
+		{Sqlite(), uid, "varchar(36) not null default (uuid())"},
+		{Mysql(), uid, "varchar(36) not null default (uuid())"},
+		{Postgres(), uid, "varchar(36) not null default uuid()"},
+		{SqlServer(), uid, "varchar(36) not null default uuid()"},
+
+		// a default tag that is not a literal for the type is an expression, as it was before defaultsql
+		{Sqlite(), updated, "timestamp not null default CURRENT_TIMESTAMP"},
+		{Postgres(), updated, "timestamp not null default CURRENT_TIMESTAMP"},
+		{Mysql(), updated, "timestamp not null default CURRENT_TIMESTAMP"},
+	}
+	for _, c := range cases {
+		s := c.di.FieldAsColumn(c.field)
+		expect.String(s).I(c.di.Name()+" "+c.field.Name).ToBe(t, c.expected)
+	}
+}
@@ -39,6 +39,7 @@ var fooey1 = &Field{Node: Node{Name: "Foo1", Type: scv1}, SqlName: "foo1", Encod
 var fooey2 = &Field{Node: Node{Name: "Foo2", Type: scv2}, SqlName: "foo2", Encode: ENCNONE}
 var barey1 = &Field{Node: Node{Name: "Bar1", Type: bar1}, SqlName: "bar1", Encode: ENCDRIVER, Tags: &Tag{Encode: "driver"}}
 var barey2 = &Field{Node: Node{Name: "Bar2", Type: bar2}, SqlName: "bar2", Encode: ENCDRIVER, Tags: &Tag{Encode: "driver"}}
+var huge = &Field{Node: Node{Name: "Huge", Type: bgi}, SqlName: "huge", Encode: ENCNONE}
 var updated = &Field{Node: Node{Name: "Updated", Type: tim}, SqlName: "updated", Encode: ENCTEXT, Tags: &Tag{Size: 100, Encode: "text"}}
 
 var icat = &Index{Name: "catIdx", Fields: FieldList{category}}
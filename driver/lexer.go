@@ -0,0 +1,421 @@
+package driver
+
+import (
+	"strconv"
+	"strings"
+)
+
+// tokenKind classifies the lexical elements of an SQL string that matter when
+// placeholders are being processed.
+type tokenKind int
+
+const (
+	tText                tokenKind = iota // ordinary SQL text, including whitespace
+	tPlaceholder                          // a '?' placeholder
+	tEscapedQuestion                      // '??', which stands for a literal '?' operator
+	tNumberedPlaceholder                  // a '$1', '@p1' or (SQLite) '?1' placeholder
+	tNamedPlaceholder                     // a ':name', '@name' or (SQLite) '$name' placeholder
+	tStringLiteral                        // a quoted string
+	tQuotedIdentifier                     // a quoted identifier
+	tComment                              // a line comment or block comment
+	tDollarQuoted                         // a PostgreSQL $tag$...$tag$ body
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+type tokens []token
+
+func (tt tokens) has(kind tokenKind) bool {
+	for _, t := range tt {
+		if t.kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// syntax holds the lexical rules that differ between dialects.
+type syntax struct {
+	backslashEscapes  bool   // strings may contain backslash escapes (MySQL)
+	doubleQuoteString bool   // "..." is a string literal, not a quoted identifier (MySQL)
+	backTicks         bool   // `...` is a quoted identifier (MySQL, SQLite)
+	brackets          bool   // [...] is a quoted identifier (SQLite, SQL Server)
+	hashComments      bool   // # starts a line comment (MySQL)
+	dashSpaceComments bool   // -- must be followed by whitespace to start a comment (MySQL)
+	nestedComments    bool   // block comments can be nested (PostgreSQL, SQL Server)
+	dollarQuotes      bool   // $tag$...$tag$ bodies and E'...' strings (PostgreSQL)
+	escapedQuestion   bool   // '??' is an escaped literal '?'
+	numberedPrefix    string // the prefix of numbered placeholders, if any
+	sqliteParameters  bool   // '?NNN' is a numbered placeholder and '$name' is a named one (SQLite)
+	delimiterCommand  bool   // scripts can change the statement delimiter with DELIMITER (MySQL)
+	triggerBlocks     bool   // statements within BEGIN...END trigger bodies end with ';' (SQLite)
+	goBatches         bool   // scripts can be split into batches by GO lines (SQL Server)
+}
+
+var sqliteSyntax = &syntax{
+	backTicks:        true,
+	brackets:         true,
+	sqliteParameters: true,
+	triggerBlocks:    true,
+}
+
+var mysqlSyntax = &syntax{
+	backslashEscapes:  true,
+	doubleQuoteString: true,
+	backTicks:         true,
+	hashComments:      true,
+	dashSpaceComments: true,
+	delimiterCommand:  true,
+}
+
+var postgresSyntax = &syntax{
+	nestedComments:  true,
+	dollarQuotes:    true,
+	escapedQuestion: true,
+	numberedPrefix:  "$",
+}
+
+var sqlServerSyntax = &syntax{
+	brackets:        true,
+	nestedComments:  true,
+	escapedQuestion: true,
+	numberedPrefix:  "@p",
+	goBatches:       true,
+}
+
+// lex splits an SQL string into tokens. String literals, quoted identifiers, comments and
+// dollar-quoted bodies are each kept intact as single tokens so that any '?' within them
+// is not mistaken for a placeholder. Unterminated elements extend to the end of the input.
+func (syn *syntax) lex(sql string) tokens {
+	var list tokens
+	start := 0 // start of the current run of plain text
+
+	emit := func(from, to int, kind tokenKind) {
+		if start < from {
+			list = append(list, token{kind: tText, text: sql[start:from]})
+		}
+		list = append(list, token{kind: kind, text: sql[from:to]})
+		start = to
+	}
+
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'':
+			end := endOfQuoted(sql, i, '\'', syn.backslashEscapes)
+			emit(i, end, tStringLiteral)
+			i = end
+
+		case (c == 'E' || c == 'e') && syn.dollarQuotes && next(sql, i) == '\'' && !isIdentChar(prev(sql, i)):
+			end := endOfQuoted(sql, i+1, '\'', true)
+			emit(i, end, tStringLiteral)
+			i = end
+
+		case c == '"':
+			if syn.doubleQuoteString {
+				end := endOfQuoted(sql, i, '"', syn.backslashEscapes)
+				emit(i, end, tStringLiteral)
+				i = end
+			} else {
+				end := endOfQuoted(sql, i, '"', false)
+				emit(i, end, tQuotedIdentifier)
+				i = end
+			}
+
+		case c == '`' && syn.backTicks:
+			end := endOfQuoted(sql, i, '`', false)
+			emit(i, end, tQuotedIdentifier)
+			i = end
+
+		case c == '[' && syn.brackets:
+			end := endOfQuoted(sql, i, ']', false)
+			emit(i, end, tQuotedIdentifier)
+			i = end
+
+		case c == '-' && next(sql, i) == '-' && (!syn.dashSpaceComments || isSpaceOrEnd(sql, i+2)),
+			c == '#' && syn.hashComments:
+			end := endOfLine(sql, i)
+			emit(i, end, tComment)
+			i = end
+
+		case c == '/' && next(sql, i) == '*':
+			end := syn.endOfBlockComment(sql, i)
+			emit(i, end, tComment)
+			i = end
+
+		case syn.isNumberedPlaceholder(sql, i):
+			end := endOfNumber(sql, i+len(syn.numberedPrefix))
+			emit(i, end, tNumberedPlaceholder)
+			i = end
+
+		case c == '$' && syn.dollarQuotes && !isIdentChar(prev(sql, i)):
+			if end := endOfDollarQuoted(sql, i); end > i {
+				emit(i, end, tDollarQuoted)
+				i = end
+			} else {
+				i++
+			}
+
+		case c == '?' && syn.sqliteParameters && isDigit(next(sql, i)):
+			end := endOfNumber(sql, i+1)
+			emit(i, end, tNumberedPlaceholder)
+			i = end
+
+		case c == '$' && syn.sqliteParameters && isIdentChar(next(sql, i)) && next(sql, i) != '$' && !isIdentChar(prev(sql, i)):
+			end := endOfIdent(sql, i+1) // SQLite treats '$1' as a name too
+			emit(i, end, tNamedPlaceholder)
+			i = end
+
+		case c == '?':
+			if syn.escapedQuestion && next(sql, i) == '?' {
+				emit(i, i+2, tEscapedQuestion)
+				i += 2
+			} else {
+				emit(i, i+1, tPlaceholder)
+				i++
+			}
+
+		case (c == ':' || c == '@') && isIdentStart(next(sql, i)) && !isIdentChar(prev(sql, i)) && prev(sql, i) != c:
+			end := endOfIdent(sql, i+1)
+			emit(i, end, tNamedPlaceholder)
+			i = end
+
+		default:
+			i++
+		}
+	}
+
+	if start < len(sql) {
+		list = append(list, token{kind: tText, text: sql[start:]})
+	}
+	return list
+}
+
+// isNumberedPlaceholder tests whether a numbered placeholder, e.g. '$1' or '@p1', starts at sql[i].
+func (syn *syntax) isNumberedPlaceholder(sql string, i int) bool {
+	n := len(syn.numberedPrefix)
+	if n == 0 || !strings.HasPrefix(sql[i:], syn.numberedPrefix) || isIdentChar(prev(sql, i)) {
+		return false
+	}
+	end := endOfNumber(sql, i+n)
+	return end > i+n && (end == len(sql) || !isIdentChar(sql[end]))
+}
+
+// endOfQuoted finds the end of a quoted element that starts at sql[from]. A doubled closing
+// quote mark is an escaped quote mark; so too is a backslash-escaped quote if allowed.
+func endOfQuoted(sql string, from int, closing byte, backslashes bool) int {
+	for i := from + 1; i < len(sql); i++ {
+		switch sql[i] {
+		case '\\':
+			if backslashes {
+				i++
+			}
+		case closing:
+			if next(sql, i) != closing {
+				return i + 1
+			}
+			i++
+		}
+	}
+	return len(sql)
+}
+
+func (syn *syntax) endOfBlockComment(sql string, from int) int {
+	depth := 0
+	for i := from; i < len(sql)-1; i++ {
+		if sql[i] == '/' && sql[i+1] == '*' {
+			if depth == 0 || syn.nestedComments {
+				depth++
+			}
+			i++
+		} else if sql[i] == '*' && sql[i+1] == '/' {
+			depth--
+			i++
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(sql)
+}
+
+func endOfLine(sql string, from int) int {
+	if n := strings.IndexByte(sql[from:], '\n'); n >= 0 {
+		return from + n + 1
+	}
+	return len(sql)
+}
+
+func endOfNumber(sql string, from int) int {
+	i := from
+	for i < len(sql) && isDigit(sql[i]) {
+		i++
+	}
+	return i
+}
+
+// endOfDollarQuoted finds the end of a PostgreSQL dollar-quoted body, e.g. $$...$$ or
+// $fn$...$fn$, that starts at sql[from]. It returns from if there is no opening tag.
+func endOfDollarQuoted(sql string, from int) int {
+	i := from + 1
+	if i < len(sql) && isIdentStart(sql[i]) {
+		i = endOfIdent(sql, i)
+	}
+
+	if i >= len(sql) || sql[i] != '$' {
+		return from
+	}
+
+	tag := sql[from : i+1]
+	if n := strings.Index(sql[i+1:], tag); n >= 0 {
+		return i + 1 + n + len(tag)
+	}
+	return len(sql)
+}
+
+func endOfIdent(sql string, from int) int {
+	i := from
+	for i < len(sql) && isIdentChar(sql[i]) && sql[i] != '$' {
+		i++
+	}
+	return i
+}
+
+func next(sql string, i int) byte {
+	if i+1 < len(sql) {
+		return sql[i+1]
+	}
+	return 0
+}
+
+func prev(sql string, i int) byte {
+	if i > 0 {
+		return sql[i-1]
+	}
+	return 0
+}
+
+func isSpaceOrEnd(sql string, i int) bool {
+	if i >= len(sql) {
+		return true
+	}
+	switch sql[i] {
+	case ' ', '\t', '\n', '\r', '\f', '\v':
+		return true
+	}
+	return false
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || c >= 0x80
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c) || c == '$'
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// replaceWithNumbers replaces each '?' placeholder with a numbered placeholder, using the
+// syntax's prefix. Escaped '??' become a literal '?'. Question marks within string literals,
+// quoted identifiers, comments and dollar-quoted bodies are left unchanged.
+//
+// If the query already contains numbered placeholders, it is returned unchanged; this makes
+// the replacement idempotent, so it does no harm if a query gets rewritten twice.
+func (syn *syntax) replaceWithNumbers(sql string) string {
+	if strings.IndexByte(sql, '?') < 0 {
+		return sql
+	}
+
+	list := syn.lex(sql)
+	if list.has(tNumberedPlaceholder) {
+		return sql
+	}
+
+	buf := &strings.Builder{}
+	buf.Grow(len(sql) + 16)
+	idx := 1
+	for _, t := range list {
+		switch t.kind {
+		case tPlaceholder:
+			buf.WriteString(syn.numberedPrefix)
+			buf.WriteString(strconv.Itoa(idx))
+			idx++
+		case tEscapedQuestion:
+			buf.WriteByte('?')
+		default:
+			buf.WriteString(t.text)
+		}
+	}
+	return buf.String()
+}
+
+// countPlaceholders counts the positional arguments needed by a query. For numbered placeholders,
+// this is the highest number used; any '?' are then operators, as for replaceWithNumbers.
+// SQLite differs: a '?' takes the number after the highest so far, so it can be mixed with '?NNN'.
+// It returns -1 if the query has named placeholders.
+func (syn *syntax) countPlaceholders(sql string) int {
+	if strings.IndexAny(sql, "?$:@") < 0 {
+		return 0
+	}
+
+	questions, highest := 0, 0
+	for _, t := range syn.lex(sql) {
+		switch t.kind {
+		case tNamedPlaceholder:
+			return -1
+		case tPlaceholder:
+			questions++
+			if syn.sqliteParameters {
+				highest++
+			}
+		case tNumberedPlaceholder:
+			if n, _ := strconv.Atoi(strings.TrimPrefix(t.text[len(syn.numberedPrefix):], "?")); n > highest {
+				highest = n
+			}
+		}
+	}
+
+	if highest > 0 {
+		return highest
+	}
+	return questions
+}
+
+// indexOfKeyword finds the first of several keywords that occurs outside any literals,
+// comments and parentheses. Keywords are matched as whole words, ignoring case.
+// It returns -1 if none is found.
+func (syn *syntax) indexOfKeyword(sql string, keywords ...string) int {
+	offset, depth := 0, 0
+	for _, t := range syn.lex(sql) {
+		if t.kind == tText {
+			for i := 0; i < len(t.text); i++ {
+				switch c := t.text[i]; {
+				case c == '(':
+					depth++
+				case c == ')':
+					depth--
+				case depth == 0 && isIdentStart(c) && !isIdentChar(prev(t.text, i)):
+					end := endOfIdent(t.text, i)
+					for _, kw := range keywords {
+						if strings.EqualFold(t.text[i:end], kw) {
+							return offset + i
+						}
+					}
+					i = end - 1
+				}
+			}
+		}
+		offset += len(t.text)
+	}
+	return -1
+}
@@ -0,0 +1,148 @@
+package driver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rickb777/expect"
+)
+
+// markPlaceholders shows where the lexer found placeholders by replacing each one with '#'.
+func markPlaceholders(syn *syntax, sql string) string {
+	buf := &strings.Builder{}
+	for _, t := range syn.lex(sql) {
+		switch t.kind {
+		case tPlaceholder, tNumberedPlaceholder:
+			buf.WriteByte('#')
+		default:
+			buf.WriteString(t.text)
+		}
+	}
+	return buf.String()
+}
+
+func TestLexRoundTrip(t *testing.T) {
+	cases := []string{
+		"",
+		"SELECT 1",
+		"SELECT 'it''s' FROM \"a\"\"b\" WHERE x=? -- done?",
+		"SELECT $a$ ? $a$, E'\\'?', /* /* ? */ ? */ ? FROM t",
+		"SELECT 'unterminated ?",
+		"SELECT `x?` FROM [y?] # z?\n WHERE a=?",
+	}
+	for _, c := range cases {
+		for _, syn := range []*syntax{sqliteSyntax, mysqlSyntax, postgresSyntax} {
+			buf := &strings.Builder{}
+			for _, tok := range syn.lex(c) {
+				buf.WriteString(tok.text)
+			}
+			expect.String(buf.String()).I(c).ToBe(t, c)
+		}
+	}
+}
+
+func TestSqliteLex(t *testing.T) {
+	cases := []struct {
+		input, expected string
+	}{
+		{"SELECT a FROM t WHERE b=? AND c=?", "SELECT a FROM t WHERE b=# AND c=#"},
+		{"SELECT 'what?' FROM t WHERE b=?", "SELECT 'what?' FROM t WHERE b=#"},
+		{"SELECT 'it''s ?' FROM t WHERE b=?", "SELECT 'it''s ?' FROM t WHERE b=#"},
+		{`SELECT "a?" FROM t WHERE b=?`, `SELECT "a?" FROM t WHERE b=#`},
+		{"SELECT `a?` FROM [t?] WHERE b=?", "SELECT `a?` FROM [t?] WHERE b=#"},
+		{"SELECT a -- why?\nFROM t WHERE b=?", "SELECT a -- why?\nFROM t WHERE b=#"},
+		{"SELECT a /* why? */ FROM t WHERE b=?", "SELECT a /* why? */ FROM t WHERE b=#"},
+		{"SELECT a FROM t WHERE b=?? ", "SELECT a FROM t WHERE b=## "},
+	}
+	for _, c := range cases {
+		s := markPlaceholders(sqliteSyntax, c.input)
+		expect.String(s).I(c.input).ToBe(t, c.expected)
+	}
+}
+
+func TestMysqlLex(t *testing.T) {
+	cases := []struct {
+		input, expected string
+	}{
+		{"SELECT a FROM t WHERE b=? AND c=?", "SELECT a FROM t WHERE b=# AND c=#"},
+		{"SELECT 'what?' FROM t WHERE b=?", "SELECT 'what?' FROM t WHERE b=#"},
+		{`SELECT 'it\'s ?' FROM t WHERE b=?`, `SELECT 'it\'s ?' FROM t WHERE b=#`},
+		{`SELECT "say \"?\"" FROM t WHERE b=?`, `SELECT "say \"?\"" FROM t WHERE b=#`},
+		{"SELECT `a?` FROM t WHERE b=?", "SELECT `a?` FROM t WHERE b=#"},
+		{"SELECT a # why?\nFROM t WHERE b=?", "SELECT a # why?\nFROM t WHERE b=#"},
+		{"SELECT a -- why?\nFROM t WHERE b=?", "SELECT a -- why?\nFROM t WHERE b=#"},
+		{"SELECT a--? FROM t", "SELECT a--# FROM t"},
+		{"SELECT a /* why? */ FROM t WHERE b=?", "SELECT a /* why? */ FROM t WHERE b=#"},
+		{"SELECT a FROM [t] WHERE b=?", "SELECT a FROM [t] WHERE b=#"},
+	}
+	for _, c := range cases {
+		s := markPlaceholders(mysqlSyntax, c.input)
+		expect.String(s).I(c.input).ToBe(t, c.expected)
+	}
+}
+
+func TestPostgresReplacePlaceholders(t *testing.T) {
+	cases := []struct {
+		input, expected string
+	}{
+		{"", ""},
+		{"SELECT a FROM t", "SELECT a FROM t"},
+		{"SELECT a FROM t WHERE b=? AND c=?", "SELECT a FROM t WHERE b=$1 AND c=$2"},
+		{"?,?,?,?,?,?,?,?,?,?,?", "$1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11"},
+		{"SELECT 'what?' FROM t WHERE b=?", "SELECT 'what?' FROM t WHERE b=$1"},
+		{"SELECT 'it''s ?' FROM t WHERE b=?", "SELECT 'it''s ?' FROM t WHERE b=$1"},
+		{`SELECT E'it\'s ?' FROM t WHERE b=?`, `SELECT E'it\'s ?' FROM t WHERE b=$1`},
+		{`SELECT "a?" FROM t WHERE b=?`, `SELECT "a?" FROM t WHERE b=$1`},
+		{"SELECT a -- why?\nFROM t WHERE b=?", "SELECT a -- why?\nFROM t WHERE b=$1"},
+		{"SELECT a /* why? /* nested? */ still? */ FROM t WHERE b=?", "SELECT a /* why? /* nested? */ still? */ FROM t WHERE b=$1"},
+		{"SELECT $$what?$$ FROM t WHERE b=?", "SELECT $$what?$$ FROM t WHERE b=$1"},
+		{"SELECT $fn$ a $$?$$ b $fn$, ?", "SELECT $fn$ a $$?$$ b $fn$, $1"},
+		{"SELECT a FROM t WHERE data ?? 'k' AND b=?", "SELECT a FROM t WHERE data ? 'k' AND b=$1"},
+		{"SELECT a FROM t WHERE data ??| array['x'] AND b=?", "SELECT a FROM t WHERE data ?| array['x'] AND b=$1"},
+		{"SELECT a FROM t WHERE data ??& ? AND b=?", "SELECT a FROM t WHERE data ?& $1 AND b=$2"},
+		{"SELECT a$1, b FROM t WHERE c=?", "SELECT a$1, b FROM t WHERE c=$1"},
+		{"SELECT a FROM t WHERE b=?::int", "SELECT a FROM t WHERE b=$1::int"},
+		// already numbered, so unchanged
+		{"SELECT a FROM t WHERE data ? $1", "SELECT a FROM t WHERE data ? $1"},
+		{"SELECT a FROM t WHERE b=$1 AND c=$2", "SELECT a FROM t WHERE b=$1 AND c=$2"},
+		// unterminated elements
+		{"SELECT 'what? ", "SELECT 'what? "},
+		{"SELECT $$what? ", "SELECT $$what? "},
+	}
+	for _, c := range cases {
+		s := Postgres().ReplacePlaceholders(c.input, nil)
+		expect.String(s).I(c.input).ToBe(t, c.expected)
+
+		s = Postgres().ReplacePlaceholders(s, nil) // idempotent
+		expect.String(s).I(c.input).ToBe(t, c.expected)
+	}
+}
+
+func TestCountPlaceholders(t *testing.T) {
+	cases := []struct {
+		di       Dialect
+		input    string
+		expected int
+	}{
+		{Sqlite(), "SELECT a FROM t", 0},
+		{Sqlite(), "SELECT a FROM t WHERE b=? AND c='?' AND d=?", 2},
+		{Sqlite(), "SELECT a FROM t WHERE b=:b", -1},
+		{Sqlite(), "SELECT a FROM t WHERE b=?1 OR c=?1", 1},
+		{Sqlite(), "SELECT a FROM t WHERE b=?2 AND c=? AND d=?1", 3},
+		{Sqlite(), "SELECT a FROM t WHERE b=$1", -1},
+		{Sqlite(), "SELECT a FROM t WHERE b=$b AND c='$c'", -1},
+		{Sqlite(), "SELECT a FROM t WHERE b=@b", -1},
+		{Mysql(), "SELECT a FROM t WHERE b=? # c=?", 1},
+		{Postgres(), "SELECT a FROM t WHERE b=? AND c=?", 2},
+		{Postgres(), "SELECT a FROM t WHERE data ?? 'k' AND b=?", 1},
+		{Postgres(), "SELECT a FROM t WHERE b=$2 AND c=$1 AND d=$2", 2},
+		{Postgres(), "SELECT a FROM t WHERE data ? 'k' AND b=$1", 1},
+		{Postgres(), "SELECT $$?$$ FROM t WHERE b=?::int", 1},
+		{SqlServer(), "SELECT a FROM [t?] WHERE b=@p1 AND c=@p3", 3},
+		{SqlServer(), "SELECT a FROM t WHERE b=@pa", -1},
+	}
+	for _, c := range cases {
+		n := c.di.CountPlaceholders(c.input)
+		expect.Number(n).I(c.input).ToBe(t, c.expected)
+	}
+}
@@ -0,0 +1,104 @@
+package driver
+
+import (
+	sqldriver "database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// literal writes a value as an SQL literal. See Dialect.Literal.
+func (lit literals) literal(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return lit.quote(v), nil
+	case []byte:
+		if v == nil {
+			return "NULL", nil
+		}
+		return lit.blob(v), nil
+	case bool:
+		return lit.boolean(v), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", v), nil
+	case float32:
+		return float(float64(v), 32)
+	case float64:
+		return float(v, 64)
+	case time.Time:
+		if lit.utc {
+			v = v.UTC()
+		}
+		return ansiString(v.Format(lit.timeLayout)), nil
+	case sqldriver.Valuer:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return "NULL", nil
+		}
+		dv, err := v.Value()
+		if err != nil {
+			return "", err
+		}
+		return lit.literal(dv)
+	}
+
+	// named types and pointers
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return "NULL", nil
+		}
+		return lit.literal(rv.Elem().Interface())
+	case reflect.String:
+		return lit.quote(rv.String()), nil
+	case reflect.Bool:
+		return lit.boolean(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32:
+		return float(rv.Float(), 32)
+	case reflect.Float64:
+		return float(rv.Float(), 64)
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return lit.literal(rv.Bytes())
+		}
+	}
+
+	if s, ok := value.(fmt.Stringer); ok {
+		return lit.quote(s.String()), nil
+	}
+	return "", fmt.Errorf("%T cannot be written as an SQL literal", value)
+}
+
+func (lit literals) boolean(b bool) string {
+	switch {
+	case lit.bitBools && b:
+		return "1"
+	case lit.bitBools:
+		return "0"
+	case b:
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+func float(f float64, bitSize int) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("%v cannot be written as an SQL literal", f)
+	}
+	return strconv.FormatFloat(f, 'g', -1, bitSize), nil
+}
+
+// hexBlob writes a binary literal as X'...', which SQLite and MySQL accept.
+func hexBlob(b []byte) string {
+	return "X'" + hex.EncodeToString(b) + "'"
+}
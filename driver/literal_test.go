@@ -0,0 +1,65 @@
+package driver
+
+import (
+	"database/sql"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/rickb777/expect"
+)
+
+func TestLiteral(t *testing.T) {
+	type Colour string
+	tm := time.Date(2024, 2, 3, 4, 5, 6, 700000000, time.FixedZone("X", 3600))
+	s := "x"
+	var nilPtr *string
+
+	cases := []struct {
+		di       Dialect
+		value    any
+		expected string
+	}{
+		{Sqlite(), nil, "NULL"},
+		{Sqlite(), nilPtr, "NULL"},
+		{Sqlite(), &s, "'x'"},
+		{Sqlite(), "it's", "'it''s'"},
+		{Sqlite(), Colour("red"), "'red'"},
+		{Sqlite(), int64(-42), "-42"},
+		{Sqlite(), uint8(7), "7"},
+		{Sqlite(), 1.5, "1.5"},
+		{Sqlite(), float32(0.1), "0.1"},
+		{Sqlite(), true, "TRUE"},
+		{Sqlite(), []byte{0x0a, 0xff}, "X'0aff'"},
+		{Sqlite(), []byte(nil), "NULL"},
+		{Sqlite(), tm, "'2024-02-03 04:05:06.7+01:00'"},
+		{Sqlite(), sql.NullString{}, "NULL"},
+		{Sqlite(), sql.NullInt64{Int64: 3, Valid: true}, "3"},
+		{Mysql(), `a\b'c`, `'a\\b''c'`},
+		{Mysql(), []byte{0x0a, 0xff}, "X'0aff'"},
+		{Mysql(), tm, "'2024-02-03 03:05:06.7'"},
+		{Postgres(), []byte{0x0a, 0xff}, `'\x0aff'::bytea`},
+		{Postgres(), false, "FALSE"},
+		{Postgres(), tm, "'2024-02-03 04:05:06.7+01:00'"},
+		{Pgx(), "a", "'a'"},
+		{SqlServer(), "a", "N'a'"},
+		{SqlServer(), true, "1"},
+		{SqlServer(), []byte{0x0a, 0xff}, "0x0aff"},
+		{SqlServer(), tm, "'2024-02-03T03:05:06.7'"},
+		{DuckDB(), []byte{0x0a, 0xff}, `'\x0A\xFF'::BLOB`},
+		{DuckDB(), tm, "'2024-02-03 03:05:06.7'"},
+		{Modernc(), true, "TRUE"},
+	}
+
+	for _, c := range cases {
+		actual, err := c.di.Literal(c.value)
+		expect.Error(err).I(c.di.Name()).Info(c.value).Not().ToHaveOccurred(t)
+		expect.String(actual).I(c.di.Name()).Info(c.value).ToBe(t, c.expected)
+	}
+
+	_, err := Postgres().Literal(math.NaN())
+	expect.Error(err).ToHaveOccurred(t)
+
+	_, err = Postgres().Literal(struct{}{})
+	expect.Error(err).ToContain(t, "cannot be written")
+}
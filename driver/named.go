@@ -0,0 +1,191 @@
+package driver
+
+import (
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rickb777/sqlapi/types"
+)
+
+// replaceNamed replaces each ':name' or '@name' placeholder with a positional placeholder,
+// returning the names in the order their values are needed. For dialects with numbered
+// placeholders, a name that is used more than once gets the same number each time; otherwise
+// the name is repeated in the list of names.
+func (syn *syntax) replaceNamed(sql string) (string, []string, error) {
+	list := syn.lex(sql)
+	if !list.has(tNamedPlaceholder) {
+		return sql, nil, nil
+	}
+
+	if list.has(tPlaceholder) || list.has(tNumberedPlaceholder) {
+		return "", nil, fmt.Errorf("positional placeholders cannot be mixed with named parameters: %s", sql)
+	}
+
+	var names []string
+	numbers := make(map[string]int)
+
+	buf := &strings.Builder{}
+	buf.Grow(len(sql))
+	for _, t := range list {
+		switch t.kind {
+		case tNamedPlaceholder:
+			name := t.text[1:]
+			if syn.numberedPrefix == "" {
+				buf.WriteByte('?')
+				names = append(names, name)
+			} else {
+				n, exists := numbers[name]
+				if !exists {
+					names = append(names, name)
+					n = len(names)
+					numbers[name] = n
+				}
+				buf.WriteString(syn.numberedPrefix)
+				buf.WriteString(strconv.Itoa(n))
+			}
+		case tEscapedQuestion:
+			buf.WriteByte('?')
+		default:
+			buf.WriteString(t.text)
+		}
+	}
+	return buf.String(), names, nil
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// BindArgs prepares a query and its arguments for execution using a dialect.
+//
+// Normally, the query uses '?' placeholders and the arguments are positional; the placeholders
+// are replaced as needed by the dialect (see Dialect.ReplacePlaceholders).
+//
+// Alternatively, the query can use ':name' or '@name' placeholders (or '$name' for SQLite). In
+// this case, the arguments must be either
+//
+//   - all sql.NamedArg values, or
+//   - a single map with string keys, or
+//   - a single struct (or pointer to struct); fields are matched by their 'sql' tag name or
+//     otherwise by their field name, ignoring case.
+//
+// The named placeholders are replaced with positional placeholders and the values are
+// returned in the corresponding order. A name may be used several times. If the query has no
+// named placeholders, the arguments are passed through unchanged.
+func BindArgs(di Dialect, query string, args []interface{}) (string, []interface{}, error) {
+	lookup := namedValuesOf(args)
+	if lookup == nil {
+		return di.ReplacePlaceholders(query, args), args, nil
+	}
+
+	q2, names, err := di.ReplaceNamedPlaceholders(query)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if names == nil {
+		// no named placeholders, so the arguments are passed through unchanged
+		return di.ReplacePlaceholders(query, args), args, nil
+	}
+
+	values := make([]interface{}, len(names))
+	for i, name := range names {
+		v, ok := lookup(name)
+		if !ok {
+			return "", nil, fmt.Errorf("named parameter %q has no value: %s", name, query)
+		}
+		values[i] = v
+	}
+	return q2, values, nil
+}
+
+// namedValuesOf returns a function to look up argument values by name, or nil if the
+// arguments are positional.
+func namedValuesOf(args []interface{}) func(string) (interface{}, bool) {
+	if len(args) == 0 {
+		return nil
+	}
+
+	if _, isNamed := args[0].(sql.NamedArg); isNamed {
+		for _, a := range args {
+			if _, isNamed = a.(sql.NamedArg); !isNamed {
+				return nil
+			}
+		}
+		return func(name string) (interface{}, bool) {
+			for _, a := range args {
+				if na := a.(sql.NamedArg); na.Name == name {
+					return na.Value, true
+				}
+			}
+			return nil, false
+		}
+	}
+
+	if len(args) > 1 || args[0] == nil {
+		return nil
+	}
+
+	switch args[0].(type) {
+	case sqldriver.Valuer, time.Time, *time.Time:
+		return nil
+	}
+
+	v := reflect.ValueOf(args[0])
+	if v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.Struct {
+		v = v.Elem()
+	}
+
+	switch {
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		return func(name string) (interface{}, bool) {
+			e := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+			if !e.IsValid() {
+				return nil, false
+			}
+			return e.Interface(), true
+		}
+
+	case v.Kind() == reflect.Struct:
+		return func(name string) (interface{}, bool) {
+			return structField(v, name)
+		}
+	}
+
+	return nil
+}
+
+// structField finds an exported field by its 'sql' tag name, or otherwise by its name
+// ignoring case. Embedded structs are searched too.
+func structField(v reflect.Value, name string) (interface{}, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		tag, _ := types.ParseTag(string(sf.Tag))
+		if tag != nil && tag.Skip {
+			continue
+		}
+
+		if tag != nil && tag.Name != "" {
+			if tag.Name == name {
+				return v.Field(i).Interface(), true
+			}
+		} else if strings.EqualFold(sf.Name, name) {
+			return v.Field(i).Interface(), true
+		}
+
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			if x, ok := structField(v.Field(i), name); ok {
+				return x, true
+			}
+		}
+	}
+	return nil, false
+}
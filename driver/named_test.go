@@ -0,0 +1,88 @@
+package driver
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/rickb777/expect"
+)
+
+func TestReplaceNamedPlaceholders(t *testing.T) {
+	cases := []struct {
+		di       Dialect
+		input    string
+		expected string
+		names    []string
+	}{
+		{Sqlite(), "SELECT a FROM t", "SELECT a FROM t", nil},
+		{Sqlite(), "SELECT a FROM t WHERE b=:b AND c=@c OR d=:b", "SELECT a FROM t WHERE b=? AND c=? OR d=?", []string{"b", "c", "b"}},
+		{Sqlite(), "SELECT ':x', \"@y\" FROM t -- :z\n WHERE b=:b", "SELECT ':x', \"@y\" FROM t -- :z\n WHERE b=?", []string{"b"}},
+		{Mysql(), "SELECT a FROM t WHERE b=:b AND c=:c", "SELECT a FROM t WHERE b=? AND c=?", []string{"b", "c"}},
+		{Mysql(), "SELECT @@version, a FROM t WHERE b=@b", "SELECT @@version, a FROM t WHERE b=?", []string{"b"}},
+		{Postgres(), "SELECT a FROM t WHERE b=:b AND c=:c OR d=:b", "SELECT a FROM t WHERE b=$1 AND c=$2 OR d=$1", []string{"b", "c"}},
+		{Postgres(), "SELECT a::text FROM t WHERE b=:b::int", "SELECT a::text FROM t WHERE b=$1::int", []string{"b"}},
+		{Postgres(), "SELECT a FROM t WHERE data @> :doc AND k ?? 'x'", "SELECT a FROM t WHERE data @> $1 AND k ? 'x'", []string{"doc"}},
+		{Postgres(), "SELECT $$:x$$, a FROM t WHERE b=:b_1", "SELECT $$:x$$, a FROM t WHERE b=$1", []string{"b_1"}},
+		{SqlServer(), "SELECT @@ROWCOUNT, a FROM [t] WHERE b=@b AND c=@c OR d=@b", "SELECT @@ROWCOUNT, a FROM [t] WHERE b=@p1 AND c=@p2 OR d=@p1", []string{"b", "c"}},
+	}
+	for _, c := range cases {
+		s, names, err := c.di.ReplaceNamedPlaceholders(c.input)
+		expect.Error(err).I(c.input).Not().ToHaveOccurred(t)
+		expect.String(s).I(c.input).ToBe(t, c.expected)
+		expect.Slice(names).I(c.input).ToBe(t, c.names...)
+	}
+}
+
+func TestReplaceNamedPlaceholders_mixed(t *testing.T) {
+	for _, di := range []Dialect{Sqlite(), Mysql(), Postgres()} {
+		_, _, err := di.ReplaceNamedPlaceholders("SELECT a FROM t WHERE b=:b AND c=?")
+		expect.Error(err).I(di.Name()).ToContain(t, "cannot be mixed")
+	}
+}
+
+func TestBindArgs(t *testing.T) {
+	type Base struct {
+		ID int64
+	}
+	type Person struct {
+		Base
+		Name  string `sql:"name: name"`
+		Email string `sql:"name: email_address"`
+		Age   int    `sql:"-"`
+	}
+	const query = "SELECT * FROM people WHERE id=:id AND (name=:name OR email=:email_address OR nick=:name)"
+	const expected = "SELECT * FROM people WHERE id=$1 AND (name=$2 OR email=$3 OR nick=$2)"
+
+	cases := []struct {
+		name string
+		args []interface{}
+	}{
+		{"NamedArg", []interface{}{sql.Named("name", "Ann"), sql.Named("id", int64(7)), sql.Named("email_address", "a@b.c")}},
+		{"map", []interface{}{map[string]interface{}{"id": int64(7), "name": "Ann", "email_address": "a@b.c"}}},
+		{"struct", []interface{}{Person{Base: Base{ID: 7}, Name: "Ann", Email: "a@b.c"}}},
+		{"pointer", []interface{}{&Person{Base: Base{ID: 7}, Name: "Ann", Email: "a@b.c"}}},
+	}
+	for _, c := range cases {
+		q, args, err := BindArgs(Postgres(), query, c.args)
+		expect.Error(err).I(c.name).Not().ToHaveOccurred(t)
+		expect.String(q).I(c.name).ToBe(t, expected)
+		expect.Slice(args).I(c.name).ToBe(t, int64(7), "Ann", "a@b.c")
+	}
+}
+
+func TestBindArgs_positional(t *testing.T) {
+	q, args, err := BindArgs(Postgres(), "SELECT * FROM t WHERE a=? AND b=?", []interface{}{1, "x"})
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.String(q).ToBe(t, "SELECT * FROM t WHERE a=$1 AND b=$2")
+	expect.Slice(args).ToBe(t, 1, "x")
+
+	q, args, err = BindArgs(Sqlite(), "SELECT * FROM t WHERE a=?", []interface{}{sql.Named("a", 1)})
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.String(q).ToBe(t, "SELECT * FROM t WHERE a=?")
+	expect.Slice(args).ToBe(t, sql.Named("a", 1))
+}
+
+func TestBindArgs_missing(t *testing.T) {
+	_, _, err := BindArgs(Mysql(), "SELECT * FROM t WHERE a=:a AND b=:b", []interface{}{map[string]int{"a": 1}})
+	expect.Error(err).ToContain(t, `named parameter "b" has no value`)
+}
@@ -0,0 +1,147 @@
+package driver
+
+import (
+	"strings"
+)
+
+// Statement is one statement in an SQL script.
+type Statement struct {
+	SQL  string // the statement, without its delimiter
+	Line int    // the line in the script where the statement starts, counting from 1
+}
+
+// splitter accumulates the statements while a script is scanned.
+type splitter struct {
+	script  string
+	list    []Statement
+	first   int // where the current statement starts, or -1 if it has no content yet
+	depth   int // the nesting of BEGIN...END and CASE...END in the current statement
+	line    int // the line number at position counted
+	counted int
+}
+
+func (sp *splitter) mark(at int) {
+	if sp.first < 0 {
+		sp.first = at
+	}
+}
+
+func (sp *splitter) end(at int) {
+	if sp.first >= 0 {
+		sp.line += strings.Count(sp.script[sp.counted:sp.first], "\n")
+		sp.counted = sp.first
+		sp.list = append(sp.list, Statement{SQL: strings.TrimSpace(sp.script[sp.first:at]), Line: sp.line})
+	}
+	sp.first = -1
+	sp.depth = 0
+}
+
+// splitStatements splits a script into its statements, which are delimited by ';'. Delimiters
+// within string literals, quoted identifiers, comments and dollar-quoted bodies are ignored.
+// Statements that are empty or contain only comments are dropped.
+//
+// Depending on the syntax,
+//   - a "DELIMITER xx" line changes the delimiter for the statements that follow (MySQL);
+//   - the statements within the BEGIN...END body of a trigger are kept together (SQLite);
+//   - if the script has any "GO" lines, these separate the statements instead of ';' (SQL Server).
+func (syn *syntax) splitStatements(script string) []Statement {
+	list := syn.lex(script)
+	sp := &splitter{script: script, first: -1, line: 1}
+	goBatches := syn.goBatches && hasGoLine(script, list)
+	delimiter := ";"
+
+	skip, offset := 0, 0 // skip is the end of text already consumed, e.g. by a DELIMITER line
+	for _, t := range list {
+		start := offset
+		offset += len(t.text)
+
+		if t.kind != tText {
+			if t.kind != tComment && start >= skip {
+				sp.mark(start)
+			}
+			continue
+		}
+
+		for i := max(start, skip); i < offset; {
+			c := script[i]
+			switch {
+			case goBatches && isGoLine(script, i):
+				sp.end(i)
+				i = endOfLine(script, i)
+				skip = i
+
+			case syn.delimiterCommand && sp.first < 0 && isDelimiterLine(script, i):
+				eol := endOfLine(script, i)
+				delimiter = strings.TrimSpace(script[i+len("DELIMITER") : eol])
+				i = eol
+				skip = i
+
+			case !goBatches && strings.HasPrefix(script[i:], delimiter):
+				if sp.depth > 0 {
+					i++ // within a trigger body
+				} else {
+					sp.end(i)
+					i += len(delimiter)
+				}
+
+			case syn.triggerBlocks && isIdentStart(c) && !isIdentChar(prev(script, i)):
+				end := endOfIdent(script, i)
+				switch word := script[i:end]; {
+				case strings.EqualFold(word, "BEGIN"), strings.EqualFold(word, "CASE"):
+					if sp.first >= 0 {
+						sp.depth++ // BEGIN at the start of a statement starts a transaction instead
+					}
+				case strings.EqualFold(word, "END"):
+					if sp.depth > 0 {
+						sp.depth--
+					}
+				}
+				sp.mark(i)
+				i = end
+
+			default:
+				if !isSpaceOrEnd(script, i) {
+					sp.mark(i)
+				}
+				i++
+			}
+		}
+	}
+
+	sp.end(len(script))
+	return sp.list
+}
+
+// isGoLine tests whether a line consisting only of "GO" starts at script[i].
+func isGoLine(script string, i int) bool {
+	if i > 0 && script[i-1] != '\n' {
+		return false
+	}
+	line := strings.TrimSpace(script[i:endOfLine(script, i)])
+	return strings.EqualFold(line, "GO")
+}
+
+func hasGoLine(script string, list tokens) bool {
+	offset := 0
+	for _, t := range list {
+		if t.kind == tText {
+			for i := offset; i < offset+len(t.text); i++ {
+				if isGoLine(script, i) {
+					return true
+				}
+			}
+		}
+		offset += len(t.text)
+	}
+	return false
+}
+
+// isDelimiterLine tests whether a line such as "DELIMITER //" starts at script[i].
+func isDelimiterLine(script string, i int) bool {
+	const keyword = "DELIMITER"
+	if (i > 0 && script[i-1] != '\n') || len(script) < i+len(keyword) ||
+		!strings.EqualFold(script[i:i+len(keyword)], keyword) || !isSpaceOrEnd(script, i+len(keyword)) {
+		return false
+	}
+	return strings.TrimSpace(script[i+len(keyword):endOfLine(script, i)]) != ""
+}
@@ -0,0 +1,43 @@
+package driver
+
+import (
+	"testing"
+
+	"github.com/rickb777/expect"
+)
+
+func TestSplitStatements(t *testing.T) {
+	cases := []struct {
+		di       Dialect
+		script   string
+		expected []Statement
+	}{
+		{Sqlite(), "", nil},
+		{Sqlite(), " \n-- nothing here\n;;\n", nil},
+		{Sqlite(), "SELECT 1", []Statement{{"SELECT 1", 1}}},
+		{Sqlite(), "SELECT 1;\nSELECT 2;\n\n  SELECT 3",
+			[]Statement{{"SELECT 1", 1}, {"SELECT 2", 2}, {"SELECT 3", 4}}},
+		{Sqlite(), "INSERT INTO t VALUES ('a;b', \"c;d\", [e;f], `g;h`); -- x;y\n/* p;\nq */ SELECT 2;",
+			[]Statement{{"INSERT INTO t VALUES ('a;b', \"c;d\", [e;f], `g;h`)", 1}, {"SELECT 2", 3}}},
+		{Sqlite(), "-- leading comment\nSELECT 1 -- trailing;\n;",
+			[]Statement{{"SELECT 1 -- trailing;", 2}}},
+		{Sqlite(), "BEGIN;\nCREATE TRIGGER tr AFTER INSERT ON t BEGIN\n  UPDATE u SET n = CASE WHEN n > 0 THEN n+1 ELSE 1 END;\n  DELETE FROM v;\nEND;\nCOMMIT;",
+			[]Statement{{"BEGIN", 1}, {"CREATE TRIGGER tr AFTER INSERT ON t BEGIN\n  UPDATE u SET n = CASE WHEN n > 0 THEN n+1 ELSE 1 END;\n  DELETE FROM v;\nEND", 2}, {"COMMIT", 6}}},
+		{Postgres(), "CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql;\nSELECT $tag$;$tag$, E'\\';', ';';",
+			[]Statement{{"CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql", 1}, {"SELECT $tag$;$tag$, E'\\';', ';'", 2}}},
+		{Postgres(), "SELECT 1 /* a /* nested; */ comment; */;",
+			[]Statement{{"SELECT 1 /* a /* nested; */ comment; */", 1}}},
+		{Mysql(), "SELECT 'a\\';b', \"c;d\"; # e;f\nSELECT 2;",
+			[]Statement{{"SELECT 'a\\';b', \"c;d\"", 1}, {"SELECT 2", 2}}},
+		{Mysql(), "DROP PROCEDURE IF EXISTS p;\nDELIMITER //\nCREATE PROCEDURE p()\nBEGIN\n  SELECT 1;\n  SELECT 2;\nEND//\nDELIMITER ;\nCALL p();\n",
+			[]Statement{{"DROP PROCEDURE IF EXISTS p", 1}, {"CREATE PROCEDURE p()\nBEGIN\n  SELECT 1;\n  SELECT 2;\nEND", 3}, {"CALL p()", 9}}},
+		{SqlServer(), "SELECT 1; SELECT [a;b];", []Statement{{"SELECT 1", 1}, {"SELECT [a;b]", 1}}},
+		{SqlServer(), "CREATE PROCEDURE p AS\nBEGIN\n  SELECT 1;\n  SELECT 2;\nEND\ngo\n-- GO\nEXEC p;\nGO\n",
+			[]Statement{{"CREATE PROCEDURE p AS\nBEGIN\n  SELECT 1;\n  SELECT 2;\nEND", 1}, {"EXEC p;", 8}}},
+		{DuckDB(), "SELECT 1;SELECT 2", []Statement{{"SELECT 1", 1}, {"SELECT 2", 1}}},
+	}
+	for _, c := range cases {
+		actual := c.di.SplitStatements(c.script)
+		expect.Slice(actual).I(c.di.Name()).Info(c.script).ToBe(t, c.expected...)
+	}
+}
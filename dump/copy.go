@@ -0,0 +1,295 @@
+package dump
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/tracelog"
+	"github.com/rickb777/sqlapi"
+	"github.com/rickb777/sqlapi/driver"
+	"github.com/rickb777/sqlapi/introspect"
+	"github.com/rickb777/sqlapi/schema"
+	"github.com/rickb777/sqlapi/types"
+	"github.com/rickb777/where/dialect"
+)
+
+// DefaultCopyBatchSize is the number of rows inserted by each statement when using Copy.
+const DefaultCopyBatchSize = 100
+
+// Copy copies all the rows of the tables from one database to another, which may use a
+// different dialect. It is shorthand for
+//
+//	dump.New(src).WithBatchSize(dump.DefaultCopyBatchSize).Copy(ctx, dst, tables...)
+func Copy(ctx context.Context, src, dst sqlapi.Execer, tables ...sqlapi.TableName) error {
+	return New(src).WithBatchSize(DefaultCopyBatchSize).Copy(ctx, dst, tables...)
+}
+
+// Copy copies all the rows of the tables into another database, in the order the tables are
+// listed. The tables must already exist in the destination and must have all the columns that
+// are in the source tables; the destination is introspected to find the column types.
+//
+// The values are converted as needed for the destination columns. Integers become booleans
+// for boolean columns and vice versa (SQLite has no boolean type, so booleans are stored as
+// 0 or 1). Unsigned integers too large for a signed 64-bit column are passed as decimal
+// strings, which suits PostgreSQL's numeric type. Text returned as []byte becomes a string.
+//
+// The rows are inserted using parameterised multi-row INSERT statements of up to the batch
+// size (see WithBatchSize), limited by the number of parameters the destination allows and,
+// for SQL Server, by its limit of 1000 rows in a VALUES list. If dst is a SqlDB, each table
+// is copied within its own transaction.
+//
+// Auto-increment columns are copied verbatim. For SQL Server, IDENTITY_INSERT is enabled
+// whilst copying; for PostgreSQL, the sequences are reset afterwards so that new rows follow
+// on from the copied ones. DuckDB sequences are not adjusted.
+//
+// Progress is reported via dst.Logger(): each batch is logged at debug level and each
+// completed table at info level.
+func (d *Dumper) Copy(ctx context.Context, dst sqlapi.Execer, tables ...sqlapi.TableName) error {
+	for _, name := range tables {
+		var err error
+		if db, ok := dst.(sqlapi.SqlDB); ok {
+			err = db.Transact(ctx, nil, func(tx sqlapi.SqlTx) error {
+				return d.copyTable(ctx, tx, name)
+			})
+		} else {
+			err = d.copyTable(ctx, dst, name)
+		}
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (d *Dumper) copyTable(ctx context.Context, dst sqlapi.Execer, name sqlapi.TableName) error {
+	startTime := time.Now()
+	di := dst.Dialect()
+	q := di.Quoter()
+
+	table, _, err := introspect.ReadTable(ctx, dst, name)
+	if err != nil {
+		return err
+	}
+
+	sqlRows, err := d.ex.Query(ctx, "SELECT * FROM "+d.ex.Dialect().Quoter().Quote(name.String()))
+	if err != nil {
+		return err
+	}
+	defer sqlRows.Close()
+
+	rows, err := sqlapi.WrapRows(sqlRows)
+	if err != nil {
+		return err
+	}
+
+	columns, err := sqlRows.Columns()
+	if err != nil {
+		return err
+	}
+
+	fields := make([]*schema.Field, len(columns))
+	identity := false
+	for i, c := range columns {
+		fields[i] = findField(table, c)
+		if fields[i] == nil {
+			return fmt.Errorf("column %s does not exist in the destination", c)
+		}
+		identity = identity || fields[i].Tags.Auto
+	}
+	identity = identity && di.Index() == dialect.SqlServer
+
+	quotedTable := q.Quote(name.String())
+	if identity {
+		if _, err = dst.Exec(ctx, "SET IDENTITY_INSERT "+quotedTable+" ON"); err != nil {
+			return err
+		}
+	}
+
+	ins := &inserter{
+		dst:    dst,
+		table:  name,
+		prefix: fmt.Sprintf("INSERT INTO %s (%s) VALUES ", quotedTable, strings.Join(q.QuoteN(columns), ", ")),
+		tuple:  "(" + strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",") + ")",
+		batch:  max(min(d.batch, maxParameters(di)/len(columns)), 1),
+	}
+	if di.Index() == dialect.SqlServer {
+		ins.batch = min(ins.batch, maxSqlServerRows)
+	}
+
+	for rows.Next() {
+		row, err := rows.ScanToMap()
+		if err != nil {
+			return err
+		}
+
+		for i, c := range columns {
+			ins.args = append(ins.args, convert(row.Data[c], row.ColumnTypes[i].DatabaseTypeName(), fields[i], di))
+		}
+
+		if err = ins.add(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	if err = ins.flush(ctx); err != nil {
+		return err
+	}
+
+	if identity {
+		if _, err = dst.Exec(ctx, "SET IDENTITY_INSERT "+quotedTable+" OFF"); err != nil {
+			return err
+		}
+	}
+
+	if err = resetSequences(ctx, dst, name, fields); err != nil {
+		return err
+	}
+
+	dst.Logger().LogT(ctx, tracelog.LogLevelInfo, "Copy", &startTime, "table", name.String(), "rows", ins.count)
+	return nil
+}
+
+func findField(table *schema.TableDescription, column string) *schema.Field {
+	for _, f := range table.Fields {
+		if strings.EqualFold(f.SqlName, column) {
+			return f
+		}
+	}
+	return nil
+}
+
+// maxParameters gives the most placeholder parameters that a dialect allows in one statement.
+func maxParameters(di driver.Dialect) int {
+	switch di.Index() {
+	case dialect.SqlServer:
+		return 2000 // the limit is 2100, less some for the driver's own use
+	case dialect.Sqlite:
+		return 32766
+	}
+	return math.MaxUint16
+}
+
+// convert alters a value read from the source to suit the destination column.
+func convert(value any, srcType string, f *schema.Field, di driver.Dialect) any {
+	if b, ok := value.([]byte); ok && b != nil && !isBinary(srcType) {
+		value = string(b) // some drivers, notably MySQL, return text as []byte
+	}
+
+	switch v := value.(type) {
+	case bool:
+		if f.Type.Base != types.Bool || di.Index() == dialect.Sqlite {
+			return boolToInt(v)
+		}
+
+	case int64:
+		if f.Type.Base == types.Bool {
+			return v != 0
+		}
+
+	case string:
+		if f.Type.Base == types.Bool {
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b
+			}
+		}
+
+	case uint:
+		return unsigned(uint64(v))
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case uint64:
+		return unsigned(v)
+	}
+
+	return value
+}
+
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func unsigned(u uint64) any {
+	if u > math.MaxInt64 {
+		return strconv.FormatUint(u, 10)
+	}
+	return int64(u)
+}
+
+// resetSequences sets the PostgreSQL sequences behind auto-increment columns to follow on
+// from the largest copied value.
+func resetSequences(ctx context.Context, dst sqlapi.Execer, name sqlapi.TableName, fields []*schema.Field) error {
+	di := dst.Dialect()
+	if di.Index() != dialect.Postgres || driver.IsDuckDB(di) {
+		return nil
+	}
+
+	q := di.Quoter()
+	quotedTable := q.Quote(name.String())
+	for _, f := range fields {
+		if f.Tags.Auto {
+			col := q.Quote(f.SqlName)
+			query := fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', '%s'), COALESCE(MAX(%s), 0) + 1, false) FROM %s",
+				strings.ReplaceAll(quotedTable, "'", "''"), strings.ReplaceAll(f.SqlName, "'", "''"), col, quotedTable)
+			if _, err := dst.Exec(ctx, query); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// inserter accumulates the parameters of one multi-row INSERT statement.
+type inserter struct {
+	dst    sqlapi.Execer
+	table  sqlapi.TableName
+	prefix string
+	tuple  string
+	batch  int
+	rows   int
+	count  int64
+	args   []any
+}
+
+// add counts the row whose values have just been appended to args.
+func (ins *inserter) add(ctx context.Context) error {
+	ins.rows++
+	if ins.rows >= ins.batch {
+		return ins.flush(ctx)
+	}
+	return nil
+}
+
+func (ins *inserter) flush(ctx context.Context) error {
+	if ins.rows == 0 {
+		return nil
+	}
+
+	query := ins.prefix + strings.TrimSuffix(strings.Repeat(ins.tuple+",", ins.rows), ",")
+	if _, err := ins.dst.Exec(ctx, query, ins.args...); err != nil {
+		return err
+	}
+
+	ins.count += int64(ins.rows)
+	ins.rows = 0
+	ins.args = ins.args[:0]
+	ins.dst.Logger().LogT(ctx, tracelog.LogLevelDebug, "Copy progress", nil, "table", ins.table.String(), "rows", ins.count)
+	return nil
+}
@@ -0,0 +1,75 @@
+package dump_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"path/filepath"
+	"testing"
+
+	"github.com/rickb777/expect"
+	"github.com/rickb777/sqlapi"
+	"github.com/rickb777/sqlapi/driver"
+	"github.com/rickb777/sqlapi/dump"
+	"github.com/rickb777/sqlapi/pgxapi/logadapter"
+)
+
+var flags = sqlapi.TableName{Prefix: "dump_", Name: "flags"}
+
+func TestCopy(t *testing.T) {
+	ctx := context.Background()
+
+	err := sqlapi.ExecScript(ctx, gdb, `DROP TABLE IF EXISTS dump_flags;
+CREATE TABLE dump_flags (id integer primary key, name varchar(20), flag boolean, n bigint);
+INSERT INTO dump_flags VALUES (1, 'one', TRUE, 10);
+INSERT INTO dump_flags VALUES (2, 'two', FALSE, NULL);
+INSERT INTO dump_flags VALUES (3, NULL, NULL, -30);`)
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	buf := &bytes.Buffer{}
+	lgr := sqlapi.NewLogger(logadapter.NewLogger(log.New(buf, "", 0)))
+	dst, err := sqlapi.Connect(ctx, "sqlite3", filepath.Join(t.TempDir(), "copy.db"), driver.Sqlite(), lgr, 1)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	defer dst.Close()
+
+	err = sqlapi.ExecScript(ctx, dst, `CREATE TABLE dump_flags (id integer primary key autoincrement, name text, flag boolean, n integer);
+CREATE TABLE dump_notes (id integer primary key, title text);`)
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	err = dump.New(gdb).WithBatchSize(2).Copy(ctx, dst, flags)
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	rows, err := dst.Query(ctx, "SELECT id, name, flag, typeof(flag), n FROM dump_flags ORDER BY id")
+	expect.Error(err).Not().ToHaveOccurred(t)
+	defer rows.Close()
+
+	type flagRow struct {
+		id   int64
+		name *string
+		flag *bool
+		typ  string
+		n    *int64
+	}
+	var actual []flagRow
+	for rows.Next() {
+		var r flagRow
+		err = rows.Scan(&r.id, &r.name, &r.flag, &r.typ, &r.n)
+		expect.Error(err).Not().ToHaveOccurred(t)
+		actual = append(actual, r)
+	}
+	expect.Slice(actual).ToHaveLength(t, 3)
+	expect.String(*actual[0].name).ToBe(t, "one")
+	expect.Bool(*actual[0].flag).ToBeTrue(t)
+	expect.String(actual[0].typ).ToBe(t, "integer") // booleans are stored as integers in SQLite
+	expect.Bool(*actual[1].flag).ToBeFalse(t)
+	expect.Any(actual[2].flag).ToBeNil(t)
+	expect.Number(*actual[2].n).ToBe(t, -30)
+
+	expect.String(buf.String()).ToContain(t, "Copy progress")
+	expect.String(buf.String()).ToContain(t, "Copy [rows:3 table:dump_flags took:")
+
+	// dump_notes in the destination lacks some of the source columns
+	createNotes(t)
+	err = dump.Copy(ctx, gdb, dst, notes)
+	expect.Error(err).ToContain(t, "does not exist in the destination")
+}
@@ -0,0 +1,151 @@
+package dump_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5/tracelog"
+	_ "github.com/lib/pq"
+	_ "github.com/marcboeker/go-duckdb"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/rickb777/expect"
+	"github.com/rickb777/sqlapi"
+	"github.com/rickb777/sqlapi/driver"
+	"github.com/rickb777/sqlapi/dump"
+	"github.com/rickb777/sqlapi/support/testenv"
+	_ "modernc.org/sqlite"
+)
+
+var gdb sqlapi.SqlDB
+
+var notes = sqlapi.TableName{Prefix: "dump_", Name: "notes"}
+
+func createNotes(t *testing.T) {
+	t.Helper()
+	ctx := context.Background()
+	blob := "blob"
+	switch {
+	case driver.IsDuckDB(gdb.Dialect()):
+	case gdb.Dialect().Index() == driver.Postgres().Index():
+		blob = "bytea"
+	}
+
+	script := `DROP TABLE IF EXISTS dump_notes;
+CREATE TABLE dump_notes (id integer primary key, title varchar(100), body text, data ` + blob + `, score float);
+INSERT INTO dump_notes VALUES (1, 'It''s; here', 'a\b', NULL, 1.5);
+INSERT INTO dump_notes VALUES (2, '-- not a comment', NULL, NULL, -2);
+INSERT INTO dump_notes VALUES (3, '/* nor this */', '$$', NULL, 0);`
+
+	err := sqlapi.ExecScript(ctx, gdb, script)
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	q := gdb.Dialect().ReplacePlaceholders("UPDATE dump_notes SET data = ? WHERE id = ?", nil)
+	_, err = gdb.Exec(ctx, q, []byte{0, 1, 0xfe, 0xff}, 2)
+	expect.Error(err).Not().ToHaveOccurred(t)
+}
+
+func TestDump_format(t *testing.T) {
+	createNotes(t)
+	b := &strings.Builder{}
+
+	err := dump.New(gdb).WithDialect(driver.Postgres()).WithBatchSize(2).Dump(context.Background(), b, notes)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.String(b.String()).ToBe(t, `
+-- dump_notes
+INSERT INTO "dump_notes" ("id", "title", "body", "data", "score") VALUES
+	(1, 'It''s; here', 'a\b', NULL, 1.5),
+	(2, '-- not a comment', NULL, '\x0001feff'::bytea, -2);
+INSERT INTO "dump_notes" ("id", "title", "body", "data", "score") VALUES
+	(3, '/* nor this */', '$$', NULL, 0);
+`)
+
+	b.Reset()
+	err = dump.New(gdb).WithDialect(driver.Mysql()).Dump(context.Background(), b, notes)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.String(b.String()).ToContain(t, "INSERT INTO `dump_notes` (`id`, `title`, `body`, `data`, `score`) VALUES (1, 'It''s; here', 'a\\\\b', NULL, 1.5);\n")
+}
+
+func TestDump_crossDialect(t *testing.T) {
+	ctx := context.Background()
+	flags := sqlapi.TableName{Prefix: "dump_", Name: "flags"}
+
+	err := sqlapi.ExecScript(ctx, gdb, `DROP TABLE IF EXISTS dump_flags;
+CREATE TABLE dump_flags (id integer primary key, done boolean);
+INSERT INTO dump_flags VALUES (1, TRUE);
+INSERT INTO dump_flags VALUES (2, FALSE);`)
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	// modernc SQLite and MySQL return booleans as integers, which PostgreSQL would reject
+	b := &strings.Builder{}
+	err = dump.New(gdb).WithDialect(driver.Postgres()).Dump(ctx, b, flags)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.String(b.String()).ToBe(t, `
+-- dump_flags
+INSERT INTO "dump_flags" ("id", "done") VALUES (1, TRUE);
+INSERT INTO "dump_flags" ("id", "done") VALUES (2, FALSE);
+`)
+
+	b.Reset()
+	err = dump.New(gdb).WithDialect(driver.SqlServer()).WithBatchSize(2).Dump(ctx, b, flags)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.String(b.String()).ToBe(t, `
+-- dump_flags
+IF OBJECTPROPERTY(OBJECT_ID('dump_flags'), 'TableHasIdentity') = 1 SET IDENTITY_INSERT "dump_flags" ON;
+INSERT INTO "dump_flags" ("id", "done") VALUES
+	(1, 1),
+	(2, 0);
+IF OBJECTPROPERTY(OBJECT_ID('dump_flags'), 'TableHasIdentity') = 1 SET IDENTITY_INSERT "dump_flags" OFF;
+`)
+}
+
+func TestDumpAndLoad(t *testing.T) {
+	ctx := context.Background()
+	createNotes(t)
+	before := readAll(t)
+
+	for _, batch := range []int{1, 2, 10} {
+		buf := &bytes.Buffer{}
+		err := dump.New(gdb).WithBatchSize(batch).Dump(ctx, buf, notes)
+		expect.Error(err).Not().ToHaveOccurred(t)
+
+		_, err = gdb.Exec(ctx, "DELETE FROM dump_notes")
+		expect.Error(err).Not().ToHaveOccurred(t)
+
+		err = dump.Load(ctx, gdb, buf)
+		expect.Error(err).Info(batch).Not().ToHaveOccurred(t)
+		expect.Slice(readAll(t)).Info(batch).ToBe(t, before...)
+	}
+}
+
+func readAll(t *testing.T) []sqlapi.RowData {
+	t.Helper()
+	rows, err := gdb.Query(context.Background(), "SELECT * FROM dump_notes ORDER BY id")
+	expect.Error(err).Not().ToHaveOccurred(t)
+	defer rows.Close()
+
+	wrapped, err := sqlapi.WrapRows(rows)
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	var list []sqlapi.RowData
+	for wrapped.Next() {
+		row, err := wrapped.ScanToMap()
+		expect.Error(err).Not().ToHaveOccurred(t)
+		row.ColumnTypes = nil
+		list = append(list, row)
+	}
+	return list
+}
+
+//-------------------------------------------------------------------------------------------------
+
+func TestMain(m *testing.M) {
+	testenv.SetDefaultDbDriver("sqlite3")
+	testenv.Shebang(m, func(lgr tracelog.Logger, logLevel tracelog.LogLevel, tries int) (err error) {
+		gdb, err = sqlapi.ConnectEnv(context.Background(), lgr, logLevel, tries)
+		return err
+	})
+}
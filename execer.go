@@ -26,6 +26,7 @@ type Getter interface {
 	// Query executes a query that returns rows, typically a SELECT.
 	// The arguments are for any placeholder parameters in the query.
 	// Placeholders in the SQL are automatically replaced with numbered placeholders.
+	// Named parameters (':name' or '@name') are also supported; see driver.BindArgs.
 	Query(ctx context.Context, sql string, arguments ...interface{}) (SqlRows, error)
 
 	// QueryRowContext executes a query that is expected to return at most one row.
@@ -92,6 +93,17 @@ type SqlDB interface {
 	// With returns a modified SqlDB with a user-supplied item.
 	With(wrapped interface{}) SqlDB
 
+	// CheckArgCount returns a modified SqlDB that checks (or not) that the number of arguments
+	// matches the number of placeholders in each query. If they differ, a PlaceholderCountError
+	// is returned without the query being sent. This check is on by default.
+	CheckArgCount(on bool) SqlDB
+
+	// WithInterceptors returns a modified SqlDB that passes every call to Query, QueryRow, Exec,
+	// Insert, Transact, Commit and Rollback through the interceptors, which are added after any
+	// already present. The first interceptor is the outermost. Transactions and single
+	// connections obtained from the SqlDB use the same interceptors.
+	WithInterceptors(interceptors ...Interceptor) SqlDB
+
 	// UserItem gets a user-supplied item associated with this DB.
 	UserItem() interface{}
 }
@@ -35,6 +35,10 @@ func insertOne(t *testing.T, e Execer, query string) int64 {
 }
 
 func createTablesSql(di driver.Dialect) []string {
+	if driver.IsDuckDB(di) {
+		return createTablesDuckDB
+	}
+
 	switch di.Index() {
 	case dialect.Sqlite:
 		return createTablesSqlite
@@ -97,6 +101,27 @@ var createTablesPostgresql = []string{
 	)`,
 }
 
+var createTablesDuckDB = []string{
+	`DROP TABLE IF EXISTS pfx_addresses`,
+	`DROP TABLE IF EXISTS pfx_persons`,
+	`DROP SEQUENCE IF EXISTS pfx_addresses_id_seq`,
+	`DROP SEQUENCE IF EXISTS pfx_persons_id_seq`,
+	`CREATE SEQUENCE pfx_addresses_id_seq`,
+	`CREATE SEQUENCE pfx_persons_id_seq`,
+
+	`CREATE TABLE pfx_addresses (
+	id        integer primary key default nextval('pfx_addresses_id_seq'),
+	xlines    text,
+	postcode  text
+	)`,
+
+	`CREATE TABLE pfx_persons (
+	id        integer primary key default nextval('pfx_persons_id_seq'),
+	name      text,
+	addressid integer default null
+	)`,
+}
+
 const address1 = `INSERT INTO pfx_addresses (xlines, postcode) VALUES ('Laurel Cottage', 'FX1 1AA')`
 const address2 = `INSERT INTO pfx_addresses (xlines, postcode) VALUES ('2 Nutmeg Lane', 'FX1 2BB')`
 const address3 = `INSERT INTO pfx_addresses (xlines, postcode) VALUES ('Corner Shop', 'FX1 3CC')`
@@ -0,0 +1,147 @@
+package sqlapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/tracelog"
+	"github.com/rickb777/sqlapi/driver"
+	"github.com/rickb777/sqlapi/metrics"
+)
+
+// Operation identifies the method being intercepted.
+type Operation string
+
+const (
+	OpQuery    Operation = "Query"
+	OpQueryRow Operation = "QueryRow"
+	OpExec     Operation = "Exec"
+	OpInsert   Operation = "Insert"
+	OpTransact Operation = "Transact"
+	OpCommit   Operation = "Commit"
+	OpRollback Operation = "Rollback"
+
+	OpSingleConn Operation = "SingleConn"
+)
+
+// Call describes one intercepted call. The SQL and arguments are as given by the caller,
+// i.e. before any placeholders or named parameters are bound; they are blank for Transact,
+// Commit, Rollback and SingleConn.
+type Call struct {
+	Op      Operation
+	SQL     string
+	Args    []interface{}
+	IsTx    bool // the call is made within a transaction
+	Dialect driver.Dialect
+
+	// Scope is the context that was passed through the interceptors to the enclosing Transact
+	// or SingleConn, if any; otherwise it is nil. It allows, for example, a tracing span to be
+	// parented on the transaction even when the caller's context is some other context.
+	Scope context.Context
+
+	// These are set when the database is called, so they are available after next returns.
+	Started  time.Time
+	Duration time.Duration
+	N        int64 // the rows affected by Exec or the id returned by Insert
+}
+
+// Interceptor wraps each call to Query, QueryRow, Exec, Insert, Transact, Commit, Rollback and
+// SingleConn, providing a single place to add behaviour such as logging, metrics or tracing.
+//
+// An interceptor must call next to proceed with the call, optionally with a modified context,
+// and would normally return the error from next. It can act both before and after next; after
+// next returns, the Call gives the duration and result of the database call.
+//
+// QueryRow is intercepted when Scan is called, so that the error includes any error from
+// scanning, such as sql.ErrNoRows. For Query, the duration is until the rows are returned,
+// not until they have all been read. For Transact and SingleConn, the duration includes all the
+// calls made within them.
+type Interceptor func(ctx context.Context, call *Call, next func(context.Context) error) error
+
+// LoggingInterceptor logs each call, with its duration and any error, at info level.
+func LoggingInterceptor(lgr Logger) Interceptor {
+	return func(ctx context.Context, call *Call, next func(context.Context) error) error {
+		err := next(ctx)
+		data := []interface{}{"op", string(call.Op)}
+		if call.SQL != "" {
+			data = append(data, "sql", call.SQL, "args", call.Args)
+		}
+		if err != nil {
+			data = append(data, "error", err)
+		}
+		lgr.LogT(ctx, tracelog.LogLevelInfo, "Call", &call.Started, data...)
+		return err
+	}
+}
+
+// MetricsInterceptor records the outcome of every statement in the collector. The rows are
+// those affected by Exec, or one for each successful Insert; sql.ErrNoRows is not counted as
+// an error.
+func MetricsInterceptor(c *metrics.Collector) Interceptor {
+	return func(ctx context.Context, call *Call, next func(context.Context) error) error {
+		err := next(ctx)
+		if call.SQL == "" {
+			return err
+		}
+
+		var rows int64
+		switch {
+		case err != nil:
+		case call.Op == OpExec:
+			rows = call.N
+		case call.Op == OpInsert:
+			rows = 1
+		}
+
+		failure := err
+		if errors.Is(err, sql.ErrNoRows) {
+			failure = nil
+		}
+		c.Record(call.SQL, rows, call.Duration, failure)
+		return err
+	}
+}
+
+// intercept runs a call through the interceptors, with fn as the innermost step that calls
+// the database.
+func (sh *shim) intercept(ctx context.Context, call *Call, fn func(context.Context) error) error {
+	ctx = defaultCtx(ctx)
+	if len(sh.interceptors) == 0 {
+		return fn(ctx)
+	}
+
+	call.IsTx = sh.isTx
+	call.Dialect = sh.di
+	call.Scope = sh.scope
+	return runInterceptors(ctx, sh.interceptors, call, fn)
+}
+
+func runInterceptors(ctx context.Context, interceptors []Interceptor, call *Call, fn func(context.Context) error) error {
+	if len(interceptors) == 0 {
+		call.Started = time.Now()
+		err := fn(ctx)
+		call.Duration = time.Since(call.Started)
+		return err
+	}
+
+	return interceptors[0](ctx, call, func(ctx context.Context) error {
+		return runInterceptors(ctx, interceptors[1:], call, fn)
+	})
+}
+
+// interceptedRow defers the query until Scan is called, so that the interceptors see the
+// outcome of scanning.
+type interceptedRow struct {
+	sh    *shim
+	ctx   context.Context
+	query string
+	args  []interface{}
+}
+
+func (r interceptedRow) Scan(dest ...interface{}) error {
+	return r.sh.intercept(r.ctx, &Call{Op: OpQueryRow, SQL: r.query, Args: r.args}, func(ctx context.Context) error {
+		return r.sh.queryRow(ctx, r.query, r.args...).Scan(dest...)
+	})
+}
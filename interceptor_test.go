@@ -0,0 +1,162 @@
+package sqlapi
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"testing"
+
+	"github.com/rickb777/expect"
+	"github.com/rickb777/sqlapi/metrics"
+	"github.com/rickb777/sqlapi/pgxapi/logadapter"
+)
+
+type ctxKey struct{}
+
+// recorder is an interceptor that records each completed call.
+type recorder struct {
+	calls []string
+}
+
+func (r *recorder) intercept(ctx context.Context, call *Call, next func(context.Context) error) error {
+	err := next(context.WithValue(ctx, ctxKey{}, "seen"))
+	s := fmt.Sprintf("%s tx=%v n=%d", call.Op, call.IsTx, call.N)
+	if call.SQL != "" {
+		s += " " + call.SQL
+	}
+	if err != nil {
+		s += " error"
+	}
+	r.calls = append(r.calls, s)
+	return err
+}
+
+func TestInterceptors(t *testing.T) {
+	ctx := context.Background()
+	_, aid2, _, _ := insertFixtures(t, gdb)
+
+	var order []string
+	second := func(ctx context.Context, call *Call, next func(context.Context) error) error {
+		order = append(order, "second")
+		return next(ctx)
+	}
+	third := func(ctx context.Context, call *Call, next func(context.Context) error) error {
+		order = append(order, fmt.Sprintf("third %v", ctx.Value(ctxKey{})))
+		return next(ctx)
+	}
+
+	rec := &recorder{}
+	db := gdb.WithInterceptors(rec.intercept).WithInterceptors(second, third)
+
+	q := db.Dialect().ReplacePlaceholders("select xlines from pfx_addresses where id=?", nil)
+	var xlines string
+	err := db.QueryRow(ctx, q, aid2).Scan(&xlines)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.String(xlines).ToBe(t, "2 Nutmeg Lane")
+	expect.Slice(order).ToBe(t, "second", "third seen")
+
+	err = db.QueryRow(ctx, q, -1).Scan(&xlines)
+	expect.Bool(errors.Is(err, sql.ErrNoRows)).ToBeTrue(t)
+
+	rows, err := db.Query(ctx, "select id from pfx_addresses")
+	expect.Error(err).Not().ToHaveOccurred(t)
+	rows.Close()
+
+	err = db.Transact(ctx, nil, func(tx SqlTx) error {
+		_, e2 := tx.Exec(ctx, "update pfx_addresses set postcode = 'X'")
+		return e2
+	})
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	err = db.Transact(ctx, nil, func(tx SqlTx) error {
+		return errors.New("Bang")
+	})
+	expect.Error(err).ToContain(t, "Bang")
+
+	_, err = db.Exec(ctx, "delete from pfx_no_such_table")
+	expect.Error(err).ToHaveOccurred(t)
+
+	expect.Slice(rec.calls).ToBe(t,
+		"QueryRow tx=false n=0 "+q,
+		"QueryRow tx=false n=0 "+q+" error",
+		"Query tx=false n=0 select id from pfx_addresses",
+		"Exec tx=true n=4 update pfx_addresses set postcode = 'X'",
+		"Commit tx=true n=0",
+		"Transact tx=false n=0",
+		"Rollback tx=true n=0",
+		"Transact tx=false n=0 error",
+		"Exec tx=false n=0 delete from pfx_no_such_table error",
+	)
+
+	// the original is unchanged
+	_, err = gdb.Exec(ctx, "update pfx_addresses set postcode = 'Y'")
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Slice(rec.calls).ToHaveLength(t, 9)
+}
+
+func TestInterceptorRejects(t *testing.T) {
+	ctx := context.Background()
+	insertFixtures(t, gdb)
+
+	reject := func(ctx context.Context, call *Call, next func(context.Context) error) error {
+		if err := next(ctx); err != nil {
+			return err
+		}
+		return fmt.Errorf("rejected %s", call.Op)
+	}
+
+	db := gdb.WithInterceptors(reject)
+	rows, err := db.Query(ctx, "select id from pfx_addresses")
+	expect.Error(err).ToContain(t, "rejected Query")
+	expect.Any(rows).ToBeNil(t)
+
+	_, err = db.Insert(ctx, "id", "INSERT INTO pfx_addresses (xlines, postcode) VALUES ('a', 'b')")
+	expect.Error(err).ToContain(t, "rejected Insert")
+}
+
+func TestLoggingInterceptor(t *testing.T) {
+	ctx := context.Background()
+	insertFixtures(t, gdb)
+
+	buf := &bytes.Buffer{}
+	lgr := NewLogger(logadapter.NewLogger(log.New(buf, "X.", 0)))
+	db := gdb.WithInterceptors(LoggingInterceptor(lgr))
+
+	_, err := db.Exec(ctx, "update pfx_addresses set postcode = 'Z'")
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	expect.String(buf.String()).ToContain(t, "X.Call [args:[] op:Exec sql:update pfx_addresses set postcode = 'Z' took:")
+}
+
+func TestMetricsInterceptor(t *testing.T) {
+	ctx := context.Background()
+	_, aid2, _, _ := insertFixtures(t, gdb)
+
+	c := metrics.New(gdb)
+	db := gdb.WithInterceptors(MetricsInterceptor(c))
+
+	q := db.Dialect().ReplacePlaceholders("select xlines from pfx_addresses where id=?", nil)
+	var xlines string
+	for _, id := range []int64{aid2, -1} {
+		_ = db.QueryRow(ctx, q, id).Scan(&xlines)
+	}
+
+	_, err := db.Exec(ctx, "update pfx_addresses set postcode = 'Z'")
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	_, err = db.Exec(ctx, "delete from pfx_no_such_table")
+	expect.Error(err).ToHaveOccurred(t)
+
+	s := c.Snapshot()
+	expect.Slice(s.Queries).ToHaveLength(t, 3)
+	expect.String(s.Queries[0].Fingerprint).ToBe(t, "delete from pfx_no_such_table")
+	expect.Number(s.Queries[0].Errors).ToBe(t, 1)
+	expect.Number(s.Queries[1].Count).ToBe(t, 2)
+	expect.Number(s.Queries[1].Errors).ToBe(t, 0)
+	expect.String(s.Queries[2].Fingerprint).ToBe(t, "update pfx_addresses set postcode = ?")
+	expect.Number(s.Queries[2].Rows).ToBe(t, 4)
+	expect.Any(s.DB).Not().ToBeNil(t)
+}
@@ -0,0 +1,293 @@
+package introspect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/tracelog"
+	"github.com/rickb777/sqlapi"
+	"github.com/rickb777/sqlapi/constraint"
+	"github.com/rickb777/sqlapi/driver"
+	"github.com/rickb777/sqlapi/schema"
+)
+
+// DriftKind classifies the differences between the expected tables and the live database.
+type DriftKind int
+
+const (
+	MissingTable DriftKind = iota + 1
+	MissingColumn
+	ExtraColumn
+	TypeMismatch
+	NullabilityMismatch
+	MissingIndex
+	MissingForeignKey
+)
+
+var driftKindNames = []string{"", "missing table", "missing column", "extra column", "type mismatch",
+	"nullability mismatch", "missing index", "missing foreign key"}
+
+func (k DriftKind) String() string {
+	if k > 0 && int(k) < len(driftKindNames) {
+		return driftKindNames[k]
+	}
+	return fmt.Sprintf("DriftKind(%d)", int(k))
+}
+
+// Difference is one way in which a live table differs from the table that is expected.
+type Difference struct {
+	Kind     DriftKind
+	Table    string // the table name, including any prefix
+	Item     string // the column, index or foreign key concerned; blank for a missing table
+	Expected string // the expected column type, nullability etc, if relevant
+	Actual   string // the actual column type, nullability etc, if relevant
+}
+
+func (d Difference) String() string {
+	s := fmt.Sprintf("%s: %s", d.Table, d.Kind)
+	if d.Item != "" {
+		s += " " + d.Item
+	}
+
+	var detail []string
+	if d.Expected != "" {
+		detail = append(detail, "expected "+d.Expected)
+	}
+	if d.Actual != "" {
+		detail = append(detail, "actual "+d.Actual)
+	}
+	if len(detail) > 0 {
+		s += " (" + strings.Join(detail, ", ") + ")"
+	}
+	return s
+}
+
+// Drift lists the differences found by Check. It is empty if the database matches the
+// expected tables.
+//
+// Drift is also an error, so that a service can fail fast at startup, e.g.
+//
+//	drift, err := introspect.Check(ctx, db, prefix, expected...)
+//	if err == nil {
+//		err = drift.Err()
+//	}
+type Drift []Difference
+
+// Err returns the drift as an error, or nil if there are no differences.
+func (d Drift) Err() error {
+	if len(d) == 0 {
+		return nil
+	}
+	return d
+}
+
+func (d Drift) Error() string {
+	lines := make([]string, len(d))
+	for i, diff := range d {
+		lines[i] = diff.String()
+	}
+	return "schema drift: " + strings.Join(lines, "; ")
+}
+
+// Log writes each difference to the logger as a separate warning, with the fields of the
+// difference as the log data.
+func (d Drift) Log(ctx context.Context, lgr sqlapi.Logger) {
+	for _, diff := range d {
+		lgr.LogT(ctx, tracelog.LogLevelWarn, "schema drift", nil,
+			"table", diff.Table,
+			"kind", diff.Kind.String(),
+			"item", diff.Item,
+			"expected", diff.Expected,
+			"actual", diff.Actual)
+	}
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// Expected is a table as the application expects it to be. The table description is typically
+// generated by sqlgen; its name does not include any prefix. The foreign keys declared in the
+// tags of the fields are expected as well as those listed in Constraints.
+type Expected struct {
+	Table       *schema.TableDescription
+	Constraints constraint.Constraints
+}
+
+// Check compares the expected tables with the live database. The prefix is applied to the
+// name of every table. All the expected tables are checked and the differences are returned
+// together. An error is only returned if the catalog could not be read.
+//
+// There is no pgxapi version of Check: see the package documentation.
+func Check(ctx context.Context, ex sqlapi.Execer, prefix string, expected ...Expected) (Drift, error) {
+	var drift Drift
+	for _, exp := range expected {
+		d, err := CheckTable(ctx, ex, sqlapi.TableName{Prefix: prefix, Name: exp.Table.Name}, exp)
+		if err != nil {
+			return nil, err
+		}
+		drift = append(drift, d...)
+	}
+	return drift, nil
+}
+
+// CheckTable compares one expected table with the live table of the given name. This reports
+// missing and extra columns, mismatched column types and nullability, and missing indexes and
+// foreign keys. The expected column types are those given by driver.ColumnOf.
+// Extra indexes and foreign keys are not reported.
+func CheckTable(ctx context.Context, ex sqlapi.Execer, name sqlapi.TableName, expected Expected) (Drift, error) {
+	actual, cc, err := ReadTable(ctx, ex, name)
+	if err != nil {
+		var notFound *NotFoundError
+		if errors.As(err, &notFound) {
+			return Drift{{Kind: MissingTable, Table: name.String()}}, nil
+		}
+		return nil, err
+	}
+
+	c := &comparison{di: ex.Dialect(), table: name.String()}
+	c.columns(expected.Table, actual)
+	c.indexes(expected.Table.Index, actual.Index)
+	c.foreignKeys(foreignKeysOf(expected.Constraints, constraint.ConstraintsOf(expected.Table)), foreignKeysOf(cc))
+	return c.drift, nil
+}
+
+//-------------------------------------------------------------------------------------------------
+
+type comparison struct {
+	di    driver.Dialect
+	table string
+	drift Drift
+}
+
+func (c *comparison) add(kind DriftKind, item, expected, actual string) {
+	c.drift = append(c.drift, Difference{Kind: kind, Table: c.table, Item: item, Expected: expected, Actual: actual})
+}
+
+func (c *comparison) columns(expected, actual *schema.TableDescription) {
+	isPk := make(map[string]bool)
+	for _, f := range expected.PrimaryKeyFields() {
+		isPk[f.SqlName] = true
+	}
+
+	live := make(map[string]*schema.Field)
+	for _, f := range actual.Fields {
+		live[f.SqlName] = f
+	}
+
+	wanted := make(map[string]bool)
+	for _, f := range expected.Fields {
+		if f.Skip() {
+			continue
+		}
+		wanted[f.SqlName] = true
+
+		col := driver.ColumnOf(c.di, c.table, expected, f)
+		wantType := col.Type
+
+		lf, exists := live[f.SqlName]
+		if !exists {
+			c.add(MissingColumn, f.SqlName, wantType, "")
+			continue
+		}
+
+		if !driver.SameType(c.di, wantType, lf.Tags.Type) {
+			c.add(TypeMismatch, f.SqlName, wantType, lf.Tags.Type)
+		}
+
+		wantNull := col.Nullable && !isPk[f.SqlName]
+		if wantNull != lf.Type.IsPtr {
+			c.add(NullabilityMismatch, f.SqlName, nullability(wantNull), nullability(lf.Type.IsPtr))
+		}
+	}
+
+	for _, lf := range actual.Fields {
+		if !wanted[lf.SqlName] {
+			c.add(ExtraColumn, lf.SqlName, "", lf.Tags.Type)
+		}
+	}
+}
+
+func (c *comparison) indexes(expected, actual []*schema.Index) {
+	for _, ix := range expected {
+		found := false
+		for _, lx := range actual {
+			if lx.Unique == ix.Unique && lx.Columns() == ix.Columns() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			c.add(MissingIndex, fmt.Sprintf("%s%s (%s)", ix.UniqueStr(), ix.Name, ix.Columns()), "", "")
+		}
+	}
+}
+
+func (c *comparison) foreignKeys(expected, actual []foreignKey) {
+	for i, fk := range expected {
+		if indexOfForeignKey(expected[:i], fk) >= 0 {
+			continue // declared both in the tags and in the constraints
+		}
+		if indexOfForeignKey(actual, fk) < 0 {
+			c.add(MissingForeignKey, fk.String(), "", "")
+		}
+	}
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// foreignKey is the common form of simple and composite foreign key constraints.
+type foreignKey struct {
+	columns       []string
+	parent        string
+	parentColumns []string // empty if the parent's primary key is implied
+}
+
+func foreignKeysOf(ccs ...constraint.Constraints) []foreignKey {
+	var list []foreignKey
+	for _, cc := range ccs {
+		for _, fkc := range cc.FkConstraints() {
+			fk := foreignKey{columns: []string{fkc.ForeignKeyColumn}, parent: fkc.Parent.TableName}
+			if fkc.Parent.Column != "" {
+				fk.parentColumns = []string{fkc.Parent.Column}
+			}
+			list = append(list, fk)
+		}
+		for _, fkc := range cc.CompositeFkConstraints() {
+			list = append(list, foreignKey{columns: fkc.ForeignKeyColumns, parent: fkc.Parent.TableName, parentColumns: fkc.Parent.Columns})
+		}
+	}
+	return list
+}
+
+func indexOfForeignKey(list []foreignKey, fk foreignKey) int {
+	for i, other := range list {
+		if fk.matches(other) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (fk foreignKey) matches(other foreignKey) bool {
+	if fk.parent != other.parent || strings.Join(fk.columns, ",") != strings.Join(other.columns, ",") {
+		return false
+	}
+	return len(fk.parentColumns) == 0 || len(other.parentColumns) == 0 ||
+		strings.Join(fk.parentColumns, ",") == strings.Join(other.parentColumns, ",")
+}
+
+func (fk foreignKey) String() string {
+	s := fmt.Sprintf("(%s) references %s", strings.Join(fk.columns, ", "), fk.parent)
+	if len(fk.parentColumns) > 0 {
+		s += fmt.Sprintf(" (%s)", strings.Join(fk.parentColumns, ", "))
+	}
+	return s
+}
+
+func nullability(nullable bool) string {
+	if nullable {
+		return "null"
+	}
+	return "not null"
+}
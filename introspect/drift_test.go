@@ -0,0 +1,85 @@
+package introspect_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rickb777/expect"
+	"github.com/rickb777/sqlapi/constraint"
+	"github.com/rickb777/sqlapi/introspect"
+	"github.com/rickb777/sqlapi/schema"
+	"github.com/rickb777/sqlapi/types"
+)
+
+func driftFixture(t *testing.T) *schema.TableDescription {
+	dropTables(t, "intro_orders", "intro_members", "intro_people")
+
+	id := field("Id", "id", i64, types.Tag{Primary: true})
+	people := &schema.TableDescription{Name: "people", Fields: schema.FieldList{id}, Primary: id}
+	createTable(t, "intro_", people)
+
+	orders := &schema.TableDescription{Name: "orders", Fields: schema.FieldList{
+		field("Id", "id", i64, types.Tag{Primary: true}),
+		field("PersonId", "person_id", i64, types.Tag{ForeignKey: "people.id"}),
+		field("Code", "code", str, types.Tag{Size: 20}),
+		field("Note", "note", spt, types.Tag{}),
+	}}
+	orders.Primary = orders.Fields[0]
+	orders.Index = []*schema.Index{{Name: "intro_orders_code", Unique: true, Fields: orders.Fields[2:3]}}
+	createTable(t, "intro_", orders, constraint.ConstraintsOf(orders)...)
+
+	_, err := gdb.Exec(context.Background(), "CREATE UNIQUE INDEX intro_orders_code ON intro_orders (code)")
+	expect.Error(err).Not().ToHaveOccurred(t)
+	return orders
+}
+
+func TestCheck_noDrift(t *testing.T) {
+	ctx := context.Background()
+	orders := driftFixture(t)
+
+	drift, err := introspect.Check(ctx, gdb, "intro_", introspect.Expected{Table: orders})
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Slice(drift).ToBeEmpty(t)
+	expect.Error(drift.Err()).Not().ToHaveOccurred(t)
+}
+
+func TestCheck_drift(t *testing.T) {
+	ctx := context.Background()
+	orders := driftFixture(t)
+
+	f64 := schema.Type{Name: "float64", Base: types.Float64}
+	expected := &schema.TableDescription{Name: "orders", Fields: schema.FieldList{
+		orders.Fields[0],
+		field("Code", "code", f64, types.Tag{}),   // type differs
+		field("Note", "note", str, types.Tag{}),   // nullability differs
+		field("Email", "email", str, types.Tag{}), // missing
+	}, Primary: orders.Fields[0]}
+	expected.Index = []*schema.Index{{Name: "intro_orders_note", Fields: expected.Fields[2:3]}}
+
+	drift, err := introspect.Check(ctx, gdb, "intro_",
+		introspect.Expected{Table: expected, Constraints: constraint.Constraints{
+			constraint.FkConstraintOn("code").RefersTo("people", "id"),
+		}},
+		introspect.Expected{Table: &schema.TableDescription{Name: "nonexistent"}})
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	kinds := make([]introspect.DriftKind, len(drift))
+	for i, d := range drift {
+		kinds[i] = d.Kind
+	}
+	expect.Slice(kinds).Info(drift).ToBe(t,
+		introspect.TypeMismatch,
+		introspect.NullabilityMismatch,
+		introspect.MissingColumn,
+		introspect.ExtraColumn,
+		introspect.MissingIndex,
+		introspect.MissingForeignKey,
+		introspect.MissingTable)
+
+	expect.String(drift[1].String()).ToBe(t, "intro_orders: nullability mismatch note (expected not null, actual null)")
+	expect.String(drift[3].Item).ToBe(t, "person_id")
+	expect.String(drift[4].Item).ToBe(t, "intro_orders_note (note)")
+	expect.String(drift[5].Item).ToBe(t, "(code) references people (id)")
+	expect.String(drift[6].String()).ToBe(t, "intro_nonexistent: missing table")
+	expect.Error(drift.Err()).ToHaveOccurred(t)
+}
@@ -0,0 +1,119 @@
+package introspect
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/rickb777/sqlapi"
+)
+
+// https://duckdb.org/docs/sql/meta/duckdb_table_functions
+
+const duckDBColumns = `SELECT column_name, lower(data_type), is_nullable = 'YES', column_default
+FROM information_schema.columns
+WHERE table_schema = COALESCE(NULLIF(?, ''), current_schema()) AND table_name = ?
+ORDER BY ordinal_position`
+
+const duckDBConstraints = `SELECT constraint_type, array_to_string(constraint_column_names, ','),
+	COALESCE(referenced_table, ''), COALESCE(array_to_string(referenced_column_names, ','), '')
+FROM duckdb_constraints()
+WHERE schema_name = COALESCE(NULLIF(?, ''), current_schema()) AND table_name = ?
+	AND constraint_type IN ('PRIMARY KEY', 'UNIQUE', 'FOREIGN KEY')
+ORDER BY constraint_index`
+
+const duckDBIndexes = `SELECT index_name, is_unique, expressions
+FROM duckdb_indexes()
+WHERE schema_name = COALESCE(NULLIF(?, ''), current_schema()) AND table_name = ?
+ORDER BY index_name`
+
+func readDuckDB(ctx context.Context, ex sqlapi.Execer, name sqlapi.TableName) (*tableInfo, error) {
+	di := ex.Dialect()
+	info := &tableInfo{}
+	schemaName, table := splitName(name)
+
+	//---------- columns ----------
+
+	rows, err := ex.Query(ctx, di.ReplacePlaceholders(duckDBColumns, nil), schemaName, table)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var c columnInfo
+		var dflt sql.NullString
+		if err = rows.Scan(&c.name, &c.sqlType, &c.nullable, &dflt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		if dflt.Valid {
+			c.dflt = &dflt.String
+		}
+		c.auto = strings.HasPrefix(dflt.String, "nextval(")
+		info.columns = append(info.columns, c)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	//---------- primary key, unique constraints and foreign keys ----------
+
+	rows, err = ex.Query(ctx, di.ReplacePlaceholders(duckDBConstraints, nil), schemaName, table)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var kind, columns, parent, parentColumns string
+		if err = rows.Scan(&kind, &columns, &parent, &parentColumns); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		cols := strings.Split(columns, ",")
+		switch kind {
+		case "PRIMARY KEY":
+			info.primaryKey = cols
+		case "UNIQUE":
+			// unique constraints are unnamed, so a name is made up
+			info.indexes = append(info.indexes, indexInfo{name: table + "_" + strings.Join(cols, "_") + "_key", unique: true, columns: cols})
+		case "FOREIGN KEY":
+			// DuckDB does not support ON UPDATE or ON DELETE actions
+			info.foreignKeys = append(info.foreignKeys, foreignKeyInfo{
+				columns:       cols,
+				parentTable:   parent,
+				parentColumns: strings.Split(parentColumns, ","),
+			})
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	//---------- indexes ----------
+
+	rows, err = ex.Query(ctx, di.ReplacePlaceholders(duckDBIndexes, nil), schemaName, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ix indexInfo
+		var expressions string
+		if err = rows.Scan(&ix.name, &ix.unique, &expressions); err != nil {
+			return nil, err
+		}
+
+		// the expressions are listed like "[a, b]"
+		for _, col := range strings.Split(strings.Trim(expressions, "[]"), ",") {
+			ix.columns = append(ix.columns, strings.Trim(strings.TrimSpace(col), `"`))
+		}
+		info.indexes = append(info.indexes, ix)
+	}
+
+	return info, rows.Err()
+}
@@ -0,0 +1,259 @@
+package introspect
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rickb777/sqlapi/constraint"
+	"github.com/rickb777/sqlapi/schema"
+	"github.com/rickb777/sqlapi/types"
+)
+
+// WriteGo writes Go source code declaring a struct for each table, e.g. as read by
+// ReadTables. This is the reverse of the usual route from structs to tables, and allows
+// structs to be written for an existing database rather than by hand. The constraints
+// are optional; if present, they correspond to the tables.
+//
+// Each struct is named after its table, without the prefix and made singular, e.g. table
+// "app_user_roles" with prefix "app_" gives struct UserRole. Each column becomes a field
+// with a `sql:"..."` tag that types.ParseTag accepts. The tags give the primary key
+// (pk, auto), indexes (index, unique), foreign keys (fk, onupdate, ondelete), string
+// sizes (size) and defaults. A column in a single-column unique index that is not
+// nullable is tagged as a natural key (nk) instead, so its index name is not kept.
+//
+// The Go types follow the SQL types; nullable columns become pointers, except for []byte.
+// JSON columns become json.RawMessage, tagged with encode: json. Where the Go type alone
+// would not reproduce the SQL type, e.g. numeric(12,2) or uuid, the tag also gives the
+// type explicitly. Composite foreign keys cannot be expressed as tags, so they are written
+// as comments.
+func WriteGo(w io.Writer, pkg, prefix string, tables []*schema.TableDescription, constraints []constraint.Constraints) error {
+	imports := make(map[string]bool)
+	body := &bytes.Buffer{}
+
+	for i, table := range tables {
+		var cc constraint.Constraints
+		if i < len(constraints) {
+			cc = constraints[i]
+		}
+		writeStruct(body, prefix, table, cc, imports)
+	}
+
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "// Code generated from the database by github.com/rickb777/sqlapi/introspect.\n\npackage %s\n", pkg)
+	if len(imports) > 0 {
+		paths := make([]string, 0, len(imports))
+		for p := range imports {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+
+		b.WriteString("\nimport (\n")
+		for _, p := range paths {
+			fmt.Fprintf(b, "\t%q\n", p)
+		}
+		b.WriteString(")\n")
+	}
+	b.Write(body.Bytes())
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(src)
+	return err
+}
+
+func writeStruct(w io.Writer, prefix string, table *schema.TableDescription, cc constraint.Constraints, imports map[string]bool) {
+	name := structName(prefix, table.Name)
+	fmt.Fprintf(w, "\n// %s is a row of the %s table.\ntype %s struct {\n", name, table.Name, name)
+
+	for _, f := range table.Fields {
+		fmt.Fprintf(w, "\t%s %s", identifier(f.Name), fieldType(f, imports))
+		if tag := fieldTag(table, f); tag != "" {
+			fmt.Fprintf(w, " %s", tag)
+		}
+		fmt.Fprintln(w)
+	}
+
+	for _, c := range cc {
+		if fk, ok := c.(constraint.CompositeFkConstraint); ok {
+			fmt.Fprintf(w, "\t// foreign key (%s) references %s (%s)\n",
+				strings.Join(fk.ForeignKeyColumns, ", "), fk.Parent.TableName, strings.Join(fk.Parent.Columns, ", "))
+		}
+	}
+
+	fmt.Fprintln(w, "}")
+}
+
+// structName converts a table name such as "app_user_roles" to a struct name such as "UserRole".
+func structName(prefix, table string) string {
+	name := strings.ReplaceAll(strings.TrimPrefix(table, prefix), ".", "_")
+	return identifier(goName(singular(name)))
+}
+
+// singular makes a plural English table name singular, for the common cases.
+func singular(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, "ies"):
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(lower, "sses"), strings.HasSuffix(lower, "xes"),
+		strings.HasSuffix(lower, "ches"), strings.HasSuffix(lower, "shes"):
+		return name[:len(name)-2]
+	case strings.HasSuffix(lower, "ss"), strings.HasSuffix(lower, "us"):
+		return name
+	case strings.HasSuffix(lower, "s"):
+		return name[:len(name)-1]
+	}
+	return name
+}
+
+// identifier ensures that a name is a valid exported Go identifier.
+func identifier(name string) string {
+	if name == "" || name[0] < 'A' || name[0] > 'Z' {
+		return "X" + name
+	}
+	return name
+}
+
+func fieldType(f *schema.Field, imports map[string]bool) string {
+	if isJSON(f.Tags.Type) {
+		imports["encoding/json"] = true
+		return "json.RawMessage"
+	}
+
+	t := f.Type
+	name := t.Name
+	if t.PkgName != "" {
+		imports[t.PkgPath] = true
+		name = t.PkgName + "." + t.Name
+	}
+
+	if t.IsPtr && t.Base != types.Slice {
+		return "*" + name
+	}
+	return name
+}
+
+// fieldTag writes the struct tag for a field, or a blank string if there is nothing to tag.
+func fieldTag(table *schema.TableDescription, f *schema.Field) string {
+	tags := f.Tags
+	var parts []string
+	add := func(key, value string) {
+		if value != "" {
+			parts = append(parts, key+": "+yamlValue(value))
+		}
+	}
+	flag := func(key string, on bool) {
+		if on {
+			parts = append(parts, key+": true")
+		}
+	}
+
+	if !strings.EqualFold(f.Name, f.SqlName) {
+		add("name", f.SqlName)
+	}
+	if explicitType(tags.Type) {
+		add("type", tags.Type)
+	}
+
+	nk := naturalKey(table, f)
+	flag("pk", tags.Primary)
+	flag("auto", tags.Auto && tags.Primary)
+	flag("nk", nk)
+	add("index", tags.Index)
+	if !nk {
+		add("unique", tags.Unique)
+	}
+	add("fk", tags.ForeignKey)
+	add("onupdate", tags.OnUpdate)
+	add("ondelete", tags.OnDelete)
+	if tags.Size > 0 {
+		parts = append(parts, "size: "+strconv.Itoa(tags.Size))
+	}
+	add("default", tags.Default)
+	add("defaultsql", tags.DefaultSQL)
+	if isJSON(tags.Type) {
+		add("encode", "json")
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+
+	tag := types.TagKey + ":" + strconv.Quote(strings.Join(parts, ", "))
+	if strings.ContainsRune(tag, '`') {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
+
+// naturalKey is true for a column that is not nullable and is the only column of a unique
+// index, provided it is not tagged in ways that conflict with being a natural key.
+func naturalKey(table *schema.TableDescription, f *schema.Field) bool {
+	tags := f.Tags
+	if tags.Unique == "" || tags.Primary || tags.Index != "" || tags.ForeignKey != "" || f.Type.IsPtr {
+		return false
+	}
+
+	for _, ix := range table.Index {
+		if ix.Name == tags.Unique {
+			return ix.Unique && ix.Single()
+		}
+	}
+	return false
+}
+
+var plainYaml = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// yamlValue quotes a value if it might otherwise be misread as YAML, e.g. "on" or "a, b".
+func yamlValue(s string) string {
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null":
+	default:
+		if plainYaml.MatchString(s) {
+			return s
+		}
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func isJSON(sqlType string) bool {
+	t := strings.ToLower(sqlType)
+	return t == "json" || t == "jsonb"
+}
+
+// portableTypes are the SQL types that are reproduced from the Go type alone, so they are
+// not given explicitly in the tags.
+var portableTypes = map[string]bool{
+	"bool": true, "boolean": true, "bit": true,
+	"tinyint": true, "smallint": true, "mediumint": true, "int": true, "integer": true, "bigint": true,
+	"int1": true, "int2": true, "int4": true, "int8": true,
+	"utinyint": true, "usmallint": true, "uinteger": true, "ubigint": true,
+	"serial": true, "smallserial": true, "bigserial": true,
+	"real": true, "float": true, "float4": true, "float8": true, "double": true, "double precision": true,
+	"text": true, "varchar": true, "nvarchar": true, "character varying": true,
+	"timestamp": true, "timestamptz": true, "datetime": true, "datetime2": true,
+	"timestamp with time zone": true, "timestamp without time zone": true,
+	"blob": true, "bytea": true, "varbinary": true,
+}
+
+// explicitType is true for SQL types that the Go type alone would not reproduce.
+func explicitType(sqlType string) bool {
+	t := strings.ToLower(strings.TrimSpace(sqlType))
+	if t == "" {
+		return false
+	}
+	if strings.Contains(t, ",") { // precision and scale
+		return true
+	}
+	base := strings.Join(strings.Fields(strings.Replace(baseTypeName(t), "unsigned", "", 1)), " ")
+	return !portableTypes[base]
+}
@@ -0,0 +1,90 @@
+package introspect
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rickb777/expect"
+	"github.com/rickb777/sqlapi/constraint"
+	"github.com/rickb777/sqlapi/schema"
+	"github.com/rickb777/sqlapi/types"
+)
+
+func TestWriteGo(t *testing.T) {
+	fields := func(ff ...*schema.Field) schema.FieldList { return ff }
+	field := func(sqlName, sqlType string, nullable bool, tag types.Tag) *schema.Field {
+		tag.Type = sqlType
+		typ := goType(sqlType)
+		typ.IsPtr = nullable
+		return &schema.Field{Node: schema.Node{Name: goName(sqlName), Type: typ}, SqlName: sqlName, Tags: &tag}
+	}
+
+	categories := &schema.TableDescription{Name: "app_categories", Fields: fields(
+		field("id", "integer", false, types.Tag{Primary: true, Auto: true}),
+		field("code", "varchar(20)", false, types.Tag{Unique: "app_categories_code", Size: 20}),
+	)}
+	categories.Index = []*schema.Index{{Name: "app_categories_code", Unique: true, Fields: categories.Fields[1:]}}
+
+	items := &schema.TableDescription{Name: "app_items", Fields: fields(
+		field("id", "bigint", false, types.Tag{Primary: true}),
+		field("category_id", "integer", true, types.Tag{ForeignKey: "categories.id", OnDelete: "cascade", Index: "app_items_cat"}),
+		field("price", "numeric(12,2)", false, types.Tag{Default: "0"}),
+		field("on", "boolean", false, types.Tag{DefaultSQL: "TRUE"}),
+		field("attrs", "jsonb", true, types.Tag{}),
+		field("picture", "bytea", true, types.Tag{}),
+		field("added", "timestamp with time zone", false, types.Tag{DefaultSQL: "now()"}),
+		field("org", "int", false, types.Tag{}),
+		field("code", "varchar(10)", false, types.Tag{Size: 10}),
+	)}
+	itemsCC := constraint.Constraints{constraint.CompositeFkConstraint{
+		ForeignKeyColumns: []string{"org", "code"},
+		Parent:            constraint.CompositeReference{TableName: "groups", Columns: []string{"org", "code"}},
+	}}
+
+	b := &strings.Builder{}
+	err := WriteGo(b, "model", "app_", []*schema.TableDescription{categories, items}, []constraint.Constraints{nil, itemsCC})
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.String(b.String()).ToBe(t, "// Code generated from the database by github.com/rickb777/sqlapi/introspect.\n"+`
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Category is a row of the app_categories table.
+type Category struct {
+	Id   int64  `+"`"+`sql:"pk: true, auto: true"`+"`"+`
+	Code string `+"`"+`sql:"nk: true, size: 20"`+"`"+`
+}
+
+// Item is a row of the app_items table.
+type Item struct {
+	Id         int64           `+"`"+`sql:"pk: true"`+"`"+`
+	CategoryId *int64          `+"`"+`sql:"name: category_id, index: app_items_cat, fk: categories.id, ondelete: cascade"`+"`"+`
+	Price      float64         `+"`"+`sql:"type: 'numeric(12,2)', default: '0'"`+"`"+`
+	On         bool            `+"`"+`sql:"defaultsql: 'TRUE'"`+"`"+`
+	Attrs      json.RawMessage `+"`"+`sql:"type: jsonb, encode: json"`+"`"+`
+	Picture    []byte
+	Added      time.Time `+"`"+`sql:"defaultsql: 'now()'"`+"`"+`
+	Org        int32
+	Code       string `+"`"+`sql:"size: 10"`+"`"+`
+	// foreign key (org, code) references groups (org, code)
+}
+`)
+}
+
+func TestSingular(t *testing.T) {
+	cases := map[string]string{
+		"users":      "user",
+		"categories": "category",
+		"addresses":  "address",
+		"boxes":      "box",
+		"status":     "status",
+		"person":     "person",
+	}
+
+	for plural, expected := range cases {
+		expect.String(singular(plural)).Info(plural).ToBe(t, expected)
+	}
+}
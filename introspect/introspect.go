@@ -0,0 +1,298 @@
+// Package introspect reads the structure of existing tables from the database catalog. The
+// result is expressed as a schema.TableDescription plus the table's constraints, i.e. the same
+// form used to describe the tables that an application expects. This allows, for example,
+// live tables to be compared with the expected tables.
+//
+// The catalog is read using PRAGMA statements for SQLite, the information_schema for MySQL,
+// SQL Server and DuckDB, and pg_catalog for PostgreSQL.
+//
+// Check compares the tables that an application expects with the live database and reports any
+// drift between them, so that mismatches can be found at startup rather than via scan errors.
+//
+// WriteGo writes Go structs, with sql tags, that correspond to the tables, which allows an
+// existing database to be adopted without writing the structs by hand.
+//
+// This package is separate from sqlapi itself because it depends on the constraint package.
+// It reads the catalog via sqlapi.Execer, i.e. database/sql, and has no pgxapi counterpart.
+// An application that uses pgxapi can run ReadTable or Check using a connection opened with
+// the pgx stdlib driver, e.g. sqlapi.ConnectEnv with DB_DRIVER=pgx; the catalog queries need
+// only one connection, which can be closed afterwards.
+package introspect
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/rickb777/sqlapi"
+	"github.com/rickb777/sqlapi/constraint"
+	"github.com/rickb777/sqlapi/driver"
+	"github.com/rickb777/sqlapi/schema"
+	"github.com/rickb777/sqlapi/types"
+	"github.com/rickb777/where/dialect"
+)
+
+// ReadTable reads the columns, primary key, indexes and foreign keys of a table. The columns
+// are described by the fields of the table description, in order; each column's SQL type is
+// given by its explicit type tag and its Go type is inferred from that. Foreign keys are
+// returned as FkConstraint values for single columns and CompositeFkConstraint values
+// otherwise.
+//
+// If the table name has a prefix ending in a dot, the prefix is used as the schema name.
+// Otherwise the prefix is simply part of the table name, and the current schema is used.
+//
+// A *NotFoundError is returned if the table does not exist.
+func ReadTable(ctx context.Context, ex sqlapi.Execer, name sqlapi.TableName) (*schema.TableDescription, constraint.Constraints, error) {
+	info, err := readerFor(ex.Dialect())(ctx, ex, name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w reading table %s", err, name)
+	}
+
+	if len(info.columns) == 0 {
+		return nil, nil, &NotFoundError{Name: name}
+	}
+
+	table, cc := info.describe(name)
+	return table, cc, nil
+}
+
+// ReadTables reads all the tables in the database that are listed by sqlapi.ListTables.
+// If the prefix is not blank, only tables whose names start with it are included.
+func ReadTables(ctx context.Context, ex sqlapi.Execer, prefix string) ([]*schema.TableDescription, []constraint.Constraints, error) {
+	names, err := sqlapi.ListTables(ex, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var tables []*schema.TableDescription
+	var constraints []constraint.Constraints
+
+	for _, n := range names {
+		if strings.HasPrefix(n, prefix) {
+			table, cc, err := ReadTable(ctx, ex, sqlapi.TableName{Prefix: prefix, Name: n[len(prefix):]})
+			if err != nil {
+				return nil, nil, err
+			}
+			tables = append(tables, table)
+			constraints = append(constraints, cc)
+		}
+	}
+
+	return tables, constraints, nil
+}
+
+// NotFoundError is returned when a table does not exist.
+type NotFoundError struct {
+	Name sqlapi.TableName
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("table %s not found", e.Name)
+}
+
+//-------------------------------------------------------------------------------------------------
+
+type reader func(ctx context.Context, ex sqlapi.Execer, name sqlapi.TableName) (*tableInfo, error)
+
+func readerFor(di driver.Dialect) reader {
+	if driver.IsDuckDB(di) {
+		return readDuckDB
+	}
+
+	switch di.Index() {
+	case dialect.Sqlite:
+		return readSqlite
+	case dialect.Mysql:
+		return readMysql
+	case dialect.SqlServer:
+		return readSqlServer
+	}
+	return readPostgres
+}
+
+// tableInfo is what is read from the catalog, before it is converted to a TableDescription.
+type tableInfo struct {
+	columns     []columnInfo
+	primaryKey  []string
+	indexes     []indexInfo
+	foreignKeys []foreignKeyInfo
+}
+
+type columnInfo struct {
+	name     string
+	sqlType  string
+	nullable bool
+	dflt     *string // nil if there is no default
+	literal  bool    // the default is a plain literal that is not quoted
+	auto     bool
+}
+
+type indexInfo struct {
+	name    string
+	unique  bool
+	columns []string
+}
+
+type foreignKeyInfo struct {
+	name               string
+	columns            []string
+	parentTable        string
+	parentColumns      []string
+	onUpdate, onDelete constraint.Consequence
+}
+
+// fkByName finds the foreign key with a given name, adding a new one if necessary. This is
+// used for catalogs that list multi-column keys as one row per column.
+func (info *tableInfo) fkByName(name string) *foreignKeyInfo {
+	for i := range info.foreignKeys {
+		if info.foreignKeys[i].name == name {
+			return &info.foreignKeys[i]
+		}
+	}
+	info.foreignKeys = append(info.foreignKeys, foreignKeyInfo{name: name})
+	return &info.foreignKeys[len(info.foreignKeys)-1]
+}
+
+// indexByName finds the index with a given name, adding a new one if necessary.
+func (info *tableInfo) indexByName(name string, unique bool) *indexInfo {
+	for i := range info.indexes {
+		if info.indexes[i].name == name {
+			return &info.indexes[i]
+		}
+	}
+	info.indexes = append(info.indexes, indexInfo{name: name, unique: unique})
+	return &info.indexes[len(info.indexes)-1]
+}
+
+func (info *tableInfo) describe(name sqlapi.TableName) (*schema.TableDescription, constraint.Constraints) {
+	table := &schema.TableDescription{Name: name.String()}
+	bySqlName := make(map[string]*schema.Field)
+
+	for _, c := range info.columns {
+		tag := &types.Tag{Type: c.sqlType, Auto: c.auto}
+		if c.dflt != nil && !c.auto {
+			if c.literal {
+				tag.Default = *c.dflt
+			} else {
+				tag.Default, tag.DefaultSQL = parseDefault(*c.dflt)
+			}
+		}
+
+		typ := goType(c.sqlType)
+		typ.IsPtr = c.nullable
+		if n := typeSize(c.sqlType); n > 0 && typ.Base == types.String {
+			tag.Size = n
+		}
+
+		field := &schema.Field{
+			Node:    schema.Node{Name: goName(c.name), Type: typ},
+			SqlName: c.name,
+			Tags:    tag,
+		}
+		table.Fields = append(table.Fields, field)
+		bySqlName[c.name] = field
+	}
+
+	var pk schema.FieldList
+	for _, col := range info.primaryKey {
+		if f, exists := bySqlName[col]; exists {
+			f.Tags.Primary = true
+			pk = append(pk, f)
+		}
+	}
+	if len(pk) > 0 {
+		table.SetPrimaryKey(pk...)
+	}
+
+	for _, ix := range info.indexes {
+		index := &schema.Index{Name: ix.name, Unique: ix.unique}
+		for _, col := range ix.columns {
+			if f, exists := bySqlName[col]; exists {
+				index.Fields = append(index.Fields, f)
+				if ix.unique && f.Tags.Unique == "" {
+					f.Tags.Unique = ix.name
+				} else if !ix.unique && f.Tags.Index == "" {
+					f.Tags.Index = ix.name
+				}
+			}
+		}
+		table.Index = append(table.Index, index)
+	}
+
+	var cc constraint.Constraints
+	for _, fk := range info.foreignKeys {
+		parent := unprefixed(name, fk.parentTable)
+		if len(fk.columns) == 1 {
+			parentColumn := ""
+			if len(fk.parentColumns) == 1 {
+				parentColumn = fk.parentColumns[0]
+			}
+			cc = append(cc, constraint.FkConstraint{
+				ForeignKeyColumn: fk.columns[0],
+				Parent:           constraint.Reference{TableName: parent, Column: parentColumn},
+				Update:           fk.onUpdate,
+				Delete:           fk.onDelete,
+			})
+
+			// a primary key cannot also be tagged as a foreign key
+			if f, exists := bySqlName[fk.columns[0]]; exists && !f.Tags.Primary {
+				f.Tags.ForeignKey = strings.TrimSuffix(parent+"."+parentColumn, ".")
+				f.Tags.OnUpdate = string(fk.onUpdate)
+				f.Tags.OnDelete = string(fk.onDelete)
+			}
+		} else {
+			cc = append(cc, constraint.CompositeFkConstraint{
+				ForeignKeyColumns: fk.columns,
+				Parent:            constraint.CompositeReference{TableName: parent, Columns: fk.parentColumns},
+				Update:            fk.onUpdate,
+				Delete:            fk.onDelete,
+			})
+		}
+	}
+
+	return table, cc
+}
+
+// unprefixed removes the table prefix from a parent table name, because constraints refer to
+// tables without their prefix. A schema prefix will not be present in the parent name anyway.
+func unprefixed(name sqlapi.TableName, parent string) string {
+	return strings.TrimPrefix(parent, name.Prefix)
+}
+
+// splitName gets the schema and table names. The schema is blank unless the prefix ends
+// with a dot.
+func splitName(name sqlapi.TableName) (string, string) {
+	if strings.HasSuffix(name.Prefix, ".") {
+		return name.PrefixWithoutDot(), name.Name
+	}
+	return "", name.String()
+}
+
+// consequence normalises the various ways that catalogs express foreign key actions, e.g.
+// "NO ACTION", "SET_NULL".
+func consequence(action string) constraint.Consequence {
+	c := strings.ToLower(strings.ReplaceAll(action, "_", " "))
+	if c == "no action" || c == "" {
+		return "" // the default
+	}
+	return constraint.Consequence(c)
+}
+
+// goName converts a column name such as "user_id" to an exported Go name such as "UserId".
+func goName(column string) string {
+	b := &strings.Builder{}
+	upper := true
+	for _, r := range column {
+		switch {
+		case r == '_' || r == ' ' || r == '-':
+			upper = true
+		case upper:
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,213 @@
+package introspect_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5/tracelog"
+	_ "github.com/lib/pq"
+	_ "github.com/marcboeker/go-duckdb"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/rickb777/expect"
+	"github.com/rickb777/sqlapi"
+	"github.com/rickb777/sqlapi/constraint"
+	"github.com/rickb777/sqlapi/driver"
+	"github.com/rickb777/sqlapi/introspect"
+	"github.com/rickb777/sqlapi/schema"
+	"github.com/rickb777/sqlapi/support/testenv"
+	"github.com/rickb777/sqlapi/types"
+	_ "modernc.org/sqlite"
+)
+
+var gdb sqlapi.SqlDB
+
+var (
+	i64 = schema.Type{Name: "int64", Base: types.Int64}
+	str = schema.Type{Name: "string", Base: types.String}
+	spt = schema.Type{Name: "string", Base: types.String, IsPtr: true}
+	f64 = schema.Type{Name: "float64", Base: types.Float64}
+)
+
+func field(name, sqlName string, typ schema.Type, tag types.Tag) *schema.Field {
+	return &schema.Field{Node: schema.Node{Name: name, Type: typ}, SqlName: sqlName, Tags: &tag}
+}
+
+func createTable(t *testing.T, prefix string, table *schema.TableDescription, cc ...constraint.Constraint) {
+	ctx := context.Background()
+	di := gdb.Dialect()
+	q := di.Quoter()
+	name := sqlapi.TableName{Prefix: prefix, Name: table.Name}
+
+	defs := driver.ColumnDefinitions(di, name.String(), table)
+	for i, c := range cc {
+		defs = append(defs, c.ConstraintSql(q, name, i))
+	}
+
+	for _, f := range table.Fields {
+		if f.Tags.Auto && driver.IsDuckDB(di) {
+			_, err := gdb.Exec(ctx, "CREATE SEQUENCE IF NOT EXISTS "+driver.SequenceName(name.String(), f.SqlName))
+			expect.Error(err).Not().ToHaveOccurred(t)
+		}
+	}
+
+	ddl := fmt.Sprintf("CREATE TABLE %s (%s)", q.Quote(name.String()), strings.Join(defs, ", "))
+	_, err := gdb.Exec(ctx, ddl)
+	expect.Error(err).Info(ddl).Not().ToHaveOccurred(t)
+}
+
+func dropTables(t *testing.T, names ...string) {
+	for _, n := range names {
+		_, err := gdb.Exec(context.Background(), "DROP TABLE IF EXISTS "+gdb.Dialect().Quoter().Quote(n))
+		expect.Error(err).Not().ToHaveOccurred(t)
+	}
+}
+
+func TestReadTable(t *testing.T) {
+	ctx := context.Background()
+	dropTables(t, "intro_orders", "intro_members", "intro_people", "intro_groups")
+
+	org := field("Org", "org", i64, types.Tag{Primary: true})
+	code := field("Code", "code", str, types.Tag{Primary: true, Size: 10})
+	groups := &schema.TableDescription{Name: "intro_groups", Fields: schema.FieldList{org, code}}
+	groups.SetPrimaryKey(org, code)
+	createTable(t, "", groups)
+
+	id := field("Id", "id", i64, types.Tag{Primary: true, Auto: true})
+	people := &schema.TableDescription{Name: "intro_people", Fields: schema.FieldList{id}, Primary: id}
+	createTable(t, "", people)
+
+	members := &schema.TableDescription{Name: "intro_members", Fields: schema.FieldList{
+		field("Id", "id", i64, types.Tag{Primary: true}),
+		field("PersonId", "person_id", i64, types.Tag{}),
+		field("Org", "org", i64, types.Tag{}),
+		field("Code", "code", str, types.Tag{Size: 10}),
+		field("Score", "score", f64, types.Tag{Default: "0"}),
+		field("Note", "note", spt, types.Tag{}),
+	}}
+	members.Primary = members.Fields[0]
+	createTable(t, "", members,
+		constraint.FkConstraintOn("person_id").RefersTo("intro_people", "id"),
+		constraint.CompositeFkConstraintOn("org", "code").RefersTo("intro_groups", "org", "code"))
+
+	_, err := gdb.Exec(context.Background(), "CREATE UNIQUE INDEX intro_members_note ON intro_members (note)")
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	//---------- composite primary key ----------
+
+	table, cc, err := introspect.ReadTable(ctx, gdb, sqlapi.TableName{Name: "intro_groups"})
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Slice(table.Fields.SqlNames()).ToBe(t, "org", "code")
+	expect.Slice(table.PrimaryKeyFields().SqlNames()).ToBe(t, "org", "code")
+	expect.Slice(cc).ToBeEmpty(t)
+
+	//---------- auto-increment primary key ----------
+
+	table, _, err = introspect.ReadTable(ctx, gdb, sqlapi.TableName{Name: "intro_people"})
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Bool(table.HasIntegerPrimaryKey()).ToBeTrue(t)
+	expect.String(table.Primary.Name).ToBe(t, "Id")
+	expect.Bool(table.Primary.Tags.Auto).ToBeTrue(t)
+
+	//---------- columns, indexes and foreign keys ----------
+
+	table, cc, err = introspect.ReadTable(ctx, gdb, sqlapi.TableName{Prefix: "intro_", Name: "members"})
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.String(table.Name).ToBe(t, "intro_members")
+	expect.Slice(table.Fields.SqlNames()).ToBe(t, "id", "person_id", "org", "code", "score", "note")
+	expect.Slice(table.PrimaryKeyFields().SqlNames()).ToBe(t, "id")
+	expect.Bool(table.Primary.Tags.Auto).ToBeFalse(t)
+
+	score := table.Fields[4]
+	expect.Number(score.Type.Base).ToBe(t, types.Float64)
+	expect.Bool(score.Type.IsPtr).ToBeFalse(t)
+	expect.String(score.Tags.Default).ToBe(t, "0")
+
+	note := table.Fields[5]
+	expect.Number(note.Type.Base).ToBe(t, types.String)
+	expect.Bool(note.Type.IsPtr).ToBeTrue(t)
+	expect.String(note.Tags.Unique).ToBe(t, "intro_members_note")
+
+	expect.Slice(table.Index).ToHaveLength(t, 1)
+	expect.String(table.Index[0].Name).ToBe(t, "intro_members_note")
+	expect.Bool(table.Index[0].Unique).ToBeTrue(t)
+
+	expect.Slice(cc.FkConstraints()).ToBe(t, constraint.FkConstraint{
+		ForeignKeyColumn: "person_id",
+		Parent:           constraint.Reference{TableName: "people", Column: "id"},
+	})
+	expect.Slice(cc.CompositeFkConstraints()).ToBe(t, constraint.CompositeFkConstraint{
+		ForeignKeyColumns: []string{"org", "code"},
+		Parent:            constraint.CompositeReference{TableName: "groups", Columns: []string{"org", "code"}},
+	})
+	expect.String(table.Fields[1].Tags.ForeignKey).ToBe(t, "people.id")
+
+	//---------- missing table ----------
+
+	_, _, err = introspect.ReadTable(ctx, gdb, sqlapi.TableName{Name: "intro_nonexistent"})
+	var notFound *introspect.NotFoundError
+	expect.Bool(errors.As(err, &notFound)).Info(err).ToBeTrue(t)
+}
+
+func TestReadTables(t *testing.T) {
+	ctx := context.Background()
+	dropTables(t, "intro_orders", "intro_members", "intro_people", "intro_groups", "intro_x1", "intro_x2")
+
+	for _, n := range []string{"intro_x1", "intro_x2"} {
+		id := field("Id", "id", i64, types.Tag{Primary: true})
+		createTable(t, "", &schema.TableDescription{Name: n, Fields: schema.FieldList{id}, Primary: id})
+	}
+
+	tables, cc, err := introspect.ReadTables(ctx, gdb, "intro_x")
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Slice(tables).ToHaveLength(t, 2)
+	expect.Slice(cc).ToHaveLength(t, 2)
+}
+
+func TestWriteGo_database(t *testing.T) {
+	ctx := context.Background()
+	TestReadTable(t) // creates the intro_ tables
+
+	tables, cc, err := introspect.ReadTables(ctx, gdb, "intro_")
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	b := &strings.Builder{}
+	err = introspect.WriteGo(b, "model", "intro_", tables, cc)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	src := b.String()
+
+	expect.String(src).ToContain(t, "type Member struct {")
+	expect.String(src).ToContain(t, "type People struct {")
+	expect.String(src).ToContain(t, "// foreign key (org, code) references groups (org, code)")
+
+	file, err := parser.ParseFile(token.NewFileSet(), "model.go", src, 0)
+	expect.Error(err).Info(src).Not().ToHaveOccurred(t)
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		if f, ok := n.(*ast.Field); ok && f.Tag != nil {
+			raw, err := strconv.Unquote(f.Tag.Value)
+			expect.Error(err).Not().ToHaveOccurred(t)
+			_, err = types.ParseTag(raw)
+			expect.Error(err).Info(raw).Not().ToHaveOccurred(t)
+		}
+		return true
+	})
+}
+
+//-------------------------------------------------------------------------------------------------
+
+func TestMain(m *testing.M) {
+	testenv.SetDefaultDbDriver("sqlite3")
+	testenv.Shebang(m, func(lgr tracelog.Logger, logLevel tracelog.LogLevel, tries int) (err error) {
+		gdb, err = sqlapi.ConnectEnv(context.Background(), lgr, logLevel, tries)
+		return err
+	})
+}
@@ -0,0 +1,122 @@
+package introspect
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/rickb777/sqlapi"
+)
+
+// https://dev.mysql.com/doc/refman/8.0/en/information-schema.html
+
+const mysqlColumns = `SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE = 'YES', COLUMN_DEFAULT, EXTRA
+FROM information_schema.COLUMNS
+WHERE TABLE_SCHEMA = %s AND TABLE_NAME = ?
+ORDER BY ORDINAL_POSITION`
+
+const mysqlIndexes = `SELECT INDEX_NAME, NON_UNIQUE = 0, COLUMN_NAME
+FROM information_schema.STATISTICS
+WHERE TABLE_SCHEMA = %s AND TABLE_NAME = ?
+ORDER BY INDEX_NAME, SEQ_IN_INDEX`
+
+const mysqlForeignKeys = `SELECT k.CONSTRAINT_NAME, k.COLUMN_NAME, k.REFERENCED_TABLE_NAME, k.REFERENCED_COLUMN_NAME,
+	r.UPDATE_RULE, r.DELETE_RULE
+FROM information_schema.KEY_COLUMN_USAGE k
+JOIN information_schema.REFERENTIAL_CONSTRAINTS r
+	ON r.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA AND r.CONSTRAINT_NAME = k.CONSTRAINT_NAME
+WHERE k.TABLE_SCHEMA = %s AND k.TABLE_NAME = ?
+ORDER BY k.CONSTRAINT_NAME, k.ORDINAL_POSITION`
+
+func readMysql(ctx context.Context, ex sqlapi.Execer, name sqlapi.TableName) (*tableInfo, error) {
+	info := &tableInfo{}
+
+	schemaName, table := splitName(name)
+	schemaExpr := "DATABASE()"
+	args := []interface{}{table}
+	if schemaName != "" {
+		schemaExpr = "?"
+		args = []interface{}{schemaName, table}
+	}
+
+	//---------- columns ----------
+
+	rows, err := ex.Query(ctx, strings.Replace(mysqlColumns, "%s", schemaExpr, 1), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var c columnInfo
+		var dflt sql.NullString
+		var extra string
+		if err = rows.Scan(&c.name, &c.sqlType, &c.nullable, &dflt, &extra); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		extra = strings.ToLower(extra)
+		c.auto = strings.Contains(extra, "auto_increment")
+		if dflt.Valid {
+			c.dflt = &dflt.String
+			// MySQL shows literal defaults without quotes; expressions are marked as generated
+			c.literal = !strings.Contains(extra, "default_generated") && !strings.EqualFold(dflt.String, "CURRENT_TIMESTAMP")
+		}
+		info.columns = append(info.columns, c)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	//---------- primary key and indexes ----------
+
+	rows, err = ex.Query(ctx, strings.Replace(mysqlIndexes, "%s", schemaExpr, 1), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var ixName, column string
+		var unique bool
+		if err = rows.Scan(&ixName, &unique, &column); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		if ixName == "PRIMARY" {
+			info.primaryKey = append(info.primaryKey, column)
+		} else {
+			ix := info.indexByName(ixName, unique)
+			ix.columns = append(ix.columns, column)
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	//---------- foreign keys ----------
+
+	rows, err = ex.Query(ctx, strings.Replace(mysqlForeignKeys, "%s", schemaExpr, 1), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var fkName, column, parent, parentColumn, onUpdate, onDelete string
+		if err = rows.Scan(&fkName, &column, &parent, &parentColumn, &onUpdate, &onDelete); err != nil {
+			return nil, err
+		}
+
+		fk := info.fkByName(fkName)
+		fk.parentTable = parent
+		fk.columns = append(fk.columns, column)
+		fk.parentColumns = append(fk.parentColumns, parentColumn)
+		fk.onUpdate = consequence(onUpdate)
+		fk.onDelete = consequence(onDelete)
+	}
+
+	return info, rows.Err()
+}
@@ -0,0 +1,158 @@
+package introspect
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/rickb777/sqlapi"
+	"github.com/rickb777/sqlapi/constraint"
+)
+
+// https://www.postgresql.org/docs/current/catalogs.html
+//
+// The table is looked up using to_regclass, which gives NULL rather than an error if it does
+// not exist, so that the queries give no rows and ReadTable returns a NotFoundError.
+
+const pgColumns = `SELECT a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull,
+	pg_get_expr(d.adbin, d.adrelid), a.attidentity <> ''
+FROM pg_attribute a
+LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+WHERE a.attrelid = to_regclass(?) AND a.attnum > 0 AND NOT a.attisdropped
+ORDER BY a.attnum`
+
+// pgColumnNames lists the names of the columns given by an int2vector or array of column numbers.
+const pgColumnNames = `array_to_string(ARRAY(
+	SELECT a.attname FROM unnest(%s) WITH ORDINALITY AS k(n, o)
+	JOIN pg_attribute a ON a.attrelid = %s AND a.attnum = k.n
+	ORDER BY k.o), ',')`
+
+var pgIndexes = `SELECT i.relname, ix.indisunique, ix.indisprimary, ` +
+	fmt.Sprintf(pgColumnNames, "ix.indkey", "ix.indrelid") + `
+FROM pg_index ix
+JOIN pg_class i ON i.oid = ix.indexrelid
+WHERE ix.indrelid = to_regclass(?)
+ORDER BY i.relname`
+
+var pgForeignKeys = `SELECT c.conname, p.relname, ` +
+	fmt.Sprintf(pgColumnNames, "c.conkey", "c.conrelid") + `, ` +
+	fmt.Sprintf(pgColumnNames, "c.confkey", "c.confrelid") + `, c.confupdtype::text, c.confdeltype::text
+FROM pg_constraint c
+JOIN pg_class p ON p.oid = c.confrelid
+WHERE c.conrelid = to_regclass(?) AND c.contype = 'f'
+ORDER BY c.conname`
+
+func readPostgres(ctx context.Context, ex sqlapi.Execer, name sqlapi.TableName) (*tableInfo, error) {
+	di := ex.Dialect()
+	info := &tableInfo{}
+	regclass := pgRegclass(name)
+
+	//---------- columns ----------
+
+	rows, err := ex.Query(ctx, di.ReplacePlaceholders(pgColumns, nil), regclass)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var c columnInfo
+		var dflt sql.NullString
+		var identity bool
+		if err = rows.Scan(&c.name, &c.sqlType, &c.nullable, &dflt, &identity); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		if dflt.Valid {
+			c.dflt = &dflt.String
+		}
+		c.auto = identity || strings.HasPrefix(dflt.String, "nextval(")
+		info.columns = append(info.columns, c)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	//---------- primary key and indexes ----------
+
+	rows, err = ex.Query(ctx, di.ReplacePlaceholders(pgIndexes, nil), regclass)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var ixName, columns string
+		var unique, primary bool
+		if err = rows.Scan(&ixName, &unique, &primary, &columns); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		if primary {
+			info.primaryKey = strings.Split(columns, ",")
+		} else {
+			info.indexes = append(info.indexes, indexInfo{name: ixName, unique: unique, columns: strings.Split(columns, ",")})
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	//---------- foreign keys ----------
+
+	rows, err = ex.Query(ctx, di.ReplacePlaceholders(pgForeignKeys, nil), regclass)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var fkName, parent, columns, parentColumns, onUpdate, onDelete string
+		if err = rows.Scan(&fkName, &parent, &columns, &parentColumns, &onUpdate, &onDelete); err != nil {
+			return nil, err
+		}
+
+		info.foreignKeys = append(info.foreignKeys, foreignKeyInfo{
+			name:          fkName,
+			columns:       strings.Split(columns, ","),
+			parentTable:   parent,
+			parentColumns: strings.Split(parentColumns, ","),
+			onUpdate:      pgConsequence(onUpdate),
+			onDelete:      pgConsequence(onDelete),
+		})
+	}
+
+	return info, rows.Err()
+}
+
+// pgRegclass gets the table name in the form needed by to_regclass, i.e. with quoted
+// identifiers.
+func pgRegclass(name sqlapi.TableName) string {
+	schemaName, table := splitName(name)
+	if schemaName == "" {
+		return pgIdent(table)
+	}
+	return pgIdent(schemaName) + "." + pgIdent(table)
+}
+
+func pgIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// pgConsequence decodes the foreign key action codes in pg_constraint.
+func pgConsequence(code string) constraint.Consequence {
+	switch code {
+	case "r":
+		return constraint.Restrict
+	case "c":
+		return constraint.Cascade
+	case "n":
+		return constraint.SetNull
+	case "d":
+		return constraint.SetDefault
+	}
+	return "" // 'a' is no action, the default
+}
@@ -0,0 +1,154 @@
+package introspect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rickb777/sqlapi"
+)
+
+// https://www.sqlite.org/pragma.html#pragma_table_info
+
+func readSqlite(ctx context.Context, ex sqlapi.Execer, name sqlapi.TableName) (*tableInfo, error) {
+	q := ex.Dialect().Quoter()
+	table := q.Quote(name.String())
+	info := &tableInfo{}
+
+	autoIncrement, err := sqliteAutoIncrement(ctx, ex, name.String())
+	if err != nil {
+		return nil, err
+	}
+
+	//---------- columns and primary key ----------
+
+	rows, err := ex.Query(ctx, "PRAGMA table_info("+table+")")
+	if err != nil {
+		return nil, err
+	}
+
+	pkPosition := make(map[int]string)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var colName, colType string
+		var dflt sql.NullString
+		if err = rows.Scan(&cid, &colName, &colType, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		c := columnInfo{name: colName, sqlType: strings.ToLower(colType), nullable: notNull == 0 && pk == 0}
+		if dflt.Valid {
+			c.dflt = &dflt.String
+		}
+		if pk > 0 {
+			pkPosition[pk] = colName
+		}
+		info.columns = append(info.columns, c)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := 1; i <= len(pkPosition); i++ {
+		info.primaryKey = append(info.primaryKey, pkPosition[i])
+	}
+
+	if autoIncrement && len(info.primaryKey) == 1 {
+		for i := range info.columns {
+			if info.columns[i].name == info.primaryKey[0] {
+				info.columns[i].auto = true
+			}
+		}
+	}
+
+	//---------- indexes ----------
+
+	rows, err = ex.Query(ctx, "PRAGMA index_list("+table+")")
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var seq, unique, partial int
+		var ixName, origin string
+		if err = rows.Scan(&seq, &ixName, &unique, &origin, &partial); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if origin != "pk" {
+			info.indexes = append(info.indexes, indexInfo{name: ixName, unique: unique != 0})
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(info.indexes, func(i, j int) bool { return info.indexes[i].name < info.indexes[j].name })
+
+	for i := range info.indexes {
+		ix := &info.indexes[i]
+		rows, err = ex.Query(ctx, "PRAGMA index_info("+q.Quote(ix.name)+")")
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			var seqNo, cid int
+			var colName sql.NullString // null for expressions
+			if err = rows.Scan(&seqNo, &cid, &colName); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			ix.columns = append(ix.columns, colName.String)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	//---------- foreign keys ----------
+
+	rows, err = ex.Query(ctx, "PRAGMA foreign_key_list("+table+")")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, seq int
+		var parent, from, onUpdate, onDelete, match string
+		var to sql.NullString // null if the parent's primary key is implied
+		if err = rows.Scan(&id, &seq, &parent, &from, &to, &onUpdate, &onDelete, &match); err != nil {
+			return nil, err
+		}
+
+		fk := info.fkByName(strconv.Itoa(id))
+		fk.parentTable = parent
+		fk.columns = append(fk.columns, from)
+		if to.Valid {
+			fk.parentColumns = append(fk.parentColumns, to.String)
+		}
+		fk.onUpdate = consequence(onUpdate)
+		fk.onDelete = consequence(onDelete)
+	}
+
+	return info, rows.Err()
+}
+
+// sqliteAutoIncrement finds whether a table was declared with AUTOINCREMENT. This is not
+// available via PRAGMA, so the table's SQL has to be inspected.
+func sqliteAutoIncrement(ctx context.Context, ex sqlapi.Execer, table string) (bool, error) {
+	var ddl sql.NullString
+	err := ex.QueryRow(ctx, "SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&ddl)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return strings.Contains(strings.ToUpper(ddl.String), "AUTOINCREMENT"), err
+}
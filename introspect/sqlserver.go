@@ -0,0 +1,128 @@
+package introspect
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/rickb777/sqlapi"
+)
+
+// https://learn.microsoft.com/en-us/sql/relational-databases/system-information-schema-views/columns-transact-sql
+
+const sqlServerColumns = `SELECT c.COLUMN_NAME,
+	c.DATA_TYPE + CASE
+		WHEN c.CHARACTER_MAXIMUM_LENGTH = -1 THEN '(max)'
+		WHEN c.CHARACTER_MAXIMUM_LENGTH IS NOT NULL THEN '(' + CAST(c.CHARACTER_MAXIMUM_LENGTH AS varchar(10)) + ')'
+		ELSE '' END,
+	CASE WHEN c.IS_NULLABLE = 'YES' THEN 1 ELSE 0 END,
+	c.COLUMN_DEFAULT,
+	COALESCE(COLUMNPROPERTY(OBJECT_ID(c.TABLE_SCHEMA + '.' + c.TABLE_NAME), c.COLUMN_NAME, 'IsIdentity'), 0)
+FROM INFORMATION_SCHEMA.COLUMNS c
+WHERE c.TABLE_SCHEMA = COALESCE(NULLIF(?, ''), SCHEMA_NAME()) AND c.TABLE_NAME = ?
+ORDER BY c.ORDINAL_POSITION`
+
+const sqlServerIndexes = `SELECT i.name, i.is_unique, i.is_primary_key, c.name
+FROM sys.indexes i
+JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id
+JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+WHERE i.object_id = OBJECT_ID(?)
+ORDER BY i.name, ic.key_ordinal`
+
+const sqlServerForeignKeys = `SELECT fk.name, pc.name, rt.name, rc.name,
+	fk.update_referential_action_desc, fk.delete_referential_action_desc
+FROM sys.foreign_keys fk
+JOIN sys.foreign_key_columns fkc ON fkc.constraint_object_id = fk.object_id
+JOIN sys.columns pc ON pc.object_id = fkc.parent_object_id AND pc.column_id = fkc.parent_column_id
+JOIN sys.tables rt ON rt.object_id = fkc.referenced_object_id
+JOIN sys.columns rc ON rc.object_id = fkc.referenced_object_id AND rc.column_id = fkc.referenced_column_id
+WHERE fk.parent_object_id = OBJECT_ID(?)
+ORDER BY fk.name, fkc.constraint_column_id`
+
+func readSqlServer(ctx context.Context, ex sqlapi.Execer, name sqlapi.TableName) (*tableInfo, error) {
+	di := ex.Dialect()
+	info := &tableInfo{}
+
+	schemaName, table := splitName(name)
+	objectName := table
+	if schemaName != "" {
+		objectName = schemaName + "." + table
+	}
+
+	//---------- columns ----------
+
+	rows, err := ex.Query(ctx, di.ReplacePlaceholders(sqlServerColumns, nil), schemaName, table)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var c columnInfo
+		var dflt sql.NullString
+		var identity int
+		if err = rows.Scan(&c.name, &c.sqlType, &c.nullable, &dflt, &identity); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		if dflt.Valid {
+			c.dflt = &dflt.String
+		}
+		c.auto = identity != 0
+		info.columns = append(info.columns, c)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	//---------- primary key and indexes ----------
+
+	rows, err = ex.Query(ctx, di.ReplacePlaceholders(sqlServerIndexes, nil), objectName)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var ixName, column string
+		var unique, primary bool
+		if err = rows.Scan(&ixName, &unique, &primary, &column); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		if primary {
+			info.primaryKey = append(info.primaryKey, column)
+		} else {
+			ix := info.indexByName(ixName, unique)
+			ix.columns = append(ix.columns, column)
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	//---------- foreign keys ----------
+
+	rows, err = ex.Query(ctx, di.ReplacePlaceholders(sqlServerForeignKeys, nil), objectName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var fkName, column, parent, parentColumn, onUpdate, onDelete string
+		if err = rows.Scan(&fkName, &column, &parent, &parentColumn, &onUpdate, &onDelete); err != nil {
+			return nil, err
+		}
+
+		fk := info.fkByName(fkName)
+		fk.parentTable = parent
+		fk.columns = append(fk.columns, column)
+		fk.parentColumns = append(fk.parentColumns, parentColumn)
+		fk.onUpdate = consequence(onUpdate)
+		fk.onDelete = consequence(onDelete)
+	}
+
+	return info, rows.Err()
+}
@@ -0,0 +1,168 @@
+package introspect
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rickb777/sqlapi/schema"
+	"github.com/rickb777/sqlapi/types"
+)
+
+var (
+	timeType  = schema.Type{PkgPath: "time", PkgName: "time", Name: "Time", Base: types.Struct}
+	bytesType = schema.Type{Name: "[]byte", Base: types.Slice}
+)
+
+// goType infers the Go type for an SQL column type. Unrecognised types are treated as strings.
+func goType(sqlType string) schema.Type {
+	t := strings.ToLower(strings.TrimSpace(sqlType))
+
+	if strings.HasPrefix(t, "tinyint(1)") { // MySQL booleans
+		return schema.Type{Name: "bool", Base: types.Bool}
+	}
+
+	unsigned := strings.Contains(t, "unsigned")
+	base := strings.TrimSpace(strings.Replace(baseTypeName(t), "unsigned", "", 1))
+
+	switch base {
+	case "bool", "boolean", "bit":
+		return schema.Type{Name: "bool", Base: types.Bool}
+	case "tinyint", "int1":
+		return integerType(types.Int8, types.Uint8, unsigned)
+	case "smallint", "int2", "smallserial", "usmallint":
+		return integerType(types.Int16, types.Uint16, unsigned || base == "usmallint")
+	case "int", "int4", "mediumint", "serial", "uinteger":
+		return integerType(types.Int32, types.Uint32, unsigned || base == "uinteger")
+	case "integer", "bigint", "int8", "bigserial", "ubigint":
+		return integerType(types.Int64, types.Uint64, unsigned || base == "ubigint")
+	case "utinyint":
+		return integerType(types.Int8, types.Uint8, true)
+	case "real", "float4":
+		return schema.Type{Name: "float32", Base: types.Float32}
+	case "float", "float8", "double", "double precision", "numeric", "decimal", "money":
+		return schema.Type{Name: "float64", Base: types.Float64}
+	case "date", "datetime", "datetime2", "smalldatetime", "datetimeoffset", "timestamp",
+		"timestamptz", "timestamp with time zone", "timestamp without time zone":
+		return timeType
+	case "blob", "mediumblob", "longblob", "tinyblob", "bytea", "binary", "varbinary", "image":
+		return bytesType
+	}
+	return schema.Type{Name: "string", Base: types.String}
+}
+
+func integerType(signed, unsigned types.Kind, isUnsigned bool) schema.Type {
+	if isUnsigned {
+		return schema.Type{Name: unsigned.String(), Base: unsigned}
+	}
+	return schema.Type{Name: signed.String(), Base: signed}
+}
+
+// baseTypeName removes any size or precision from a type, e.g. "varchar(255)" becomes "varchar".
+func baseTypeName(sqlType string) string {
+	if i := strings.IndexByte(sqlType, '('); i >= 0 {
+		return strings.TrimSpace(sqlType[:i] + " " + sqlType[strings.IndexByte(sqlType, ')')+1:])
+	}
+	return sqlType
+}
+
+// typeSize gets the size given in a type such as "varchar(255)", or 0 if there is none.
+func typeSize(sqlType string) int {
+	i := strings.IndexByte(sqlType, '(')
+	j := strings.IndexByte(sqlType, ')')
+	if i < 0 || j < i {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(sqlType[i+1 : j]))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// parseDefault interprets a default value as shown in a catalog. This is either a literal value,
+// which is returned without any quotes or casts, or an SQL expression. NULL is treated as no
+// default.
+func parseDefault(dflt string) (literal, expr string) {
+	s := unparenthesise(strings.TrimSpace(dflt))
+
+	switch {
+	case s == "" || strings.EqualFold(s, "null"):
+		return "", ""
+
+	case strings.EqualFold(s, "true") || strings.EqualFold(s, "false"):
+		return strings.ToLower(s), ""
+
+	case isNumber(s):
+		return s, ""
+	}
+
+	if v, ok := quotedLiteral(s); ok {
+		return v, ""
+	}
+
+	return "", s
+}
+
+// quotedLiteral recognises a string literal, optionally prefixed with N (SQL Server) and
+// optionally followed by a cast (PostgreSQL), e.g. 'abc'::text.
+func quotedLiteral(s string) (string, bool) {
+	if strings.HasPrefix(s, "N'") {
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "'") {
+		return "", false
+	}
+
+	b := &strings.Builder{}
+	for i := 1; i < len(s); i++ {
+		if s[i] == '\'' {
+			if i+1 < len(s) && s[i+1] == '\'' {
+				b.WriteByte('\'')
+				i++
+				continue
+			}
+			rest := s[i+1:]
+			if rest == "" || strings.HasPrefix(rest, "::") && !strings.ContainsAny(rest, "()'+-*/|") {
+				return b.String(), true
+			}
+			return "", false
+		}
+		b.WriteByte(s[i])
+	}
+	return "", false
+}
+
+// unparenthesise removes any parentheses that enclose the whole expression, as used by
+// SQL Server, e.g. "((0))".
+func unparenthesise(s string) string {
+	for len(s) >= 2 && s[0] == '(' && closingParen(s) == len(s)-1 {
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+	return s
+}
+
+// closingParen finds the parenthesis that matches the one at the start of s.
+func closingParen(s string) int {
+	depth := 0
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\'':
+			quoted = !quoted
+		case quoted:
+		case s[i] == '(':
+			depth++
+		case s[i] == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil && !strings.ContainsAny(s, "xXnNiI_") // not hex, NaN, Inf
+}
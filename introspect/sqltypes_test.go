@@ -0,0 +1,74 @@
+package introspect
+
+import (
+	"testing"
+
+	"github.com/rickb777/expect"
+	"github.com/rickb777/sqlapi/types"
+)
+
+func TestGoType(t *testing.T) {
+	cases := []struct {
+		sqlType string
+		name    string
+		base    types.Kind
+	}{
+		{sqlType: "integer", name: "int64", base: types.Int64},
+		{sqlType: "bigint unsigned", name: "uint64", base: types.Uint64},
+		{sqlType: "int(11)", name: "int32", base: types.Int32},
+		{sqlType: "tinyint(1)", name: "bool", base: types.Bool},
+		{sqlType: "boolean", name: "bool", base: types.Bool},
+		{sqlType: "real", name: "float32", base: types.Float32},
+		{sqlType: "double precision", name: "float64", base: types.Float64},
+		{sqlType: "numeric(10,2)", name: "float64", base: types.Float64},
+		{sqlType: "timestamp with time zone", name: "Time", base: types.Struct},
+		{sqlType: "bytea", name: "[]byte", base: types.Slice},
+		{sqlType: "character varying(40)", name: "string", base: types.String},
+		{sqlType: "json", name: "string", base: types.String},
+	}
+
+	for _, c := range cases {
+		typ := goType(c.sqlType)
+		expect.String(typ.Name).I(c.sqlType).ToBe(t, c.name)
+		expect.Number(typ.Base).I(c.sqlType).ToBe(t, c.base)
+	}
+}
+
+func TestTypeSize(t *testing.T) {
+	expect.Number(typeSize("varchar(255)")).ToBe(t, 255)
+	expect.Number(typeSize("numeric(10,2)")).ToBe(t, 0)
+	expect.Number(typeSize("text")).ToBe(t, 0)
+}
+
+func TestParseDefault(t *testing.T) {
+	cases := []struct {
+		dflt, literal, expr string
+	}{
+		{dflt: "NULL"},
+		{dflt: "0", literal: "0"},
+		{dflt: "((0))", literal: "0"},
+		{dflt: "-1.5", literal: "-1.5"},
+		{dflt: "true", literal: "true"},
+		{dflt: "'abc'", literal: "abc"},
+		{dflt: "'it''s'", literal: "it's"},
+		{dflt: "N'x'", literal: "x"},
+		{dflt: "'abc'::character varying", literal: "abc"},
+		{dflt: "('a')", literal: "a"},
+		{dflt: "CURRENT_TIMESTAMP", expr: "CURRENT_TIMESTAMP"},
+		{dflt: "(getdate())", expr: "getdate()"},
+		{dflt: "nextval('id_seq'::regclass)", expr: "nextval('id_seq'::regclass)"},
+		{dflt: "'a' || 'b'", expr: "'a' || 'b'"},
+	}
+
+	for _, c := range cases {
+		literal, expr := parseDefault(c.dflt)
+		expect.String(literal).I(c.dflt).ToBe(t, c.literal)
+		expect.String(expr).I(c.dflt).ToBe(t, c.expr)
+	}
+}
+
+func TestGoName(t *testing.T) {
+	expect.String(goName("user_id")).ToBe(t, "UserId")
+	expect.String(goName("name")).ToBe(t, "Name")
+	expect.String(goName("Created At")).ToBe(t, "CreatedAt")
+}
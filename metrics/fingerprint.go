@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	valueList  = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	valueLists = regexp.MustCompile(`\(\.\.\.\)(?:\s*,\s*\(\.\.\.\))+`)
+)
+
+// Fingerprint normalises a query so that statements that differ only in their values have the
+// same fingerprint:
+//
+//   - string and numeric literals and all kinds of placeholder (?, $1, :name, @name) become ?
+//   - parenthesised lists of these become (...), as do runs of such lists, e.g. multi-row VALUES
+//   - comments are removed and whitespace is collapsed to single spaces
+//
+// Quoted identifiers and the case of keywords are unchanged.
+func Fingerprint(query string) string {
+	b := &strings.Builder{}
+	space := false
+
+	emit := func(s string) {
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteString(s)
+	}
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = true
+			i++
+
+		case strings.HasPrefix(query[i:], "--"):
+			space = true
+			i = skipTo(query, i+2, "\n")
+
+		case strings.HasPrefix(query[i:], "/*"):
+			space = true
+			i = skipTo(query, i+2, "*/")
+
+		case c == '\'':
+			emit("?")
+			i = skipQuoted(query, i+1, '\'')
+
+		case c == '"' || c == '`':
+			j := skipQuoted(query, i+1, c)
+			emit(query[i:j])
+			i = j
+
+		case c == '?':
+			emit("?")
+			i++
+
+		case (c == '$' || c == '@') && i+1 < len(query) && isIdent(query[i+1]):
+			emit("?")
+			i = skipIdent(query, i+1)
+
+		case c == ':' && i+1 < len(query) && isLetter(query[i+1]) && (i == 0 || query[i-1] != ':'):
+			emit("?")
+			i = skipIdent(query, i+1)
+
+		case isDigit(c):
+			emit("?")
+			i = skipNumber(query, i)
+
+		case isIdent(c):
+			j := skipIdent(query, i)
+			emit(query[i:j])
+			i = j
+
+		default:
+			emit(query[i : i+1])
+			i++
+		}
+	}
+
+	s := valueList.ReplaceAllString(b.String(), "(...)")
+	return valueLists.ReplaceAllString(s, "(...)")
+}
+
+// skipTo gives the index after the next occurrence of end, or the end of the query.
+func skipTo(query string, i int, end string) int {
+	if j := strings.Index(query[i:], end); j >= 0 {
+		return i + j + len(end)
+	}
+	return len(query)
+}
+
+// skipQuoted gives the index after the closing quote, allowing for doubled quotes.
+func skipQuoted(query string, i int, quote byte) int {
+	for i < len(query) {
+		if query[i] == quote {
+			if i+1 < len(query) && query[i+1] == quote {
+				i += 2
+				continue
+			}
+			return i + 1
+		}
+		i++
+	}
+	return i
+}
+
+func skipIdent(query string, i int) int {
+	for i < len(query) && isIdent(query[i]) {
+		i++
+	}
+	return i
+}
+
+func skipNumber(query string, i int) int {
+	for i < len(query) && (isDigit(query[i]) || query[i] == '.') {
+		i++
+	}
+	if i < len(query) && (query[i] == 'e' || query[i] == 'E') {
+		i++
+		if i < len(query) && (query[i] == '+' || query[i] == '-') {
+			i++
+		}
+		for i < len(query) && isDigit(query[i]) {
+			i++
+		}
+	}
+	return i
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || c == '_'
+}
+
+func isIdent(c byte) bool {
+	return isLetter(c) || isDigit(c) || c >= 0x80
+}
@@ -0,0 +1,33 @@
+package metrics_test
+
+import (
+	"testing"
+
+	"github.com/rickb777/expect"
+	"github.com/rickb777/sqlapi/metrics"
+)
+
+func TestFingerprint(t *testing.T) {
+	cases := []struct {
+		query, expected string
+	}{
+		{"SELECT id FROM t WHERE name = 'Ann'", "SELECT id FROM t WHERE name = ?"},
+		{"SELECT id FROM t WHERE name = 'It''s'", "SELECT id FROM t WHERE name = ?"},
+		{"SELECT id FROM t1 WHERE x > 42 AND y < -1.5e3", "SELECT id FROM t1 WHERE x > ? AND y < -?"},
+		{"SELECT  id\n\tFROM t -- comment\n WHERE id=?", "SELECT id FROM t WHERE id=?"},
+		{"SELECT /* hint */ id FROM t WHERE id = $1", "SELECT id FROM t WHERE id = ?"},
+		{"SELECT id FROM t WHERE id = :id OR id = @p1", "SELECT id FROM t WHERE id = ? OR id = ?"},
+		{"SELECT x::int FROM t", "SELECT x::int FROM t"},
+		{`SELECT "a b", ` + "`c`" + ` FROM t`, `SELECT "a b", ` + "`c`" + ` FROM t`},
+		{"DELETE FROM t WHERE id IN (?)", "DELETE FROM t WHERE id IN (...)"},
+		{"DELETE FROM t WHERE id IN (?,?,?)", "DELETE FROM t WHERE id IN (...)"},
+		{"DELETE FROM t WHERE id IN ( $1, $2 )", "DELETE FROM t WHERE id IN (...)"},
+		{"INSERT INTO t (a, b) VALUES (?, ?), (?, ?)", "INSERT INTO t (a, b) VALUES (...)"},
+		{"INSERT INTO t (a, b) VALUES (1, 'x')", "INSERT INTO t (a, b) VALUES (...)"},
+		{"SELECT count(*) FROM t", "SELECT count(*) FROM t"},
+	}
+
+	for _, c := range cases {
+		expect.String(metrics.Fingerprint(c.query)).Info(c.query).ToBe(t, c.expected)
+	}
+}
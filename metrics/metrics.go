@@ -0,0 +1,166 @@
+// Package metrics collects statistics for every SQL statement: the number of calls, errors
+// and rows affected, and a latency histogram. Statements are grouped by their fingerprint,
+// which is the query with its literal values and placeholders normalised (see Fingerprint), so
+// that, for example, all the batches of a DELETE ... WHERE id IN (?,?,?) are counted together.
+//
+// The statistics are gathered by an interceptor: see sqlapi.MetricsInterceptor and
+// pgxapi.MetricsInterceptor. They can be read using Snapshot, or served in the Prometheus
+// text exposition format because Collector is an http.Handler.
+//
+//	c := metrics.New(db)
+//	db = db.WithInterceptors(sqlapi.MetricsInterceptor(c))
+//	http.Handle("/metrics", c)
+//
+// The connection pool statistics are included too, for both database/sql and pgxpool.
+package metrics
+
+import (
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the upper bounds of the latency histogram buckets used by default.
+var DefaultBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// DB is the part of sqlapi.SqlDB and pgxapi.SqlDB that provides the connection pool statistics.
+type DB interface {
+	Stats() sql.DBStats
+}
+
+// Collector accumulates the statistics. It is safe for concurrent use.
+type Collector struct {
+	db      DB
+	buckets []time.Duration
+
+	mu      sync.Mutex
+	queries map[string]*query
+}
+
+// query holds the statistics for one fingerprint.
+type query struct {
+	count, errors, rows int64
+	duration            time.Duration
+	buckets             []int64 // not cumulative; the last is for +Inf
+}
+
+// New creates a collector. The database is optional and can be nil, in which case there are
+// no connection pool statistics. The histogram buckets are in increasing order; if there are
+// none, DefaultBuckets are used.
+func New(db DB, buckets ...time.Duration) *Collector {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	return &Collector{db: db, buckets: buckets, queries: make(map[string]*query)}
+}
+
+// Record adds the outcome of one statement to the statistics. The error should be nil for
+// outcomes that aren't failures, such as sql.ErrNoRows.
+func (c *Collector) Record(statement string, rows int64, d time.Duration, err error) {
+	fp := Fingerprint(statement)
+	b := sort.Search(len(c.buckets), func(i int) bool { return d <= c.buckets[i] })
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	q, exists := c.queries[fp]
+	if !exists {
+		q = &query{buckets: make([]int64, len(c.buckets)+1)}
+		c.queries[fp] = q
+	}
+
+	q.count++
+	if err != nil {
+		q.errors++
+	}
+	q.rows += rows
+	q.duration += d
+	q.buckets[b]++
+}
+
+// Reset discards all the statement statistics.
+func (c *Collector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queries = make(map[string]*query)
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// Snapshot holds the statistics at some moment.
+type Snapshot struct {
+	Queries []QueryStats // in order of fingerprint
+	DB      *sql.DBStats // nil if the collector has no database
+}
+
+// QueryStats holds the statistics for the statements with the same fingerprint.
+type QueryStats struct {
+	Fingerprint string
+	Count       int64
+	Errors      int64
+	Rows        int64         // the total rows affected
+	Duration    time.Duration // the total duration
+	Buckets     []Bucket      // the cumulative latency histogram, excluding +Inf, which is Count
+}
+
+// Bucket gives the number of statements that took no longer than UpperBound.
+type Bucket struct {
+	UpperBound time.Duration
+	Count      int64
+}
+
+// Mean gives the average duration of the statements.
+func (qs QueryStats) Mean() time.Duration {
+	if qs.Count == 0 {
+		return 0
+	}
+	return qs.Duration / time.Duration(qs.Count)
+}
+
+// Snapshot gets a copy of the current statistics.
+func (c *Collector) Snapshot() Snapshot {
+	s := Snapshot{}
+	if c.db != nil {
+		stats := c.db.Stats()
+		s.DB = &stats
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s.Queries = make([]QueryStats, 0, len(c.queries))
+	for fp, q := range c.queries {
+		qs := QueryStats{
+			Fingerprint: fp,
+			Count:       q.count,
+			Errors:      q.errors,
+			Rows:        q.rows,
+			Duration:    q.duration,
+			Buckets:     make([]Bucket, len(c.buckets)),
+		}
+
+		var n int64
+		for i, le := range c.buckets {
+			n += q.buckets[i]
+			qs.Buckets[i] = Bucket{UpperBound: le, Count: n}
+		}
+		s.Queries = append(s.Queries, qs)
+	}
+
+	sort.Slice(s.Queries, func(i, j int) bool { return s.Queries[i].Fingerprint < s.Queries[j].Fingerprint })
+	return s
+}
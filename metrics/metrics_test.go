@@ -0,0 +1,74 @@
+package metrics_test
+
+import (
+	"database/sql"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rickb777/expect"
+	"github.com/rickb777/sqlapi/metrics"
+)
+
+type stubDB struct{}
+
+func (stubDB) Stats() sql.DBStats {
+	return sql.DBStats{MaxOpenConnections: 10, OpenConnections: 3, InUse: 1, Idle: 2, WaitDuration: 1500 * time.Millisecond}
+}
+
+func TestCollector(t *testing.T) {
+	c := metrics.New(stubDB{}, 10*time.Millisecond, time.Second)
+
+	c.Record("DELETE FROM t WHERE id IN (?,?)", 2, 5*time.Millisecond, nil)
+	c.Record("DELETE FROM t WHERE id IN (?,?,?)", 3, 20*time.Millisecond, nil)
+	c.Record("DELETE FROM t WHERE id IN (?)", 0, 2*time.Second, errors.New("Bang"))
+	c.Record(`SELECT "x" FROM t WHERE a = 'b'`, 0, time.Millisecond, nil)
+
+	s := c.Snapshot()
+	expect.Slice(s.Queries).ToHaveLength(t, 2)
+	expect.Any(s.Queries[0]).ToBe(t, metrics.QueryStats{
+		Fingerprint: "DELETE FROM t WHERE id IN (...)",
+		Count:       3,
+		Errors:      1,
+		Rows:        5,
+		Duration:    2025 * time.Millisecond,
+		Buckets:     []metrics.Bucket{{UpperBound: 10 * time.Millisecond, Count: 1}, {UpperBound: time.Second, Count: 2}},
+	})
+	expect.Number(s.Queries[0].Mean()).ToBe(t, 675*time.Millisecond)
+	expect.String(s.Queries[1].Fingerprint).ToBe(t, `SELECT "x" FROM t WHERE a = ?`)
+	expect.Number(s.DB.OpenConnections).ToBe(t, 3)
+
+	buf := &strings.Builder{}
+	err := s.WritePrometheus(buf)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	out := buf.String()
+	expect.String(out).ToContain(t, "# TYPE sqlapi_statements_total counter\n")
+	expect.String(out).ToContain(t, `sqlapi_statements_total{query="DELETE FROM t WHERE id IN (...)"} 3`+"\n")
+	expect.String(out).ToContain(t, `sqlapi_statement_errors_total{query="DELETE FROM t WHERE id IN (...)"} 1`+"\n")
+	expect.String(out).ToContain(t, `sqlapi_statement_rows_total{query="DELETE FROM t WHERE id IN (...)"} 5`+"\n")
+	expect.String(out).ToContain(t, `sqlapi_statement_duration_seconds_bucket{query="DELETE FROM t WHERE id IN (...)",le="0.01"} 1`+"\n")
+	expect.String(out).ToContain(t, `sqlapi_statement_duration_seconds_bucket{query="DELETE FROM t WHERE id IN (...)",le="+Inf"} 3`+"\n")
+	expect.String(out).ToContain(t, `sqlapi_statement_duration_seconds_sum{query="DELETE FROM t WHERE id IN (...)"} 2.025`+"\n")
+	expect.String(out).ToContain(t, `sqlapi_statements_total{query="SELECT \"x\" FROM t WHERE a = ?"} 1`+"\n")
+	expect.String(out).ToContain(t, "# TYPE sqlapi_db_open_connections gauge\nsqlapi_db_open_connections 3\n")
+	expect.String(out).ToContain(t, "# TYPE sqlapi_db_wait_duration_seconds_total counter\nsqlapi_db_wait_duration_seconds_total 1.5\n")
+	expect.String(out).ToContain(t, "# TYPE sqlapi_db_max_lifetime_closed_total counter\n")
+
+	c.Reset()
+	expect.Slice(c.Snapshot().Queries).ToBeEmpty(t)
+}
+
+func TestCollector_ServeHTTP(t *testing.T) {
+	c := metrics.New(nil)
+	c.Record("SELECT 1", 0, time.Millisecond, nil)
+
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	expect.Number(w.Code).ToBe(t, 200)
+	expect.String(w.Header().Get("Content-Type")).ToBe(t, metrics.ContentType)
+	expect.String(w.Body.String()).ToContain(t, `sqlapi_statements_total{query="SELECT ?"} 1`)
+	expect.String(w.Body.String()).Not().ToContain(t, "sqlapi_db_")
+}
@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContentType is the media type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// ServeHTTP writes a snapshot of the statistics in the Prometheus text exposition format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	if err := c.Snapshot().WritePrometheus(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// WritePrometheus writes the snapshot in the Prometheus text exposition format. The statement
+// metrics are labelled with the query fingerprint; their names all start with sqlapi_statement.
+// The connection pool statistics have names that start with sqlapi_db.
+func (s Snapshot) WritePrometheus(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	header(bw, "sqlapi_statements_total", "counter", "The number of statements executed.")
+	for _, q := range s.Queries {
+		fmt.Fprintf(bw, "sqlapi_statements_total{query=%s} %d\n", label(q.Fingerprint), q.Count)
+	}
+
+	header(bw, "sqlapi_statement_errors_total", "counter", "The number of statements that failed.")
+	for _, q := range s.Queries {
+		fmt.Fprintf(bw, "sqlapi_statement_errors_total{query=%s} %d\n", label(q.Fingerprint), q.Errors)
+	}
+
+	header(bw, "sqlapi_statement_rows_total", "counter", "The number of rows affected by statements.")
+	for _, q := range s.Queries {
+		fmt.Fprintf(bw, "sqlapi_statement_rows_total{query=%s} %d\n", label(q.Fingerprint), q.Rows)
+	}
+
+	header(bw, "sqlapi_statement_duration_seconds", "histogram", "The time taken by statements.")
+	for _, q := range s.Queries {
+		fp := label(q.Fingerprint)
+		for _, b := range q.Buckets {
+			fmt.Fprintf(bw, "sqlapi_statement_duration_seconds_bucket{query=%s,le=\"%s\"} %d\n", fp, seconds(b.UpperBound), b.Count)
+		}
+		fmt.Fprintf(bw, "sqlapi_statement_duration_seconds_bucket{query=%s,le=\"+Inf\"} %d\n", fp, q.Count)
+		fmt.Fprintf(bw, "sqlapi_statement_duration_seconds_sum{query=%s} %s\n", fp, seconds(q.Duration))
+		fmt.Fprintf(bw, "sqlapi_statement_duration_seconds_count{query=%s} %d\n", fp, q.Count)
+	}
+
+	if s.DB != nil {
+		writeDBStats(bw, s.DB)
+	}
+
+	return bw.Flush()
+}
+
+// writeDBStats writes the connection pool statistics. The current state of the pool is given by
+// gauges; the cumulative statistics are counters, so their names end in _total.
+func writeDBStats(w io.Writer, stats *sql.DBStats) {
+	metrics := []struct {
+		name, kind, help, value string
+	}{
+		{"max_open_connections", "gauge", "The maximum number of open connections to the database.", strconv.Itoa(stats.MaxOpenConnections)},
+		{"open_connections", "gauge", "The number of established connections, both in use and idle.", strconv.Itoa(stats.OpenConnections)},
+		{"in_use_connections", "gauge", "The number of connections currently in use.", strconv.Itoa(stats.InUse)},
+		{"idle_connections", "gauge", "The number of idle connections.", strconv.Itoa(stats.Idle)},
+		{"wait_count_total", "counter", "The total number of connections waited for.", strconv.FormatInt(stats.WaitCount, 10)},
+		{"wait_duration_seconds_total", "counter", "The total time blocked waiting for a new connection.", seconds(stats.WaitDuration)},
+		{"max_idle_closed_total", "counter", "The total number of connections closed due to the idle limit.", strconv.FormatInt(stats.MaxIdleClosed, 10)},
+		{"max_idle_time_closed_total", "counter", "The total number of connections closed due to the idle time limit.", strconv.FormatInt(stats.MaxIdleTimeClosed, 10)},
+		{"max_lifetime_closed_total", "counter", "The total number of connections closed due to the lifetime limit.", strconv.FormatInt(stats.MaxLifetimeClosed, 10)},
+	}
+
+	for _, m := range metrics {
+		header(w, "sqlapi_db_"+m.name, m.kind, m.help)
+		fmt.Fprintf(w, "sqlapi_db_%s %s\n", m.name, m.value)
+	}
+}
+
+func header(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func label(s string) string {
+	return `"` + labelEscaper.Replace(s) + `"`
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'g', -1, 64)
+}
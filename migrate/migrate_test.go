@@ -0,0 +1,189 @@
+package migrate_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5/tracelog"
+	_ "github.com/lib/pq"
+	_ "github.com/marcboeker/go-duckdb"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/rickb777/expect"
+	"github.com/rickb777/sqlapi"
+	"github.com/rickb777/sqlapi/migrate"
+	"github.com/rickb777/sqlapi/support/testenv"
+	_ "modernc.org/sqlite"
+)
+
+var gdb sqlapi.SqlDB
+
+const insertPeople = `INSERT INTO mig_people (id, name) VALUES (1, 'Alice; A.');
+INSERT INTO mig_people (id, name) VALUES (2, 'Bob');
+`
+
+var files = fstest.MapFS{
+	"0001_create_people.up.sql":   {Data: []byte("CREATE TABLE mig_people (id integer primary key, name varchar(40))")},
+	"0001_create_people.down.sql": {Data: []byte("DROP TABLE mig_people")},
+	"0002_add_email.up.sql":       {Data: []byte("ALTER TABLE mig_people ADD COLUMN email varchar(100)")},
+	"0002_add_email.down.sql":     {Data: []byte("ALTER TABLE mig_people DROP COLUMN email")},
+	"0005_insert_people.up.sql":   {Data: []byte(insertPeople)},
+	"0005_insert_people.down.sql": {Data: []byte("DELETE FROM mig_people")},
+	"README.md":                   {Data: []byte("ignored")},
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := migrate.Load(files)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Slice(migrations).ToHaveLength(t, 3)
+	expect.Number(migrations[0].Version).ToBe(t, 1)
+	expect.String(migrations[0].Name).ToBe(t, "create_people")
+	expect.String(migrations[2].Up).ToBe(t, insertPeople)
+	expect.String(migrations[2].String()).ToBe(t, "5_insert_people")
+	expect.String(migrations[0].Checksum()).ToHaveLength(t, 64)
+}
+
+func TestLoad_errors(t *testing.T) {
+	cases := []fstest.MapFS{
+		{"0001_a.sql": {}},
+		{"x_a.up.sql": {}},
+		{"0001_a.down.sql": {}},
+		{"0001_a.up.sql": {Data: []byte("x")}, "0001_b.down.sql": {}},
+	}
+
+	for _, c := range cases {
+		_, err := migrate.Load(c)
+		expect.Error(err).I(c).ToHaveOccurred(t)
+	}
+}
+
+func TestUpDownTo(t *testing.T) {
+	ctx := context.Background()
+	dropTables(ctx)
+
+	migrations, err := migrate.Load(files)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	m := migrate.New(gdb, migrations).WithTableName("mig_versions")
+
+	//---------- up ----------
+
+	n, err := m.Up(ctx)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Number(n).ToBe(t, 3)
+	expect.Number(count(t, "SELECT count(*) FROM mig_people")).ToBe(t, 2)
+
+	n, err = m.Up(ctx)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Number(n).ToBe(t, 0)
+
+	//---------- down ----------
+
+	n, err = m.Down(ctx, 1)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Number(n).ToBe(t, 1)
+	expect.Number(count(t, "SELECT count(*) FROM mig_people")).ToBe(t, 0)
+	expect.Slice(appliedVersions(t, m)).ToBe(t, 1, 2)
+
+	//---------- to ----------
+
+	n, err = m.To(ctx, 1)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Number(n).ToBe(t, 1)
+	expect.Slice(appliedVersions(t, m)).ToBe(t, 1)
+
+	n, err = m.To(ctx, 5)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Number(n).ToBe(t, 2)
+	expect.Slice(appliedVersions(t, m)).ToBe(t, 1, 2, 5)
+
+	//---------- status ----------
+
+	status, err := m.Status(ctx)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Slice(status).ToHaveLength(t, 3)
+	expect.Bool(status[0].Applied).ToBeTrue(t)
+	expect.Bool(status[0].AppliedAt.IsZero()).ToBeFalse(t)
+	expect.Bool(status[0].Modified).ToBeFalse(t)
+
+	//---------- modified migration ----------
+
+	migrations[1].Up = "ALTER TABLE mig_people ADD COLUMN email varchar(200)"
+	modified := migrate.New(gdb, migrations[:2]).WithTableName("mig_versions")
+
+	status, err = modified.Status(ctx)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Bool(status[1].Modified).ToBeTrue(t)
+	expect.Bool(status[2].Missing).ToBeTrue(t)
+
+	_, err = modified.Up(ctx)
+	expect.Error(err).ToHaveOccurred(t)
+
+	//---------- all the way down ----------
+
+	_, err = m.Down(ctx, -1)
+	expect.Error(err).ToContain(t, "at least 1")
+
+	n, err = m.Down(ctx, 10)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Number(n).ToBe(t, 3)
+	expect.Slice(appliedVersions(t, m)).ToBeEmpty(t)
+}
+
+func TestLock(t *testing.T) {
+	ctx := context.Background()
+	dropTables(ctx)
+
+	m := migrate.New(gdb, nil).WithTableName("mig_versions").WithLockTimeout(0)
+
+	_, err := m.Status(ctx) // creates the tables
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	_, err = gdb.Exec(ctx, "INSERT INTO mig_versions_lock (id, locked_at) VALUES (1, 'now')")
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	_, err = m.Up(ctx)
+	expect.Error(err).ToHaveOccurred(t)
+
+	expect.Error(m.ForceUnlock(ctx)).Not().ToHaveOccurred(t)
+
+	_, err = m.Up(ctx)
+	expect.Error(err).Not().ToHaveOccurred(t)
+}
+
+//-------------------------------------------------------------------------------------------------
+
+func dropTables(ctx context.Context) {
+	for _, table := range []string{"mig_people", "mig_versions", "mig_versions_lock"} {
+		gdb.Exec(ctx, "DROP TABLE IF EXISTS "+table)
+	}
+}
+
+func count(t *testing.T, query string) int64 {
+	var n int64
+	err := gdb.QueryRow(context.Background(), query).Scan(&n)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	return n
+}
+
+func appliedVersions(t *testing.T, m *migrate.Migrator) []int64 {
+	status, err := m.Status(context.Background())
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	var versions []int64
+	for _, s := range status {
+		if s.Applied {
+			versions = append(versions, s.Version)
+		}
+	}
+	return versions
+}
+
+func TestMain(m *testing.M) {
+	testenv.SetDefaultDbDriver("sqlite3")
+	testenv.Shebang(m, func(lgr tracelog.Logger, logLevel tracelog.LogLevel, tries int) (err error) {
+		gdb, err = sqlapi.ConnectEnv(context.Background(), lgr, logLevel, tries)
+		return err
+	})
+}
@@ -2,6 +2,7 @@ package constraint_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/jackc/pgx/v5/tracelog"
@@ -25,7 +26,7 @@ func TestPgxCheckConstraint(t *testing.T) {
 	persons := vanilla.NewRecordTable("persons", gdb).WithPrefix("constraint_").WithConstraint(cc0)
 	fkc := persons.Constraints()[0]
 	s := fkc.ConstraintSql(quote.AnsiQuoter, persons.Name(), 0)
-	expect.String(s).I(s).ToBe(t, `CONSTRAINT "constraint_persons_c0" CHECK (role < 3)`)
+	expect.String(s).I(s).ToBe(t, `CONSTRAINT "constraint_persons_cc8b13ec6" CHECK (role < 3)`)
 }
 
 func TestPgxForeignKeyConstraint_withParentColumn(t *testing.T) {
@@ -38,7 +39,7 @@ func TestPgxForeignKeyConstraint_withParentColumn(t *testing.T) {
 	persons := vanilla.NewRecordTable("persons", gdb).WithPrefix("constraint_").WithConstraint(fkc0)
 	fkc := persons.Constraints()[0]
 	s := fkc.ConstraintSql(quote.AnsiQuoter, persons.Name(), 0)
-	expect.String(s).I(s).ToBe(t, `CONSTRAINT "constraint_persons_c0" foreign key ("addresspk") references "constraint_addresses" ("identity") on update restrict on delete cascade`)
+	expect.String(s).I(s).ToBe(t, `CONSTRAINT "constraint_persons_c2cc55c95" foreign key ("addresspk") references "constraint_addresses" ("identity") on update restrict on delete cascade`)
 }
 
 func TestPgxForeignKeyConstraint_withoutParentColumn_withoutQuotes(t *testing.T) {
@@ -51,7 +52,7 @@ func TestPgxForeignKeyConstraint_withoutParentColumn_withoutQuotes(t *testing.T)
 	persons := vanilla.NewRecordTable("persons", gdb).WithPrefix("constraint_").WithConstraint(fkc0)
 	fkc := persons.Constraints().FkConstraints()[0]
 	s := fkc.ConstraintSql(quote.NoQuoter, persons.Name(), 0)
-	expect.String(s).I(s).ToBe(t, `CONSTRAINT constraint_persons_c0 foreign key (addresspk) references constraint_addresses on update restrict on delete cascade`)
+	expect.String(s).I(s).ToBe(t, `CONSTRAINT constraint_persons_c43b1d68b foreign key (addresspk) references constraint_addresses on update restrict on delete cascade`)
 }
 
 func TestPgxIdsUsedAsForeignKeys(t *testing.T) {
@@ -78,6 +79,47 @@ func TestPgxIdsUsedAsForeignKeys(t *testing.T) {
 	expect.Slice(m2.Slice()).ToContainAll(t, aid3, aid4)
 }
 
+func TestPgxCompositeFkConstraint(t *testing.T) {
+	fkc0 := constraint.CompositeFkConstraintOn("org", "code").
+		RefersTo("groups", "org", "code").
+		OnDelete(constraint.Cascade)
+
+	members := vanilla.NewRecordTable("members", gdb).WithPrefix("constraint_").WithConstraint(fkc0)
+	fkc := members.Constraints().CompositeFkConstraints()[0]
+	s := fkc.ConstraintSql(quote.AnsiQuoter, members.Name(), 0)
+	expect.String(s).Info(s).ToBe(t, `CONSTRAINT "constraint_members_c9ef0782e" foreign key ("org", "code") references "constraint_groups" ("org", "code") on delete cascade`)
+
+	expect.String(fkc.GoString()).ToBe(t, `constraint.CompositeFkConstraint{[]string{"org", "code"}, constraint.CompositeReference{"groups", []string{"org", "code"}}, "", "cascade"}`)
+}
+
+func TestPgxKeysUsedAsForeignKeys(t *testing.T) {
+	insertCompositeFixtures(t, gdb)
+
+	fkc0 := constraint.CompositeFkConstraintOn("org", "code").RefersTo("groups", "org", "code")
+	members := vanilla.NewRecordTable("members", gdb).WithPrefix("constraint_").WithConstraint(fkc0)
+	rel := members.Constraints().CompositeFkConstraints()[0].RelationshipWith(members.Name())
+
+	used, err := rel.KeysUsedAsForeignKeys(members)
+	expect.Error(err).ToBeNil(t)
+	expect.Slice(tupleStrings(used)).ToContainAll(t, "1/2", "2/1")
+	expect.Slice(used).ToHaveLength(t, 2)
+
+	unused, err := rel.KeysUnusedAsForeignKeys(members)
+	expect.Error(err).ToBeNil(t)
+	expect.Slice(tupleStrings(unused)).ToBe(t, "1/1")
+
+	_, err = constraint.CompositeRelationship{}.KeysUsedAsForeignKeys(members)
+	expect.Error(err).ToContain(t, "requires the column names to be specified")
+}
+
+func tupleStrings(tuples []constraint.Tuple) []string {
+	ss := make([]string, len(tuples))
+	for i, tuple := range tuples {
+		ss[i] = fmt.Sprintf("%v/%v", tuple...)
+	}
+	return ss
+}
+
 func TestPgxFkConstraintOfField(t *testing.T) {
 	i64 := schema.Type{Name: "int64", Base: types.Int64}
 	field := &schema.Field{
@@ -102,6 +144,26 @@ func TestPgxFkConstraintOfField(t *testing.T) {
 	})
 }
 
+func TestPgxConstraintsOf(t *testing.T) {
+	i64 := schema.Type{Name: "int64", Base: types.Int64}
+	table := &schema.TableDescription{
+		Name: "persons",
+		Fields: schema.FieldList{
+			{Node: schema.Node{Name: "Id", Type: i64}, SqlName: "id", Tags: &types.Tag{Primary: true}},
+			{Node: schema.Node{Name: "Age", Type: i64}, SqlName: "age", Tags: &types.Tag{Check: "age >= 0"}},
+			{Node: schema.Node{Name: "Cat", Type: i64}, SqlName: "cat", Tags: &types.Tag{ForeignKey: "something", Check: "cat > 0"}},
+		},
+	}
+
+	cc := constraint.ConstraintsOf(table)
+	expect.Slice(cc).ToBe(t,
+		constraint.CheckConstraint{Expression: "age >= 0"},
+		constraint.FkConstraint{ForeignKeyColumn: "cat", Parent: constraint.Reference{TableName: "something"}},
+		constraint.CheckConstraint{Expression: "cat > 0"},
+	)
+	expect.String(cc[0].GoString()).ToBe(t, `constraint.CheckConstraint{"age >= 0"}`)
+}
+
 //-------------------------------------------------------------------------------------------------
 
 func TestMain(m *testing.M) {
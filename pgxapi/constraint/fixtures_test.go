@@ -58,3 +58,32 @@ const address4 = `INSERT INTO constraint_addresses (xlines, postcode) VALUES ('4
 const person1a = `INSERT INTO constraint_persons (name, addressid) VALUES ('John Brown', %d)`
 const person1b = `INSERT INTO constraint_persons (name, addressid) VALUES ('Mary Brown', %d)`
 const person2a = `INSERT INTO constraint_persons (name, addressid) VALUES ('Anne Bollin', %d)`
+
+// these tables use composite keys; the DDL is the same for all dialects.
+var createCompositeTablesSql = []string{
+	`DROP TABLE IF EXISTS constraint_members`,
+	`DROP TABLE IF EXISTS constraint_groups`,
+
+	`CREATE TABLE constraint_groups (
+	org       int not null,
+	code      int not null,
+	title     text,
+	primary key (org, code)
+	)`,
+
+	`CREATE TABLE constraint_members (
+	id        int primary key,
+	org       int not null,
+	code      int not null
+	)`,
+
+	`INSERT INTO constraint_groups (org, code, title) VALUES (1, 1, 'one-one'), (1, 2, 'one-two'), (2, 1, 'two-one')`,
+	`INSERT INTO constraint_members (id, org, code) VALUES (1, 1, 2), (2, 2, 1), (3, 1, 2)`,
+}
+
+func insertCompositeFixtures(t *testing.T, d pgxapi.Execer) {
+	for _, s := range createCompositeTablesSql {
+		_, err := d.Exec(context.Background(), s)
+		expect.Error(err).ToBeNil(t)
+	}
+}
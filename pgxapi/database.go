@@ -30,3 +30,35 @@ func ListTables(ex Execer, re *regexp.Regexp) ([]string, error) {
 	}
 	return ss, rows.Err()
 }
+
+// ExecScript executes a script containing any number of statements. The script is split
+// into statements using the rules of the dialect (see driver.Dialect.SplitStatements) and
+// the statements are executed in order, each without any arguments. The statements are
+// executed verbatim: the number of placeholders is not checked and '?' is not replaced, so
+// operators such as the PostgreSQL jsonb '?', '?|' and '?&' can be used.
+//
+// Execution stops at the first statement that fails; the error is then a *ScriptError that
+// identifies the statement. The statements that have already been executed are not undone,
+// so pass a transaction as the Execer if the script must succeed or fail as a whole.
+func ExecScript(ctx context.Context, ex Execer, script string) error {
+	for i, s := range ex.Dialect().SplitStatements(script) {
+		if _, err := execVerbatim(ctx, ex, s.SQL); err != nil {
+			return &ScriptError{Index: i + 1, Line: s.Line, Statement: s.SQL, Err: err}
+		}
+	}
+	return nil
+}
+
+// verbatimExecer is implemented by the Execers in this package.
+type verbatimExecer interface {
+	execVerbatim(ctx context.Context, query string) (int64, error)
+}
+
+// execVerbatim executes a statement without arguments, without checking or replacing its
+// placeholders if the Execer allows this.
+func execVerbatim(ctx context.Context, ex Execer, query string) (int64, error) {
+	if ve, ok := ex.(verbatimExecer); ok {
+		return ve.execVerbatim(ctx, query)
+	}
+	return ex.Exec(ctx, query)
+}
@@ -10,9 +10,11 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/tracelog"
 	"github.com/rickb777/expect"
 	"github.com/rickb777/sqlapi"
+	"github.com/rickb777/sqlapi/driver"
 	"github.com/rickb777/sqlapi/pgxapi/logadapter"
 	"github.com/rickb777/sqlapi/support/testenv"
 )
@@ -93,6 +95,26 @@ func TestQueryContext(t *testing.T) {
 	expect.Bool(rows.Next()).Not().ToBeTrue(t)
 }
 
+func TestPgxBindOptions(t *testing.T) {
+	sh := &shim{di: driver.Pgx()}
+
+	q, args, err := sh.bind("select xlines from pfx_addresses where id=?", []any{pgx.QueryExecModeSimpleProtocol, 1})
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.String(q).ToBe(t, "select xlines from pfx_addresses where id=$1")
+	expect.Slice(args).ToBe(t, any(pgx.QueryExecModeSimpleProtocol), any(1))
+
+	_, _, err = sh.bind("select xlines from pfx_addresses where id=?", []any{pgx.QueryExecModeSimpleProtocol, 1, 2})
+	var pce *PlaceholderCountError
+	expect.Bool(errors.As(err, &pce)).ToBeTrue(t)
+	expect.Number(pce.Actual).ToBe(t, 2)
+
+	named := pgx.NamedArgs{"id": 1}
+	q, args, err = sh.bind("select xlines from pfx_addresses where id=@id", []any{named})
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.String(q).ToBe(t, "select xlines from pfx_addresses where id=@id")
+	expect.Slice(args).ToHaveLength(t, 1)
+}
+
 func TestSingleConnQuery(t *testing.T) {
 	ctx := context.Background()
 	_, aid2, _, _ := insertFixtures(t, gdb)
@@ -114,6 +136,39 @@ func TestSingleConnQuery(t *testing.T) {
 	expect.Error(e2).Not().ToHaveOccurred(t)
 }
 
+func TestPgxExecScript(t *testing.T) {
+	ctx := context.Background()
+	insertFixtures(t, gdb)
+
+	const script = `-- two more addresses
+INSERT INTO pfx_addresses (xlines, postcode) VALUES ('1 Semi;Colon Row', 'SC1 1AA');
+
+/* the second; */
+INSERT INTO pfx_addresses (xlines, postcode) VALUES ('2 Semi;Colon Row', 'SC1 1AB');
+`
+	err := ExecScript(ctx, gdb, script)
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	var count int
+	err = gdb.QueryRow(ctx, "select count(1) from pfx_addresses where postcode like 'SC1%'").Scan(&count)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Number(count).ToBe(t, 2)
+
+	err = ExecScript(ctx, gdb, "DELETE FROM pfx_addresses WHERE postcode = 'SC1 1AA';\n\nDELETE FROM pfx_no_such_table;\nSELECT 1;")
+	var se *ScriptError
+	expect.Bool(errors.As(err, &se)).ToBeTrue(t)
+	expect.Number(se.Index).ToBe(t, 2)
+	expect.Number(se.Line).ToBe(t, 3)
+	expect.String(se.Statement).ToBe(t, "DELETE FROM pfx_no_such_table")
+	expect.Error(se.Err).ToHaveOccurred(t)
+
+	// the statements are executed verbatim, so the jsonb operators are not taken to be placeholders
+	err = ExecScript(ctx, gdb, `SELECT '{"a": 1}'::jsonb ? 'a';
+SELECT '{"a": 1}'::jsonb ?| array['a', 'b'];
+SELECT '{"a": 1}'::jsonb ?& array['a'];`)
+	expect.Error(err).Not().ToHaveOccurred(t)
+}
+
 func TestTransactCommitUsingInsert(t *testing.T) {
 	ctx := context.Background()
 	insertFixtures(t, gdb)
@@ -0,0 +1,225 @@
+// Package dump exports the rows of tables as scripts of INSERT statements and loads such
+// scripts into a database. The scripts can be written for a different dialect from the
+// source database, e.g. to take a snapshot of a PostgreSQL database and replay it into
+// SQLite for local development.
+//
+//	err := dump.New(db).WithDialect(driver.Sqlite()).WithBatchSize(100).Dump(ctx, w, tables...)
+//
+// This is the pgx counterpart of the github.com/rickb777/sqlapi/dump package.
+//
+// The tables must already exist in the destination database, e.g. having been created using
+// the ddl package. The tables should be listed with parent tables before their children (see
+// ddl.Database.Tables) so that the foreign keys are satisfied as the script is loaded.
+//
+// For SQL Server, each table's INSERT statements are bracketed by SET IDENTITY_INSERT if the
+// table has an identity column; load such a script using the sqlapi dump package.
+package dump
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rickb777/sqlapi/driver"
+	"github.com/rickb777/sqlapi/pgxapi"
+	"github.com/rickb777/where/dialect"
+)
+
+// maxSqlServerRows is the most rows that SQL Server allows in one VALUES list.
+const maxSqlServerRows = 1000
+
+// Dumper writes the rows of tables as INSERT statements.
+type Dumper struct {
+	ex    pgxapi.Execer
+	di    driver.Dialect
+	batch int
+}
+
+// New creates a dumper that reads from a database. By default, the script is written for
+// the database's own dialect, with one INSERT statement per row.
+func New(ex pgxapi.Execer) *Dumper {
+	return &Dumper{ex: ex, di: ex.Dialect(), batch: 1}
+}
+
+// WithDialect sets the dialect of the script, which may differ from that of the database.
+// The result is a modified copy of the dumper; the original is unchanged.
+func (d *Dumper) WithDialect(di driver.Dialect) *Dumper {
+	cp := *d
+	cp.di = di
+	return &cp
+}
+
+// WithBatchSize sets the number of rows in each INSERT statement. Batches of more than one
+// row use the multi-row VALUES form, which is quicker to load. For SQL Server, the batches
+// are limited to 1000 rows, which is the most it allows.
+// The result is a modified copy of the dumper; the original is unchanged.
+func (d *Dumper) WithBatchSize(n int) *Dumper {
+	cp := *d
+	cp.batch = max(n, 1)
+	return &cp
+}
+
+// Dump writes all the rows of the tables, in the order the tables are listed. Each table
+// is preceded by a comment giving its name.
+func (d *Dumper) Dump(ctx context.Context, w io.Writer, tables ...pgxapi.TableName) error {
+	for _, name := range tables {
+		if err := d.dumpTable(ctx, w, name); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (d *Dumper) dumpTable(ctx context.Context, w io.Writer, name pgxapi.TableName) error {
+	rows, err := d.ex.Query(ctx, "SELECT * FROM "+d.ex.Dialect().Quoter().Quote(name.String()))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if _, err = fmt.Fprintf(w, "\n-- %s\n", name); err != nil {
+		return err
+	}
+
+	fields := rows.FieldDescriptions()
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = f.Name
+	}
+
+	st := &statement{di: d.di, table: d.di.Quoter().Quote(name.String()), batch: d.batch}
+	if d.di.Index() == dialect.SqlServer {
+		st.batch = min(st.batch, maxSqlServerRows)
+		if _, err = fmt.Fprintln(w, identityInsert(name, st.table, "ON")); err != nil {
+			return err
+		}
+	}
+
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return err
+		}
+
+		for i, v := range values {
+			if values[i], err = literalValue(v); err != nil {
+				return fmt.Errorf("%s: %w", columns[i], err)
+			}
+		}
+
+		if err = st.add(w, columns, values); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	if err = st.flush(w); err != nil {
+		return err
+	}
+
+	if d.di.Index() == dialect.SqlServer {
+		_, err = fmt.Fprintln(w, identityInsert(name, st.table, "OFF"))
+	}
+	return err
+}
+
+// identityInsert gets the SQL Server statement that allows (or disallows) values to be
+// inserted into a table's identity column. It has no effect if there is no identity column.
+func identityInsert(name pgxapi.TableName, quotedTable, onOff string) string {
+	return fmt.Sprintf("IF OBJECTPROPERTY(OBJECT_ID('%s'), 'TableHasIdentity') = 1 SET IDENTITY_INSERT %s %s;",
+		strings.ReplaceAll(name.String(), "'", "''"), quotedTable, onOff)
+}
+
+// literalValue converts the values that pgx decodes into Go types that have no literal form,
+// i.e. UUIDs and JSON documents.
+func literalValue(v any) (any, error) {
+	switch x := v.(type) {
+	case [16]byte:
+		return fmt.Sprintf("%x-%x-%x-%x-%x", x[0:4], x[4:6], x[6:8], x[8:10], x[10:]), nil
+	case map[string]any, []any:
+		b, err := json.Marshal(x)
+		return string(b), err
+	}
+	return v, nil
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// statement accumulates the rows of one INSERT statement.
+type statement struct {
+	di     driver.Dialect
+	table  string // quoted
+	batch  int
+	prefix string
+	rows   []string
+}
+
+func (st *statement) add(w io.Writer, columns []string, values []any) error {
+	if st.prefix == "" {
+		b := &strings.Builder{}
+		fmt.Fprintf(b, "INSERT INTO %s (", st.table)
+		for i, c := range columns {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(st.di.Quoter().Quote(c))
+		}
+		b.WriteString(") VALUES")
+		st.prefix = b.String()
+	}
+
+	b := &strings.Builder{}
+	b.WriteByte('(')
+	for i, v := range values {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		lit, err := st.di.Literal(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", columns[i], err)
+		}
+		b.WriteString(lit)
+	}
+	b.WriteByte(')')
+	st.rows = append(st.rows, b.String())
+
+	if len(st.rows) >= st.batch {
+		return st.flush(w)
+	}
+	return nil
+}
+
+func (st *statement) flush(w io.Writer) error {
+	if len(st.rows) == 0 {
+		return nil
+	}
+
+	var err error
+	if st.batch == 1 {
+		_, err = fmt.Fprintf(w, "%s %s;\n", st.prefix, st.rows[0])
+	} else {
+		_, err = fmt.Fprintf(w, "%s\n\t%s;\n", st.prefix, strings.Join(st.rows, ",\n\t"))
+	}
+	st.rows = st.rows[:0]
+	return err
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// Load executes a script, such as one written by Dump, using pgxapi.ExecScript. Use a
+// transaction as the Execer if the script must be loaded completely or not at all.
+//
+// Auto-increment sequences are not adjusted, so for PostgreSQL they may need to be reset
+// after loading rows that include the primary keys.
+func Load(ctx context.Context, ex pgxapi.Execer, r io.Reader) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return pgxapi.ExecScript(ctx, ex, string(b))
+}
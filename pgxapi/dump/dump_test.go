@@ -0,0 +1,88 @@
+package dump_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/tracelog"
+	"github.com/rickb777/expect"
+	"github.com/rickb777/sqlapi/driver"
+	"github.com/rickb777/sqlapi/pgxapi"
+	"github.com/rickb777/sqlapi/pgxapi/dump"
+	"github.com/rickb777/sqlapi/support/testenv"
+)
+
+var gdb pgxapi.SqlDB
+
+var notes = pgxapi.TableName{Prefix: "dump_", Name: "notes"}
+
+func createNotes(t *testing.T) {
+	t.Helper()
+	ctx := context.Background()
+
+	script := `DROP TABLE IF EXISTS dump_notes;
+CREATE TABLE dump_notes (id integer primary key, title varchar(100), body text, data bytea, score float);
+INSERT INTO dump_notes VALUES (1, 'It''s; here', 'a\b', NULL, 1.5);
+INSERT INTO dump_notes VALUES (2, '-- not a comment', NULL, '\x0001feff'::bytea, -2);
+INSERT INTO dump_notes VALUES (3, '/* nor this */', '$$', NULL, 0);`
+
+	err := pgxapi.ExecScript(ctx, gdb, script)
+	expect.Error(err).Not().ToHaveOccurred(t)
+}
+
+func TestPgxDump_format(t *testing.T) {
+	createNotes(t)
+	b := &strings.Builder{}
+
+	err := dump.New(gdb).WithDialect(driver.Sqlite()).WithBatchSize(2).Dump(context.Background(), b, notes)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.String(b.String()).ToContain(t, `
+-- dump_notes
+INSERT INTO "dump_notes" ("id", "title", "body", "data", "score") VALUES
+	(1, 'It''s; here', 'a\b', NULL, 1.5),
+	(2, '-- not a comment', NULL, X'0001feff', -2);
+`)
+
+	b.Reset()
+	err = dump.New(gdb).WithDialect(driver.SqlServer()).Dump(context.Background(), b, notes)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.String(b.String()).ToContain(t, `
+-- dump_notes
+IF OBJECTPROPERTY(OBJECT_ID('dump_notes'), 'TableHasIdentity') = 1 SET IDENTITY_INSERT "dump_notes" ON;
+`)
+	expect.String(b.String()).ToContain(t, `SET IDENTITY_INSERT "dump_notes" OFF;
+`)
+}
+
+func TestPgxDumpAndLoad(t *testing.T) {
+	ctx := context.Background()
+	createNotes(t)
+
+	buf := &bytes.Buffer{}
+	err := dump.New(gdb).WithBatchSize(10).Dump(ctx, buf, notes)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	script := buf.String()
+
+	_, err = gdb.Exec(ctx, "DELETE FROM dump_notes")
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	err = dump.Load(ctx, gdb, buf)
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	again := &strings.Builder{}
+	err = dump.New(gdb).WithBatchSize(10).Dump(ctx, again, notes)
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.String(again.String()).ToBe(t, script)
+}
+
+//-------------------------------------------------------------------------------------------------
+
+func TestMain(m *testing.M) {
+	testenv.SetDefaultDbDriver("pgx")
+	testenv.Shebang(m, func(lgr tracelog.Logger, logLevel tracelog.LogLevel, tries int) (err error) {
+		gdb, err = pgxapi.ConnectEnv(context.Background(), lgr, logLevel, tries)
+		return err
+	})
+}
@@ -0,0 +1,32 @@
+package pgxapi
+
+import "fmt"
+
+// PlaceholderCountError is returned when the number of placeholders in a query does not match
+// the number of arguments supplied with it. This check is made before the query is sent to the
+// database, unless it has been disabled via SqlDB.CheckArgCount.
+type PlaceholderCountError struct {
+	Query    string
+	Expected int // the number of placeholders
+	Actual   int // the number of arguments
+}
+
+func (e *PlaceholderCountError) Error() string {
+	return fmt.Sprintf("query expects %d arguments but got %d: %s", e.Expected, e.Actual, e.Query)
+}
+
+// ScriptError is returned by ExecScript when one of the statements in a script fails.
+type ScriptError struct {
+	Index     int // the position of the statement in the script, counting from 1
+	Line      int // the line in the script where the statement starts, counting from 1
+	Statement string
+	Err       error
+}
+
+func (e *ScriptError) Error() string {
+	return fmt.Sprintf("script statement %d at line %d failed: %v", e.Index, e.Line, e.Err)
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
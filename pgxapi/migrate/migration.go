@@ -0,0 +1,135 @@
+// Package migrate applies numbered SQL migrations to a database and keeps a record of which
+// have been applied.
+//
+// The migrations are read from an fs.FS, so they can be embedded in the program, e.g.
+//
+//	//go:embed migrations/*.sql
+//	var files embed.FS
+//
+//	sub, _ := fs.Sub(files, "migrations")
+//	migrations, err := migrate.Load(sub)
+//	...
+//	n, err := migrate.New(db, migrations).Up(ctx)
+//
+// Each migration is a pair of files named like "0001_create_users.up.sql" and
+// "0001_create_users.down.sql". The leading number is the version; the versions determine the
+// order of the migrations and need not be contiguous. The down file is optional, but without
+// it the migration cannot be reverted.
+//
+// The applied versions are recorded in a bookkeeping table, together with a checksum of each
+// up script so that scripts that are modified after being applied can be detected. A second
+// table holds a lock that prevents concurrent runners from applying the same migrations.
+//
+// A script may contain several statements; these are executed in turn by pgxapi.ExecScript.
+// Each migration is run in a transaction, along with the update to the bookkeeping table,
+// except for MySQL, which does not support transactional DDL.
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migration is one numbered migration.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string // blank if the migration cannot be reverted
+}
+
+// Checksum gets the SHA-256 checksum of the up script, in hex.
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Up))
+	return hex.EncodeToString(sum[:])
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%d_%s", m.Version, m.Name)
+}
+
+// Load reads the migrations from the top-level directory of a filesystem. Use fs.Sub to
+// read from a sub-directory. Files that do not end in ".sql" are ignored.
+//
+// The migrations are returned in order of their versions. An error is returned if any file
+// name is not in the expected form, if a version is used more than once, or if there is a down
+// file without a corresponding up file.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".sql") {
+			continue
+		}
+
+		version, name, up, err := parseFileName(e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, name)
+		}
+
+		if up {
+			if m.Up != "" {
+				return nil, fmt.Errorf("migration %d has more than one up file", version)
+			}
+			m.Up = string(content)
+		} else {
+			if m.Down != "" {
+				return nil, fmt.Errorf("migration %d has more than one down file", version)
+			}
+			m.Down = string(content)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d has no up file", m.Version)
+		}
+		list = append(list, *m)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// parseFileName splits a name such as "0001_create_users.up.sql" into its parts.
+func parseFileName(file string) (version int64, name string, up bool, err error) {
+	base := strings.TrimSuffix(file, ".sql")
+	switch {
+	case strings.HasSuffix(base, ".up"):
+		base, up = strings.TrimSuffix(base, ".up"), true
+	case strings.HasSuffix(base, ".down"):
+		base = strings.TrimSuffix(base, ".down")
+	default:
+		return 0, "", false, fmt.Errorf("%s: migration file names must end with .up.sql or .down.sql", file)
+	}
+
+	digits, name, _ := strings.Cut(base, "_")
+	version, err = strconv.ParseInt(digits, 10, 64)
+	if err != nil || version < 0 {
+		return 0, "", false, fmt.Errorf("%s: migration file names must start with a version number", file)
+	}
+	return version, name, up, nil
+}
@@ -0,0 +1,397 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/tracelog"
+	"github.com/rickb777/sqlapi/driver"
+	"github.com/rickb777/sqlapi/pgxapi"
+	"github.com/rickb777/sqlapi/schema"
+	"github.com/rickb777/sqlapi/types"
+	"github.com/rickb777/where/dialect"
+)
+
+// DefaultTableName is the default name of the bookkeeping table. The lock table has the
+// same name with a "_lock" suffix.
+const DefaultTableName = "schema_migrations"
+
+// Migrator applies migrations to a database.
+type Migrator struct {
+	db          pgxapi.SqlDB
+	migrations  []Migration
+	table       string
+	lockTimeout time.Duration
+}
+
+// Status describes a migration and whether it has been applied.
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time // zero unless applied
+	Modified  bool      // the up script has changed since it was applied
+	Missing   bool      // the migration was applied but is not among the known migrations
+}
+
+// applied is a row in the bookkeeping table.
+type applied struct {
+	version   int64
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// New creates a migrator for some migrations, which are usually obtained via Load.
+func New(db pgxapi.SqlDB, migrations []Migration) *Migrator {
+	list := make([]Migration, len(migrations))
+	copy(list, migrations)
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+
+	return &Migrator{
+		db:          db,
+		migrations:  list,
+		table:       DefaultTableName,
+		lockTimeout: time.Minute,
+	}
+}
+
+// WithTableName sets the name of the bookkeeping table.
+// The result is a modified copy of the migrator; the original is unchanged.
+func (m *Migrator) WithTableName(name string) *Migrator {
+	cp := *m
+	cp.table = name
+	return &cp
+}
+
+// WithLockTimeout sets how long to wait for another runner to release the lock.
+// The result is a modified copy of the migrator; the original is unchanged.
+func (m *Migrator) WithLockTimeout(d time.Duration) *Migrator {
+	cp := *m
+	cp.lockTimeout = d
+	return &cp
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// Up applies all the migrations that have not yet been applied, in version order. It
+// returns the number applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	return m.run(ctx, func(done map[int64]applied) ([]Migration, []Migration) {
+		return m.pending(done, -1), nil
+	})
+}
+
+// Down reverts the n most recently applied migrations, in reverse version order. It returns
+// the number reverted. n must be at least 1.
+func (m *Migrator) Down(ctx context.Context, n int) (int, error) {
+	if n < 1 {
+		return 0, fmt.Errorf("the number of migrations to revert must be at least 1, not %d", n)
+	}
+
+	return m.run(ctx, func(done map[int64]applied) ([]Migration, []Migration) {
+		list := m.revertible(done, -1)
+		if n < len(list) {
+			list = list[:n]
+		}
+		return nil, list
+	})
+}
+
+// To migrates up or down so that exactly the migrations with versions up to and including
+// the given version are applied. It returns the number of migrations applied or reverted.
+func (m *Migrator) To(ctx context.Context, version int64) (int, error) {
+	return m.run(ctx, func(done map[int64]applied) ([]Migration, []Migration) {
+		return m.pending(done, version), m.revertible(done, version)
+	})
+}
+
+// Status lists all the known migrations and any others that have been applied, in version
+// order.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.createTables(ctx); err != nil {
+		return nil, err
+	}
+
+	done, err := m.readApplied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Status
+	for _, mg := range m.migrations {
+		s := Status{Version: mg.Version, Name: mg.Name}
+		if a, exists := done[mg.Version]; exists {
+			s.Applied = true
+			s.AppliedAt = a.appliedAt
+			s.Modified = a.checksum != mg.Checksum()
+			delete(done, mg.Version)
+		}
+		list = append(list, s)
+	}
+
+	for _, a := range done {
+		list = append(list, Status{Version: a.version, Name: a.name, Applied: true, AppliedAt: a.appliedAt, Missing: true})
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// ForceUnlock removes the lock. This is only needed if a runner has terminated abnormally
+// while holding the lock.
+func (m *Migrator) ForceUnlock(ctx context.Context) error {
+	if err := m.createTables(ctx); err != nil {
+		return err
+	}
+	return m.unlock(ctx)
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// run takes the lock, decides what to apply and revert, then does so.
+func (m *Migrator) run(ctx context.Context, plan func(map[int64]applied) (up, down []Migration)) (n int, err error) {
+	if err = m.createTables(ctx); err != nil {
+		return 0, err
+	}
+
+	if err = m.lock(ctx); err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		if e2 := m.unlock(ctx); err == nil {
+			err = e2
+		}
+	}()
+
+	done, err := m.readApplied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, mg := range m.migrations {
+		if a, exists := done[mg.Version]; exists && a.checksum != mg.Checksum() {
+			return 0, fmt.Errorf("migration %s has been modified since it was applied", mg)
+		}
+	}
+
+	up, down := plan(done)
+
+	for _, mg := range down {
+		if err = m.apply(ctx, mg, false); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	for _, mg := range up {
+		if err = m.apply(ctx, mg, true); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+// pending lists the migrations not yet applied, in version order, up to a limit unless the
+// limit is negative.
+func (m *Migrator) pending(done map[int64]applied, limit int64) []Migration {
+	var list []Migration
+	for _, mg := range m.migrations {
+		if _, exists := done[mg.Version]; !exists && (limit < 0 || mg.Version <= limit) {
+			list = append(list, mg)
+		}
+	}
+	return list
+}
+
+// revertible lists the applied migrations with versions above a limit, in reverse version order.
+func (m *Migrator) revertible(done map[int64]applied, limit int64) []Migration {
+	var list []Migration
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mg := m.migrations[i]
+		if _, exists := done[mg.Version]; exists && mg.Version > limit {
+			list = append(list, mg)
+		}
+	}
+	return list
+}
+
+// apply runs one migration up or down and updates the bookkeeping table.
+func (m *Migrator) apply(ctx context.Context, mg Migration, up bool) error {
+	script := mg.Up
+	if !up {
+		if mg.Down == "" {
+			return fmt.Errorf("migration %s cannot be reverted because it has no down script", mg)
+		}
+		script = mg.Down
+	}
+
+	q := m.db.Dialect().Quoter()
+	record := func(ex pgxapi.Execer) error {
+		if up {
+			_, err := ex.Exec(ctx, fmt.Sprintf("INSERT INTO %s (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)", q.Quote(m.table)),
+				mg.Version, mg.Name, mg.Checksum(), time.Now().UTC().Format(time.RFC3339))
+			return err
+		}
+		_, err := ex.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE version = ?", q.Quote(m.table)), mg.Version)
+		return err
+	}
+
+	start := time.Now()
+	var err error
+
+	if transactionalDDL(m.db.Dialect()) {
+		err = m.db.Transact(ctx, nil, func(tx pgxapi.SqlTx) error {
+			if err := pgxapi.ExecScript(ctx, tx, script); err != nil {
+				return err
+			}
+			return record(tx)
+		})
+	} else {
+		err = pgxapi.ExecScript(ctx, m.db, script)
+		if err == nil {
+			err = record(m.db)
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("migration %s %s: %w", mg, direction(up), err)
+	}
+
+	m.db.Logger().LogT(ctx, tracelog.LogLevelInfo, "Migration", &start, "version", mg.Version, "name", mg.Name, "direction", direction(up))
+	return nil
+}
+
+func direction(up bool) string {
+	if up {
+		return "up"
+	}
+	return "down"
+}
+
+// transactionalDDL is true for dialects in which DDL statements can be rolled back.
+func transactionalDDL(di driver.Dialect) bool {
+	return di.Index() != dialect.Mysql
+}
+
+//-------------------------------------------------------------------------------------------------
+
+func (m *Migrator) readApplied(ctx context.Context) (map[int64]applied, error) {
+	rows, err := m.db.Query(ctx, fmt.Sprintf("SELECT version, name, checksum, applied_at FROM %s", m.db.Dialect().Quoter().Quote(m.table)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	done := make(map[int64]applied)
+	for rows.Next() {
+		var a applied
+		var at string
+		if err = rows.Scan(&a.version, &a.name, &a.checksum, &at); err != nil {
+			return nil, err
+		}
+		a.appliedAt, _ = time.Parse(time.RFC3339, at)
+		done[a.version] = a
+	}
+	return done, rows.Err()
+}
+
+// lock inserts the single row into the lock table, retrying until the lock timeout if another
+// runner holds it.
+func (m *Migrator) lock(ctx context.Context) error {
+	query := fmt.Sprintf("INSERT INTO %s (id, locked_at) VALUES (1, ?)", m.db.Dialect().Quoter().Quote(m.table+"_lock"))
+	deadline := time.Now().Add(m.lockTimeout)
+
+	for {
+		_, err := m.db.Exec(ctx, query, time.Now().UTC().Format(time.RFC3339))
+		if err == nil {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("migrations are locked by another runner (see ForceUnlock): %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func (m *Migrator) unlock(ctx context.Context) error {
+	_, err := m.db.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE id = 1", m.db.Dialect().Quoter().Quote(m.table+"_lock")))
+	return err
+}
+
+// createTables creates the bookkeeping and lock tables if they do not exist.
+func (m *Migrator) createTables(ctx context.Context) error {
+	existing, err := m.listTables(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, table := range m.bookkeepingTables() {
+		if existing[table.Name] {
+			continue
+		}
+
+		di := m.db.Dialect()
+		ddl := fmt.Sprintf("CREATE TABLE %s (%s)%s", di.Quoter().Quote(table.Name),
+			strings.Join(driver.ColumnDefinitions(di, table.Name, table), ", "), di.CreateTableSettings())
+
+		if _, err = m.db.Exec(ctx, ddl); err != nil {
+			// another runner may have just created it
+			if again, e2 := m.listTables(ctx); e2 != nil || !again[table.Name] {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) listTables(ctx context.Context) (map[string]bool, error) {
+	rows, err := m.db.Query(ctx, m.db.Dialect().ShowTables())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		existing[name] = true
+	}
+	return existing, rows.Err()
+}
+
+func (m *Migrator) bookkeepingTables() []*schema.TableDescription {
+	i64 := schema.Type{Name: "int64", Base: types.Int64}
+	str := schema.Type{Name: "string", Base: types.String}
+
+	version := &schema.Field{Node: schema.Node{Name: "Version", Type: i64}, SqlName: "version", Tags: &types.Tag{Primary: true}}
+	migrations := &schema.TableDescription{Name: m.table, Primary: version, Fields: schema.FieldList{
+		version,
+		{Node: schema.Node{Name: "Name", Type: str}, SqlName: "name", Tags: &types.Tag{Size: 255}},
+		{Node: schema.Node{Name: "Checksum", Type: str}, SqlName: "checksum", Tags: &types.Tag{Size: 64}},
+		{Node: schema.Node{Name: "AppliedAt", Type: str}, SqlName: "applied_at", Tags: &types.Tag{Size: 40}},
+	}}
+
+	id := &schema.Field{Node: schema.Node{Name: "Id", Type: i64}, SqlName: "id", Tags: &types.Tag{Primary: true}}
+	lock := &schema.TableDescription{Name: m.table + "_lock", Primary: id, Fields: schema.FieldList{
+		id,
+		{Node: schema.Node{Name: "LockedAt", Type: str}, SqlName: "locked_at", Tags: &types.Tag{Size: 40}},
+	}}
+
+	return []*schema.TableDescription{migrations, lock}
+}
@@ -5,13 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"slices"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/tracelog"
 	"github.com/rickb777/sqlapi/driver"
-	"github.com/rickb777/where/dialect"
 	"github.com/rickb777/where/quote"
 )
 
@@ -47,6 +47,11 @@ type shim struct {
 	lgr     Logger
 	isTx    bool
 	wrapped interface{}
+	// noArgCheck disables the placeholder count check
+	noArgCheck   bool
+	interceptors []Interceptor
+	// scope is the context of the enclosing Transact or SingleConn, for the interceptors
+	scope context.Context
 }
 
 var _ SqlDB = new(shim)
@@ -55,7 +60,26 @@ var _ SqlTx = new(shim)
 //-------------------------------------------------------------------------------------------------
 
 func (sh *shim) Query(ctx context.Context, query string, args ...any) (SqlRows, error) {
-	qr := driver.Postgres().ReplacePlaceholders(query, nil)
+	var rows SqlRows
+	err := sh.intercept(ctx, &Call{Op: OpQuery, SQL: query, Args: args}, func(ctx context.Context) error {
+		r, err := sh.query(ctx, query, args...)
+		if err == nil {
+			rows = r
+		}
+		return err
+	})
+	if err != nil && rows != nil {
+		rows.Close() // an interceptor rejected the outcome
+		return nil, err
+	}
+	return rows, err
+}
+
+func (sh *shim) query(ctx context.Context, query string, args ...any) (SqlRows, error) {
+	qr, args, err := sh.bind(query, args)
+	if err != nil {
+		return nil, err
+	}
 	rows, err := sh.ex.Query(defaultCtx(ctx), qr, args...)
 	if err != nil {
 		return nil, wrap(err, query, args)
@@ -64,16 +88,38 @@ func (sh *shim) Query(ctx context.Context, query string, args ...any) (SqlRows,
 }
 
 func (sh *shim) QueryRow(ctx context.Context, query string, args ...any) SqlRow {
-	qr := driver.Postgres().ReplacePlaceholders(query, nil)
+	if len(sh.interceptors) > 0 {
+		return interceptedRow{sh: sh, ctx: ctx, query: query, args: args}
+	}
+	return sh.queryRow(ctx, query, args...)
+}
+
+func (sh *shim) queryRow(ctx context.Context, query string, args ...any) SqlRow {
+	qr, args, err := sh.bind(query, args)
+	if err != nil {
+		return errorRow{err}
+	}
 	return sh.ex.QueryRow(defaultCtx(ctx), qr, args...)
 }
 
 func (sh *shim) Insert(ctx context.Context, pk, query string, args ...any) (int64, error) {
-	q2 := fmt.Sprintf("%s RETURNING %s", query, pk)
-	qr := driver.Postgres().ReplacePlaceholders(q2, nil)
-	row := sh.ex.QueryRow(defaultCtx(ctx), qr, args...)
+	call := &Call{Op: OpInsert, SQL: query, Args: args}
+	err := sh.intercept(ctx, call, func(ctx context.Context) (err error) {
+		call.N, err = sh.insert(ctx, pk, query, args...)
+		return err
+	})
+	return call.N, err
+}
+
+func (sh *shim) insert(ctx context.Context, pk, query string, args ...any) (int64, error) {
+	qr, args, err := sh.bind(query, args)
+	if err != nil {
+		return 0, err
+	}
+	q2 := sh.di.InsertReturningId(qr, pk)
+	row := sh.ex.QueryRow(defaultCtx(ctx), q2, args...)
 	var id int64
-	err := row.Scan(&id)
+	err = row.Scan(&id)
 	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 		return 0, wrap(err, query, args)
 	}
@@ -81,14 +127,78 @@ func (sh *shim) Insert(ctx context.Context, pk, query string, args ...any) (int6
 }
 
 func (sh *shim) Exec(ctx context.Context, query string, args ...any) (int64, error) {
-	qr := dialect.ReplacePlaceholdersWithNumbers(query, "$")
-	tag, err := sh.ex.Exec(defaultCtx(ctx), qr, args...)
+	call := &Call{Op: OpExec, SQL: query, Args: args}
+	err := sh.intercept(ctx, call, func(ctx context.Context) (err error) {
+		call.N, err = sh.exec(ctx, query, args...)
+		return err
+	})
+	return call.N, err
+}
+
+// execVerbatim executes a statement without arguments exactly as given, i.e. without checking
+// or replacing its placeholders. This is used for scripts, in which a '?' may be an operator,
+// e.g. for PostgreSQL jsonb.
+func (sh *shim) execVerbatim(ctx context.Context, query string) (int64, error) {
+	call := &Call{Op: OpExec, SQL: query}
+	err := sh.intercept(ctx, call, func(ctx context.Context) (err error) {
+		call.N, err = sh.execBound(ctx, query, query, nil)
+		return err
+	})
+	return call.N, err
+}
+
+func (sh *shim) exec(ctx context.Context, query string, args ...any) (int64, error) {
+	qr, args, err := sh.bind(query, args)
+	if err != nil {
+		return 0, err
+	}
+	return sh.execBound(ctx, query, qr, args)
+}
+
+// execBound executes a query whose placeholders are already bound to the arguments.
+func (sh *shim) execBound(ctx context.Context, query, bound string, args []any) (int64, error) {
+	tag, err := sh.ex.Exec(defaultCtx(ctx), bound, args...)
 	if err != nil {
 		return 0, wrap(err, query, args)
 	}
 	return tag.RowsAffected(), nil
 }
 
+// bind checks the number of arguments and binds them to the query for the dialect.
+// Any leading pgx options, e.g. pgx.QueryExecModeSimpleProtocol, are not counted and are
+// passed on ahead of the bound arguments. There is no check if the options include a
+// pgx.QueryRewriter, such as pgx.NamedArgs, because that supplies the arguments itself.
+func (sh *shim) bind(query string, args []interface{}) (string, []interface{}, error) {
+	options, args, rewriter := splitOptions(args)
+	if !sh.noArgCheck && !rewriter {
+		if n := sh.di.CountPlaceholders(query); n >= 0 && n != len(args) {
+			return "", nil, &PlaceholderCountError{Query: query, Expected: n, Actual: len(args)}
+		}
+	}
+
+	bound, args, err := driver.BindArgs(sh.di, query, args)
+	if err != nil || len(options) == 0 {
+		return bound, args, err
+	}
+	return bound, append(options, args...), nil
+}
+
+// splitOptions separates the leading arguments that pgx treats as options from the rest.
+func splitOptions(args []interface{}) (options, rest []interface{}, rewriter bool) {
+	n := 0
+loop:
+	for ; n < len(args); n++ {
+		switch args[n].(type) {
+		case pgx.QueryExecMode, pgx.QueryResultFormats, pgx.QueryResultFormatsByOID:
+		case pgx.QueryRewriter:
+			rewriter = true
+		default:
+			break loop
+		}
+	}
+	return args[:n:n], args[n:], rewriter
+}
+
 func (sh *shim) IsTx() bool {
 	return sh.isTx
 }
@@ -107,6 +217,18 @@ func (sh *shim) With(userItem interface{}) SqlDB {
 	return &cp
 }
 
+func (sh *shim) CheckArgCount(on bool) SqlDB {
+	cp := *sh
+	cp.noArgCheck = !on
+	return &cp
+}
+
+func (sh *shim) WithInterceptors(interceptors ...Interceptor) SqlDB {
+	cp := *sh
+	cp.interceptors = append(slices.Clip(sh.interceptors), interceptors...)
+	return &cp
+}
+
 func (sh *shim) UserItem() interface{} {
 	return sh.wrapped
 }
@@ -125,11 +247,18 @@ func (sh *shim) beginTx(ctx context.Context, opts *pgx.TxOptions) (SqlTx, error)
 	cp := *sh
 	cp.ex = tx
 	cp.isTx = true
+	cp.scope = ctx
 	return &cp, nil
 }
 
 // Transact takes a function and executes it within a database transaction.
-func (sh *shim) Transact(ctx context.Context, txOptions *pgx.TxOptions, fn func(SqlTx) error) (err error) {
+func (sh *shim) Transact(ctx context.Context, txOptions *pgx.TxOptions, fn func(SqlTx) error) error {
+	return sh.intercept(ctx, &Call{Op: OpTransact}, func(ctx context.Context) error {
+		return sh.transact(ctx, txOptions, fn)
+	})
+}
+
+func (sh *shim) transact(ctx context.Context, txOptions *pgx.TxOptions, fn func(SqlTx) error) (err error) {
 	if sh.isTx {
 		return fn(sh) // nested transactions are inlined
 	}
@@ -156,7 +285,13 @@ func (sh *shim) Transact(ctx context.Context, txOptions *pgx.TxOptions, fn func(
 	return fn(tx)
 }
 
-func (sh *shim) SingleConn(ctx context.Context, fn func(ex Execer) error) (err error) {
+func (sh *shim) SingleConn(ctx context.Context, fn func(ex Execer) error) error {
+	return sh.intercept(ctx, &Call{Op: OpSingleConn}, func(ctx context.Context) error {
+		return sh.singleConn(ctx, fn)
+	})
+}
+
+func (sh *shim) singleConn(ctx context.Context, fn func(ex Execer) error) (err error) {
 	cp := sh.ex.(*pgxpool.Pool)
 	conn, err := cp.Acquire(ctx)
 	if err != nil {
@@ -171,10 +306,14 @@ func (sh *shim) SingleConn(ctx context.Context, fn func(ex Execer) error) (err e
 	}()
 
 	ex := &shim{
-		ex:      conn,
-		lgr:     sh.lgr,
-		isTx:    false,
-		wrapped: sh.wrapped,
+		ex:           conn,
+		di:           sh.di,
+		lgr:          sh.lgr,
+		isTx:         false,
+		wrapped:      sh.wrapped,
+		noArgCheck:   sh.noArgCheck,
+		interceptors: sh.interceptors,
+		scope:        ctx,
 	}
 	return fn(ex)
 }
@@ -207,19 +346,43 @@ func (sh *shim) Ping(ctx context.Context) error {
 	})
 }
 
+// Stats gives the pgxpool statistics, expressed as their nearest database/sql equivalents.
 func (sh *shim) Stats() DBStats {
-	return DBStats{} //sh.ex.(*pgx.ConnPool).Stats()
+	st := sh.ex.(*pgxpool.Pool).Stat()
+	return DBStats{
+		MaxOpenConnections: int(st.MaxConns()),
+		OpenConnections:    int(st.TotalConns()),
+		InUse:              int(st.AcquiredConns()),
+		Idle:               int(st.IdleConns()),
+		WaitCount:          st.EmptyAcquireCount(),
+		WaitDuration:       st.EmptyAcquireWaitTime(),
+		MaxIdleClosed:      st.MaxIdleDestroyCount(),
+		MaxLifetimeClosed:  st.MaxLifetimeDestroyCount(),
+	}
 }
 
 //-------------------------------------------------------------------------------------------------
 // TX-specific methods
 
 func (sh *shim) Commit(ctx context.Context) error {
-	return sh.ex.(pgx.Tx).Commit(ctx)
+	return sh.intercept(ctx, &Call{Op: OpCommit}, func(ctx context.Context) error {
+		return sh.ex.(pgx.Tx).Commit(ctx)
+	})
 }
 
 func (sh *shim) Rollback(ctx context.Context) error {
-	return sh.ex.(pgx.Tx).Rollback(ctx)
+	return sh.intercept(ctx, &Call{Op: OpRollback}, func(ctx context.Context) error {
+		return sh.ex.(pgx.Tx).Rollback(ctx)
+	})
+}
+
+// errorRow is a SqlRow that defers an error until Scan is called.
+type errorRow struct {
+	err error
+}
+
+func (r errorRow) Scan(_ ...interface{}) error {
+	return r.err
 }
 
 func defaultCtx(ctx context.Context) context.Context {
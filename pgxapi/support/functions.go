@@ -3,10 +3,10 @@ package support
 import (
 	"database/sql"
 	"fmt"
+	"github.com/rickb777/sqlapi/driver"
 	"github.com/rickb777/sqlapi/pgxapi"
 	"github.com/rickb777/sqlapi/require"
 	"github.com/rickb777/where"
-	"github.com/rickb777/where/dialect"
 	"github.com/rickb777/where/quote"
 	"strings"
 )
@@ -65,25 +65,33 @@ func sliceSql(tbl pgxapi.Table, column string, wh where.Expression, qc where.Que
 //
 // The caller must call rows.Close() on the result.
 func Query(tbl pgxapi.Table, query string, args ...interface{}) (pgxapi.SqlRows, error) {
-	q2 := tbl.Dialect().ReplacePlaceholders(query, args)
 	lgr := tbl.Logger()
-	lgr.LogQuery(tbl.Ctx(), q2, args...)
-	rows, err := tbl.Execer().Query(tbl.Ctx(), q2, args...)
+	logQuery(tbl, query, args)
+	rows, err := tbl.Execer().Query(tbl.Ctx(), query, args...)
 	return rows, lgr.LogIfError(tbl.Ctx(), err)
 }
 
+// logQuery logs the query as it will be sent to the database, i.e. with its placeholders
+// and named parameters already bound.
+func logQuery(tbl pgxapi.Table, query string, args []interface{}) {
+	q2, a2, err := driver.BindArgs(tbl.Dialect(), query, args)
+	if err != nil {
+		q2, a2 = query, args // the error is reported by the execer
+	}
+	tbl.Logger().LogQuery(tbl.Ctx(), q2, a2...)
+}
+
 // Exec executes a modification query (insert, update, delete, etc) and returns the number of items affected.
 //
 // The query is logged using whatever logger is configured. If an error arises, this too is logged.
 func Exec(tbl pgxapi.Table, req require.Requirement, query string, args ...interface{}) (int64, error) {
-	q2 := tbl.Dialect().ReplacePlaceholders(query, args)
-	n, err := doExec(tbl, q2, args...)
+	n, err := doExec(tbl, query, args...)
 	return n, require.ChainErrorIfExecNotSatisfiedBy(err, req, n)
 }
 
 func doExec(tbl pgxapi.Table, query string, args ...interface{}) (int64, error) {
 	lgr := tbl.Logger()
-	lgr.LogQuery(tbl.Ctx(), query, args...)
+	logQuery(tbl, query, args)
 	n, err := tbl.Execer().Exec(tbl.Ctx(), query, args...)
 	if err != nil {
 		return 0, lgr.LogError(tbl.Ctx(), err)
@@ -165,8 +173,7 @@ func DeleteByColumn(tbl pgxapi.Table, req require.Requirement, column string, v
 func GetIntIntIndex(tbl pgxapi.Table, q quote.Quoter, keyColumn, valColumn string, wh where.Expression) (map[int64]int64, error) {
 	whs, args := where.Where(wh)
 	query := fmt.Sprintf("SELECT %s, %s FROM %s%s", q.Quote(keyColumn), q.Quote(valColumn), q.Quote(tbl.Name().String()), whs)
-	qr := dialect.ReplacePlaceholdersWithNumbers(query, "$")
-	rows, err := tbl.Execer().Query(tbl.Ctx(), qr, args...)
+	rows, err := tbl.Execer().Query(tbl.Ctx(), query, args...)
 	if err != nil {
 		return nil, tbl.Logger().LogError(tbl.Ctx(), err)
 	}
@@ -188,8 +195,7 @@ func GetIntIntIndex(tbl pgxapi.Table, q quote.Quoter, keyColumn, valColumn strin
 func GetStringIntIndex(tbl pgxapi.Table, q quote.Quoter, keyColumn, valColumn string, wh where.Expression) (map[string]int64, error) {
 	whs, args := where.Where(wh)
 	query := fmt.Sprintf("SELECT %s, %s FROM %s%s", q.Quote(keyColumn), q.Quote(valColumn), q.Quote(tbl.Name().String()), whs)
-	qr := dialect.ReplacePlaceholdersWithNumbers(query, "$")
-	rows, err := tbl.Execer().Query(tbl.Ctx(), qr, args...)
+	rows, err := tbl.Execer().Query(tbl.Ctx(), query, args...)
 	if err != nil {
 		return nil, tbl.Logger().LogError(tbl.Ctx(), err)
 	}
@@ -212,8 +218,7 @@ func GetStringIntIndex(tbl pgxapi.Table, q quote.Quoter, keyColumn, valColumn st
 func GetIntStringIndex(tbl pgxapi.Table, q quote.Quoter, keyColumn, valColumn string, wh where.Expression) (map[int64]string, error) {
 	whs, args := where.Where(wh)
 	query := fmt.Sprintf("SELECT %s, %s FROM %s%s", q.Quote(keyColumn), q.Quote(valColumn), q.Quote(tbl.Name().String()), whs)
-	qr := dialect.ReplacePlaceholdersWithNumbers(query, "$")
-	rows, err := tbl.Execer().Query(tbl.Ctx(), qr, args...)
+	rows, err := tbl.Execer().Query(tbl.Ctx(), query, args...)
 	if err != nil {
 		return nil, tbl.Logger().LogError(tbl.Ctx(), err)
 	}
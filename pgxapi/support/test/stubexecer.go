@@ -24,24 +24,29 @@ type StubExecer struct {
 
 var _ pgxapi.Execer = &StubExecer{}
 
-// n.b. logging is included here because this emulates the behaviour of pgx
+// n.b. logging is included here because this emulates the behaviour of pgx;
+// placeholders and named parameters are bound first, as in the real shim
 
 func (e StubExecer) Query(ctx context.Context, query string, args ...interface{}) (pgxapi.SqlRows, error) {
+	query, args, _ = driver.BindArgs(e.Dialect(), query, args)
 	e.Lgr.Log(ctx, tracelog.LogLevelInfo, query, argMap(args...))
 	return e.Rows, e.Err
 }
 
 func (e StubExecer) QueryRow(ctx context.Context, query string, args ...interface{}) pgxapi.SqlRow {
+	query, args, _ = driver.BindArgs(e.Dialect(), query, args)
 	e.Lgr.Log(ctx, tracelog.LogLevelInfo, query, argMap(args...))
 	return e.Row
 }
 
 func (e StubExecer) Exec(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	query, args, _ = driver.BindArgs(e.Dialect(), query, args)
 	e.Lgr.Log(ctx, tracelog.LogLevelInfo, query, argMap(args...))
 	return e.N, e.Err
 }
 
 func (e StubExecer) Insert(ctx context.Context, pk, query string, args ...interface{}) (int64, error) {
+	query, args, _ = driver.BindArgs(e.Dialect(), query, args)
 	e.Lgr.Log(ctx, tracelog.LogLevelInfo, query, argMap(args...))
 	return e.N, e.Err
 }
@@ -95,6 +100,16 @@ func (e StubExecer) With(userItem interface{}) pgxapi.SqlDB {
 	return e
 }
 
+// CheckArgCount has no effect
+func (e StubExecer) CheckArgCount(_ bool) pgxapi.SqlDB {
+	return e
+}
+
+// WithInterceptors has no effect
+func (e StubExecer) WithInterceptors(_ ...pgxapi.Interceptor) pgxapi.SqlDB {
+	return e
+}
+
 func (e StubExecer) UserItem() interface{} {
 	return e.User
 }
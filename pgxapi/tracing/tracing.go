@@ -0,0 +1,154 @@
+// Package tracing provides OpenTelemetry tracing for pgxapi.SqlDB.
+//
+// It is implemented as a pgxapi.Interceptor, so every Query, QueryRow, Exec and Insert gets
+// a client span, as do Transact, Commit, Rollback and SingleConn. The spans carry the usual
+// database attributes: db.system, db.operation, db.statement, db.sql.table and, for Exec,
+// db.rows_affected. The statement arguments are never recorded.
+//
+// Calls made within a transaction or a single connection are children of the Transact or
+// SingleConn span, even though the function given to Transact doesn't receive its context.
+// Where the caller's context holds a different span, the child span is linked to it.
+//
+// The table name is taken from the context if it was set using WithTable; otherwise it is
+// read from simple statements, i.e. the first table after FROM, INTO, UPDATE or TABLE.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"regexp"
+	"strings"
+
+	"github.com/rickb777/sqlapi/driver"
+	"github.com/rickb777/sqlapi/pgxapi"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name of the tracer obtained from the TracerProvider.
+const InstrumentationName = "github.com/rickb777/sqlapi/pgxapi/tracing"
+
+// These are the attribute keys, as per the OpenTelemetry semantic conventions for databases.
+const (
+	DBSystem       = attribute.Key("db.system")
+	DBOperation    = attribute.Key("db.operation")
+	DBStatement    = attribute.Key("db.statement")
+	DBSQLTable     = attribute.Key("db.sql.table")
+	DBRowsAffected = attribute.Key("db.rows_affected")
+)
+
+// Wrap returns a copy of db that traces every call using the TracerProvider.
+// If tp is nil, the global TracerProvider is used.
+func Wrap(db pgxapi.SqlDB, tp trace.TracerProvider) pgxapi.SqlDB {
+	return db.WithInterceptors(Interceptor(tp))
+}
+
+// Interceptor returns an interceptor that traces every call using the TracerProvider.
+// If tp is nil, the global TracerProvider is used.
+func Interceptor(tp trace.TracerProvider) pgxapi.Interceptor {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	tracer := tp.Tracer(InstrumentationName)
+
+	return func(ctx context.Context, call *pgxapi.Call, next func(context.Context) error) error {
+		opts := []trace.SpanStartOption{trace.WithSpanKind(trace.SpanKindClient)}
+		ctx, opts = withinScope(ctx, call.Scope, opts)
+
+		name, attrs := describe(ctx, call.Dialect, string(call.Op), call.SQL)
+		opts = append(opts, trace.WithAttributes(attrs...))
+
+		ctx, span := tracer.Start(ctx, name, opts...)
+		defer span.End()
+
+		err := next(ctx)
+
+		if call.Op == pgxapi.OpExec && err == nil {
+			span.SetAttributes(DBRowsAffected.Int64(call.N))
+		}
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+}
+
+//-------------------------------------------------------------------------------------------------
+
+type tableKey struct{}
+
+// WithTable returns a context that gives the table name for the spans of calls made with it.
+func WithTable(ctx context.Context, name pgxapi.TableName) context.Context {
+	return context.WithValue(ctx, tableKey{}, name.String())
+}
+
+// withinScope parents the span on the enclosing transaction or connection, if there is one,
+// linking it to the span in the caller's context if that is different.
+func withinScope(ctx, scope context.Context, opts []trace.SpanStartOption) (context.Context, []trace.SpanStartOption) {
+	if scope == nil {
+		return ctx, opts
+	}
+
+	parent := trace.SpanFromContext(scope)
+	if !parent.SpanContext().IsValid() {
+		return ctx, opts
+	}
+
+	if own := trace.SpanContextFromContext(ctx); own.IsValid() && !own.Equal(parent.SpanContext()) {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: own}))
+	}
+	return trace.ContextWithSpan(ctx, parent), opts
+}
+
+var (
+	tableRe = regexp.MustCompile(`(?i)\b(?:from|into|update|table)\s+([^\s,;()]+)`)
+	unquote = strings.NewReplacer(`"`, "", "`", "", "[", "", "]", "")
+)
+
+// describe gives the span name and attributes for a call.
+func describe(ctx context.Context, di driver.Dialect, op, query string) (string, []attribute.KeyValue) {
+	var attrs []attribute.KeyValue
+	if di != nil {
+		attrs = append(attrs, DBSystem.String(system(di)))
+	}
+
+	if query == "" {
+		return op, attrs
+	}
+
+	fields := strings.Fields(query)
+	if len(fields) > 0 {
+		op = strings.ToUpper(fields[0])
+		attrs = append(attrs, DBOperation.String(op))
+	}
+	attrs = append(attrs, DBStatement.String(query))
+
+	table, _ := ctx.Value(tableKey{}).(string)
+	if table == "" {
+		if m := tableRe.FindStringSubmatch(query); m != nil {
+			table = unquote.Replace(m[1])
+		}
+	}
+	if table != "" {
+		attrs = append(attrs, DBSQLTable.String(table))
+		return op + " " + table, attrs
+	}
+	return op, attrs
+}
+
+// system gives the db.system value for a dialect.
+func system(di driver.Dialect) string {
+	switch di.Name() {
+	case "Sqlite", "Modernc":
+		return "sqlite"
+	case "Postgres", "Pgx":
+		return "postgresql"
+	case "SqlServer":
+		return "mssql"
+	}
+	return strings.ToLower(di.Name())
+}
@@ -0,0 +1,113 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/tracelog"
+	"github.com/rickb777/expect"
+	"github.com/rickb777/sqlapi/pgxapi"
+	"github.com/rickb777/sqlapi/pgxapi/tracing"
+	"github.com/rickb777/sqlapi/support/testenv"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var gdb pgxapi.SqlDB
+
+func TestPgxTracing(t *testing.T) {
+	ctx := context.Background()
+	err := pgxapi.ExecScript(ctx, gdb, `DROP TABLE IF EXISTS trace_items;
+CREATE TABLE trace_items (id integer primary key, name varchar(20));`)
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	db := tracing.Wrap(gdb, tp)
+
+	ctx, root := tp.Tracer("test").Start(ctx, "root")
+
+	n, err := db.Exec(ctx, "INSERT INTO trace_items (id, name) VALUES (1, 'a'), (2, 'b')")
+	expect.Error(err).Not().ToHaveOccurred(t)
+	expect.Number(n).ToBe(t, 2)
+
+	var name string
+	tctx := tracing.WithTable(ctx, pgxapi.TableName{Prefix: "trace_", Name: "things"})
+	err = db.QueryRow(tctx, "SELECT name FROM trace_items WHERE id = 1").Scan(&name)
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	err = db.Transact(ctx, nil, func(tx pgxapi.SqlTx) error {
+		_, e2 := tx.Exec(ctx, "UPDATE trace_items SET name = 'c'")
+		return e2
+	})
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	_, err = db.Exec(ctx, "DELETE FROM trace_no_such_table")
+	expect.Error(err).ToHaveOccurred(t)
+
+	err = db.SingleConn(ctx, func(ex pgxapi.Execer) error {
+		rows, e2 := ex.Query(ctx, "SELECT id FROM trace_items")
+		if e2 == nil {
+			rows.Close()
+		}
+		return e2
+	})
+	expect.Error(err).Not().ToHaveOccurred(t)
+
+	root.End()
+
+	spans := exp.GetSpans()
+	var names []string
+	for _, s := range spans {
+		names = append(names, s.Name)
+	}
+	expect.Slice(names).ToBe(t,
+		"INSERT trace_items",
+		"SELECT trace_things",
+		"UPDATE trace_items",
+		"Commit",
+		"Transact",
+		"DELETE trace_no_such_table",
+		"SELECT trace_items",
+		"SingleConn",
+		"root",
+	)
+
+	rootID := root.SpanContext().SpanID()
+	expect.Any(spans[0].Parent.SpanID()).ToBe(t, rootID)
+	expect.Map(attrs(spans[0])).ToContain(t, tracing.DBRowsAffected, "2")
+	expect.Map(attrs(spans[0])).ToContain(t, tracing.DBOperation, "INSERT")
+	expect.Map(attrs(spans[0])).ToContainAll(t, tracing.DBSystem, tracing.DBStatement)
+	expect.Map(attrs(spans[1])).ToContain(t, tracing.DBSQLTable, "trace_things")
+
+	// the transaction's calls are its children, linked to the caller's span
+	txID := spans[4].SpanContext.SpanID()
+	expect.Any(spans[4].Parent.SpanID()).ToBe(t, rootID)
+	expect.Any(spans[2].Parent.SpanID()).ToBe(t, txID)
+	expect.Any(spans[3].Parent.SpanID()).ToBe(t, txID)
+	expect.Slice(spans[2].Links).ToHaveLength(t, 1)
+	expect.Any(spans[2].Links[0].SpanContext.SpanID()).ToBe(t, rootID)
+
+	expect.Any(spans[5].Status.Code).ToBe(t, codes.Error)
+	expect.Slice(spans[5].Events).ToHaveLength(t, 1)
+
+	expect.Any(spans[6].Parent.SpanID()).ToBe(t, spans[7].SpanContext.SpanID())
+}
+
+func attrs(s tracetest.SpanStub) map[attribute.Key]string {
+	m := make(map[attribute.Key]string)
+	for _, kv := range s.Attributes {
+		m[kv.Key] = kv.Value.Emit()
+	}
+	return m
+}
+
+func TestMain(m *testing.M) {
+	testenv.SetDefaultDbDriver("pgx")
+	testenv.Shebang(m, func(lgr tracelog.Logger, logLevel tracelog.LogLevel, tries int) (err error) {
+		gdb, err = pgxapi.ConnectEnv(context.Background(), lgr, logLevel, tries)
+		return err
+	})
+}
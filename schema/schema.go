@@ -1,8 +1,10 @@
 package schema
 
 import (
+	"errors"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	. "github.com/rickb777/sqlapi/types"
@@ -21,9 +23,10 @@ type TableDescription struct {
 	Type string
 	Name string
 
-	Fields  FieldList
-	Index   []*Index `json:",omitempty" yaml:",omitempty"`
-	Primary *Field   `json:",omitempty" yaml:",omitempty"` // compound primaries are not supported
+	Fields      FieldList
+	Index       []*Index  `json:",omitempty" yaml:",omitempty"`
+	Primary     *Field    `json:",omitempty" yaml:",omitempty"` // the primary key, if it is a single column
+	PrimaryKeys FieldList `json:",omitempty" yaml:",omitempty"` // all the primary key columns, in order
 }
 
 type Node struct {
@@ -39,23 +42,77 @@ type Field struct {
 	Tags    *Tag      `json:",omitempty" yaml:",omitempty"`
 }
 
+// DefaultValue is the default for a column. It is either a literal value, which will be
+// written in the form needed by the column type and dialect, or a raw SQL expression,
+// which will be used verbatim.
+type DefaultValue struct {
+	Value string
+	Expr  bool // true if Value is a raw SQL expression
+}
+
 type Index struct {
 	Name   string
 	Unique bool
 	Fields FieldList
 }
 
+// Validate checks that the default value of every field is valid for its type; see
+// Field.Default.
+func (t *TableDescription) Validate() error {
+	var errs []error
+	for _, f := range t.Fields {
+		if _, err := f.Default(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("table %s: %w", t.Name, errors.Join(errs...))
+	}
+	return nil
+}
+
+// SetPrimaryKey sets the primary key, which is composite if there is more than one field.
+// The order of the fields is the order of the columns in the key.
+func (t *TableDescription) SetPrimaryKey(fields ...*Field) {
+	t.PrimaryKeys = fields
+	t.Primary = nil
+	if len(fields) == 1 {
+		t.Primary = fields[0]
+	}
+}
+
+// PrimaryKeyFields gets the primary key fields in order. This is empty if there is no primary key.
+func (t *TableDescription) PrimaryKeyFields() FieldList {
+	if len(t.PrimaryKeys) > 0 {
+		return t.PrimaryKeys
+	}
+	if t.Primary != nil {
+		return FieldList{t.Primary}
+	}
+	return nil
+}
+
+// HasCompositePrimaryKey is true if the primary key has more than one column.
+func (t *TableDescription) HasCompositePrimaryKey() bool {
+	return len(t.PrimaryKeyFields()) > 1
+}
+
+// HasIntegerPrimaryKey is true if the primary key is a single integer column.
 func (t *TableDescription) HasIntegerPrimaryKey() bool {
-	return t.Primary != nil && t.Primary.Type.Base.IsInteger()
+	pk := t.PrimaryKeyFields()
+	return len(pk) == 1 && pk[0].Type.Base.IsInteger()
 }
 
 func (t *TableDescription) HasPrimaryKey() bool {
-	return t.Primary != nil
+	return len(t.PrimaryKeyFields()) > 0
 }
 
+// SafePrimary gets the primary key field if it is a single column. Otherwise, the result is
+// the zero Field.
 func (t *TableDescription) SafePrimary() Field {
-	if t.Primary != nil {
-		return *t.Primary
+	pk := t.PrimaryKeyFields()
+	if len(pk) == 1 {
+		return *pk[0]
 	}
 	return Field{}
 }
@@ -107,6 +164,69 @@ func (f *Field) GetTags() Tag {
 	return *f.Tags
 }
 
+// Default gets the default value for the field, or nil if it has none.
+//
+// The 'default' tag is a literal for string, boolean and numeric fields. For other fields,
+// such as time.Time, it is an SQL expression, as is a value that is not a valid literal for a
+// boolean or numeric field but looks like an expression, e.g. CURRENT_TIMESTAMP or now(). This
+// keeps the meaning of tags written before 'defaultsql' existed. Any other invalid literal is
+// returned along with an error.
+func (f *Field) Default() (*DefaultValue, error) {
+	tags := f.GetTags()
+	switch {
+	case tags.DefaultSQL != "":
+		return &DefaultValue{Value: tags.DefaultSQL, Expr: true}, nil
+	case tags.Default == "":
+		return nil, nil
+	}
+
+	dv := &DefaultValue{Value: tags.Default}
+	base := f.Type.Base
+	switch {
+	case base == String:
+		return dv, nil
+	case base != Bool && !base.IsInteger() && base != Float32 && base != Float64:
+		dv.Expr = true
+		return dv, nil
+	}
+
+	if err := checkLiteral(base, tags.Default); err != nil {
+		if !looksLikeExpr(tags.Default) {
+			return dv, fmt.Errorf("%s: default %w", f.Name, err)
+		}
+		dv.Expr = true
+	}
+	return dv, nil
+}
+
+// looksLikeExpr tests whether a value starts like a keyword, a function call or a
+// parenthesised expression, as opposed to a malformed number.
+func looksLikeExpr(value string) bool {
+	c := value[0]
+	return c == '_' || c == '(' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func checkLiteral(base Kind, value string) (err error) {
+	switch {
+	case base == Bool:
+		_, err = strconv.ParseBool(value)
+	case base.IsUnsigned():
+		_, err = strconv.ParseUint(value, 10, bitSize(base))
+	case base.IsInteger():
+		_, err = strconv.ParseInt(value, 10, bitSize(base))
+	case base == Float32 || base == Float64:
+		_, err = strconv.ParseFloat(value, base.BitWidth())
+	}
+	return err
+}
+
+func bitSize(base Kind) int {
+	if base == Int || base == Uint {
+		return 0 // the platform's int size
+	}
+	return base.BitWidth()
+}
+
 func (f *Field) Skip() bool {
 	return f.Tags != nil && f.Tags.Skip
 }
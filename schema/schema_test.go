@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/rickb777/expect"
+	. "github.com/rickb777/sqlapi/types"
 )
 
 func TestDistinctTypes(t *testing.T) {
@@ -32,3 +33,80 @@ func TestDistinctTypes(t *testing.T) {
 		expect.Map(NewTypeSet(s...)).ToBe(t, c.expected)
 	}
 }
+
+func TestFieldDefault(t *testing.T) {
+	cases := []struct {
+		typ      Type
+		tag      Tag
+		expected *DefaultValue
+		err      string
+	}{
+		{i64, Tag{}, nil, ""},
+		{i64, Tag{Default: "-12"}, &DefaultValue{Value: "-12"}, ""},
+		{upt, Tag{Default: "-12"}, &DefaultValue{Value: "-12"}, `Foo: default strconv.ParseUint: parsing "-12": invalid syntax`},
+		{Type{Name: "int8", Base: Int8}, Tag{Default: "300"}, &DefaultValue{Value: "300"}, `value out of range`},
+		{boo, Tag{Default: "2"}, &DefaultValue{Value: "2"}, `invalid syntax`},
+		{fpt, Tag{Default: "1.5"}, &DefaultValue{Value: "1.5"}, ""},
+		{str, Tag{Default: "it's"}, &DefaultValue{Value: "it's"}, ""},
+		{str, Tag{Default: "CURRENT_USER"}, &DefaultValue{Value: "CURRENT_USER"}, ""},
+		{tim, Tag{DefaultSQL: "CURRENT_TIMESTAMP"}, &DefaultValue{Value: "CURRENT_TIMESTAMP", Expr: true}, ""},
+
+		// the old form of tag, before defaultsql, is still an expression
+		{tim, Tag{Default: "CURRENT_TIMESTAMP"}, &DefaultValue{Value: "CURRENT_TIMESTAMP", Expr: true}, ""},
+		{i64, Tag{Default: "NULL"}, &DefaultValue{Value: "NULL", Expr: true}, ""},
+		{i64, Tag{Default: "(1 + 2)"}, &DefaultValue{Value: "(1 + 2)", Expr: true}, ""},
+		{boo, Tag{Default: "random() > 0.5"}, &DefaultValue{Value: "random() > 0.5", Expr: true}, ""},
+	}
+	for _, c := range cases {
+		f := &Field{Node: Node{Name: "Foo", Type: c.typ}, Tags: &c.tag}
+		dv, err := f.Default()
+		expect.Any(dv).I(c.tag).ToBe(t, c.expected)
+		if c.err == "" {
+			expect.Error(err).I(c.tag).Not().ToHaveOccurred(t)
+		} else {
+			expect.Error(err).I(c.tag).ToContain(t, c.err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	count := &Field{Node: Node{Name: "Count", Type: i64}, Tags: &Tag{Default: "1.5"}}
+	flag := &Field{Node: Node{Name: "Flag", Type: boo}, Tags: &Tag{Default: "true"}}
+
+	table := &TableDescription{Name: "things", Fields: FieldList{flag}}
+	expect.Error(table.Validate()).Not().ToHaveOccurred(t)
+
+	table.Fields = append(table.Fields, count)
+	expect.Error(table.Validate()).ToContain(t, `table things: Count: default strconv.ParseInt: parsing "1.5"`)
+}
+
+func TestPrimaryKey(t *testing.T) {
+	userId := &Field{Node: Node{Name: "UserId", Type: i64}, SqlName: "user_id", Tags: &Tag{Primary: true}}
+	groupId := &Field{Node: Node{Name: "GroupId", Type: i64}, SqlName: "group_id", Tags: &Tag{Primary: true}}
+
+	none := &TableDescription{Fields: FieldList{name}}
+	expect.Bool(none.HasPrimaryKey()).ToBeFalse(t)
+	expect.Bool(none.HasIntegerPrimaryKey()).ToBeFalse(t)
+	expect.Slice(none.PrimaryKeyFields()).ToBeEmpty(t)
+
+	// the single Primary field is still honoured when PrimaryKeys is not set
+	single := &TableDescription{Fields: FieldList{id, name}, Primary: id}
+	expect.Bool(single.HasPrimaryKey()).ToBeTrue(t)
+	expect.Bool(single.HasIntegerPrimaryKey()).ToBeTrue(t)
+	expect.Bool(single.HasCompositePrimaryKey()).ToBeFalse(t)
+	expect.Slice(single.PrimaryKeyFields()).ToBe(t, id)
+	expect.String(single.SafePrimary().SqlName).ToBe(t, "id")
+
+	composite := &TableDescription{Fields: FieldList{userId, groupId, name}}
+	composite.SetPrimaryKey(userId, groupId)
+	expect.Any(composite.Primary).ToBeNil(t)
+	expect.Bool(composite.HasPrimaryKey()).ToBeTrue(t)
+	expect.Bool(composite.HasIntegerPrimaryKey()).ToBeFalse(t)
+	expect.Bool(composite.HasCompositePrimaryKey()).ToBeTrue(t)
+	expect.Slice(composite.PrimaryKeyFields().SqlNames()).ToBe(t, "user_id", "group_id")
+	expect.String(composite.SafePrimary().SqlName).ToBe(t, "")
+
+	composite.SetPrimaryKey(groupId)
+	expect.Any(composite.Primary).ToBe(t, groupId)
+	expect.Bool(composite.HasIntegerPrimaryKey()).ToBeTrue(t)
+}
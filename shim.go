@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"slices"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/tracelog"
@@ -36,6 +37,11 @@ type shim struct {
 	lgr     Logger
 	isTx    bool
 	wrapped interface{}
+	// noArgCheck disables the placeholder count check
+	noArgCheck   bool
+	interceptors []Interceptor
+	// scope is the context of the enclosing Transact or SingleConn, for the interceptors
+	scope context.Context
 }
 
 var _ SqlDB = new(shim)
@@ -44,20 +50,62 @@ var _ SqlTx = new(shim)
 //-------------------------------------------------------------------------------------------------
 
 func (sh *shim) Query(ctx context.Context, query string, args ...interface{}) (SqlRows, error) {
-	qr := sh.di.ReplacePlaceholders(query, nil)
+	var rows SqlRows
+	err := sh.intercept(ctx, &Call{Op: OpQuery, SQL: query, Args: args}, func(ctx context.Context) error {
+		r, err := sh.query(ctx, query, args...)
+		if err == nil {
+			rows = r
+		}
+		return err
+	})
+	if err != nil && rows != nil {
+		_ = rows.Close() // an interceptor rejected the outcome
+		return nil, err
+	}
+	return rows, err
+}
+
+func (sh *shim) query(ctx context.Context, query string, args ...interface{}) (SqlRows, error) {
+	qr, args, err := sh.bind(query, args)
+	if err != nil {
+		return nil, err
+	}
 	return sh.ex.QueryContext(defaultCtx(ctx), qr, args...)
 }
 
 func (sh *shim) QueryRow(ctx context.Context, query string, args ...interface{}) SqlRow {
-	qr := sh.di.ReplacePlaceholders(query, nil)
+	if len(sh.interceptors) > 0 {
+		return interceptedRow{sh: sh, ctx: ctx, query: query, args: args}
+	}
+	return sh.queryRow(ctx, query, args...)
+}
+
+func (sh *shim) queryRow(ctx context.Context, query string, args ...interface{}) SqlRow {
+	qr, args, err := sh.bind(query, args)
+	if err != nil {
+		return errorRow{err}
+	}
 	return sh.ex.QueryRowContext(defaultCtx(ctx), qr, args...)
 }
 
 func (sh *shim) Insert(ctx context.Context, pk, query string, args ...interface{}) (int64, error) {
+	call := &Call{Op: OpInsert, SQL: query, Args: args}
+	err := sh.intercept(ctx, call, func(ctx context.Context) (err error) {
+		call.N, err = sh.insert(ctx, pk, query, args...)
+		return err
+	})
+	return call.N, err
+}
+
+func (sh *shim) insert(ctx context.Context, pk, query string, args ...interface{}) (int64, error) {
+	qr, args, err := sh.bind(query, args)
+	if err != nil {
+		return 0, err
+	}
 	if sh.di.HasLastInsertId() {
-		return sh.mysqlInsert(ctx, query, args...)
+		return sh.mysqlInsert(ctx, qr, args...)
 	}
-	return sh.postgresInsert(ctx, pk, query, args...)
+	return sh.returningInsert(ctx, pk, qr, args...)
 }
 
 func (sh *shim) mysqlInsert(ctx context.Context, query string, args ...interface{}) (int64, error) {
@@ -69,10 +117,9 @@ func (sh *shim) mysqlInsert(ctx context.Context, query string, args ...interface
 	return id, wrap(err, query, args)
 }
 
-func (sh *shim) postgresInsert(ctx context.Context, pk, query string, args ...interface{}) (int64, error) {
-	q2 := fmt.Sprintf("%s RETURNING %s", query, pk)
-	qr := sh.di.ReplacePlaceholders(q2, nil)
-	row := sh.ex.QueryRowContext(defaultCtx(ctx), qr, args...)
+func (sh *shim) returningInsert(ctx context.Context, pk, query string, args ...interface{}) (int64, error) {
+	q2 := sh.di.InsertReturningId(query, pk)
+	row := sh.ex.QueryRowContext(defaultCtx(ctx), q2, args...)
 	var id int64
 	err := row.Scan(&id)
 	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
@@ -82,8 +129,37 @@ func (sh *shim) postgresInsert(ctx context.Context, pk, query string, args ...in
 }
 
 func (sh *shim) Exec(ctx context.Context, query string, args ...interface{}) (int64, error) {
-	qr := sh.di.ReplacePlaceholders(query, nil)
-	res, err := sh.ex.ExecContext(defaultCtx(ctx), qr, args...)
+	call := &Call{Op: OpExec, SQL: query, Args: args}
+	err := sh.intercept(ctx, call, func(ctx context.Context) (err error) {
+		call.N, err = sh.exec(ctx, query, args...)
+		return err
+	})
+	return call.N, err
+}
+
+// execVerbatim executes a statement without arguments exactly as given, i.e. without checking
+// or replacing its placeholders. This is used for scripts, in which a '?' may be an operator,
+// e.g. for PostgreSQL jsonb.
+func (sh *shim) execVerbatim(ctx context.Context, query string) (int64, error) {
+	call := &Call{Op: OpExec, SQL: query}
+	err := sh.intercept(ctx, call, func(ctx context.Context) (err error) {
+		call.N, err = sh.execBound(ctx, query, query, nil)
+		return err
+	})
+	return call.N, err
+}
+
+func (sh *shim) exec(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	qr, args, err := sh.bind(query, args)
+	if err != nil {
+		return 0, err
+	}
+	return sh.execBound(ctx, query, qr, args)
+}
+
+// execBound executes a query whose placeholders are already bound to the arguments.
+func (sh *shim) execBound(ctx context.Context, query, bound string, args []interface{}) (int64, error) {
+	res, err := sh.ex.ExecContext(defaultCtx(ctx), bound, args...)
 	if err != nil {
 		return 0, wrap(err, query, args)
 	}
@@ -91,6 +167,16 @@ func (sh *shim) Exec(ctx context.Context, query string, args ...interface{}) (in
 	return n, wrap(err, query, args)
 }
 
+// bind checks the number of arguments and binds them to the query for the dialect.
+func (sh *shim) bind(query string, args []interface{}) (string, []interface{}, error) {
+	if !sh.noArgCheck {
+		if n := sh.di.CountPlaceholders(query); n >= 0 && n != len(args) {
+			return "", nil, &PlaceholderCountError{Query: query, Expected: n, Actual: len(args)}
+		}
+	}
+	return driver.BindArgs(sh.di, query, args)
+}
+
 func (sh *shim) IsTx() bool {
 	return sh.isTx
 }
@@ -109,6 +195,18 @@ func (sh *shim) With(userItem interface{}) SqlDB {
 	return &cp
 }
 
+func (sh *shim) CheckArgCount(on bool) SqlDB {
+	cp := *sh
+	cp.noArgCheck = !on
+	return &cp
+}
+
+func (sh *shim) WithInterceptors(interceptors ...Interceptor) SqlDB {
+	cp := *sh
+	cp.interceptors = append(slices.Clip(sh.interceptors), interceptors...)
+	return &cp
+}
+
 func (sh *shim) UserItem() interface{} {
 	return sh.wrapped
 }
@@ -148,11 +246,18 @@ func (sh *shim) beginTx(ctx context.Context, pgopts *pgx.TxOptions) (SqlTx, erro
 	cp := *sh
 	cp.ex = tx
 	cp.isTx = true
+	cp.scope = ctx
 	return &cp, nil
 }
 
 // Transact takes a function and executes it within a database transaction.
-func (sh *shim) Transact(ctx context.Context, txOptions *pgx.TxOptions, fn func(SqlTx) error) (err error) {
+func (sh *shim) Transact(ctx context.Context, txOptions *pgx.TxOptions, fn func(SqlTx) error) error {
+	return sh.intercept(ctx, &Call{Op: OpTransact}, func(ctx context.Context) error {
+		return sh.transact(ctx, txOptions, fn)
+	})
+}
+
+func (sh *shim) transact(ctx context.Context, txOptions *pgx.TxOptions, fn func(SqlTx) error) (err error) {
 	if sh.isTx {
 		if _, isTx := sh.ex.(*sql.Tx); isTx {
 			return fn(sh) // nested transactions are inlined
@@ -181,7 +286,13 @@ func (sh *shim) Transact(ctx context.Context, txOptions *pgx.TxOptions, fn func(
 	return fn(tx)
 }
 
-func (sh *shim) SingleConn(ctx context.Context, fn func(ex Execer) error) (err error) {
+func (sh *shim) SingleConn(ctx context.Context, fn func(ex Execer) error) error {
+	return sh.intercept(ctx, &Call{Op: OpSingleConn}, func(ctx context.Context) error {
+		return sh.singleConn(ctx, fn)
+	})
+}
+
+func (sh *shim) singleConn(ctx context.Context, fn func(ex Execer) error) (err error) {
 	cp := sh.ex.(*sql.DB)
 	var conn *sql.Conn
 	conn, err = cp.Conn(defaultCtx(ctx))
@@ -200,11 +311,14 @@ func (sh *shim) SingleConn(ctx context.Context, fn func(ex Execer) error) (err e
 	}()
 
 	ex := &shim{
-		ex:      conn,
-		lgr:     sh.lgr,
-		di:      sh.di,
-		isTx:    false,
-		wrapped: sh.wrapped,
+		ex:           conn,
+		lgr:          sh.lgr,
+		di:           sh.di,
+		isTx:         false,
+		wrapped:      sh.wrapped,
+		noArgCheck:   sh.noArgCheck,
+		interceptors: sh.interceptors,
+		scope:        ctx,
 	}
 	return fn(ex)
 }
@@ -240,12 +354,25 @@ func (sh *shim) Stats() sql.DBStats {
 //-------------------------------------------------------------------------------------------------
 // TX-specific methods
 
-func (sh *shim) Commit(_ context.Context) error {
-	return sh.ex.(*sql.Tx).Commit()
+func (sh *shim) Commit(ctx context.Context) error {
+	return sh.intercept(ctx, &Call{Op: OpCommit}, func(context.Context) error {
+		return sh.ex.(*sql.Tx).Commit()
+	})
+}
+
+func (sh *shim) Rollback(ctx context.Context) error {
+	return sh.intercept(ctx, &Call{Op: OpRollback}, func(context.Context) error {
+		return sh.ex.(*sql.Tx).Rollback()
+	})
+}
+
+// errorRow is a SqlRow that defers an error until Scan is called.
+type errorRow struct {
+	err error
 }
 
-func (sh *shim) Rollback(_ context.Context) error {
-	return sh.ex.(*sql.Tx).Rollback()
+func (r errorRow) Scan(_ ...interface{}) error {
+	return r.err
 }
 
 func defaultCtx(ctx context.Context) context.Context {
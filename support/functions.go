@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/rickb777/sqlapi"
+	"github.com/rickb777/sqlapi/driver"
 	"github.com/rickb777/sqlapi/require"
 	"github.com/rickb777/where"
 	"github.com/rickb777/where/quote"
@@ -65,25 +66,33 @@ func sliceSql(tbl sqlapi.Table, column string, wh where.Expression, qc where.Que
 //
 // The caller must call rows.Close() on the result.
 func Query(tbl sqlapi.Table, query string, args ...interface{}) (sqlapi.SqlRows, error) {
-	q2 := tbl.Dialect().ReplacePlaceholders(query, args)
 	lgr := tbl.Logger()
-	lgr.LogQuery(tbl.Ctx(), q2, args...)
-	rows, err := tbl.Execer().Query(tbl.Ctx(), q2, args...)
+	logQuery(tbl, query, args)
+	rows, err := tbl.Execer().Query(tbl.Ctx(), query, args...)
 	return rows, lgr.LogIfError(tbl.Ctx(), err)
 }
 
+// logQuery logs the query as it will be sent to the database, i.e. with its placeholders
+// and named parameters already bound.
+func logQuery(tbl sqlapi.Table, query string, args []interface{}) {
+	q2, a2, err := driver.BindArgs(tbl.Dialect(), query, args)
+	if err != nil {
+		q2, a2 = query, args // the error is reported by the execer
+	}
+	tbl.Logger().LogQuery(tbl.Ctx(), q2, a2...)
+}
+
 // Exec executes a modification query (insert, update, delete, etc) and returns the number of items affected.
 //
 // The query is logged using whatever logger is configured. If an error arises, this too is logged.
 func Exec(tbl sqlapi.Table, req require.Requirement, query string, args ...interface{}) (int64, error) {
-	q2 := tbl.Dialect().ReplacePlaceholders(query, args)
-	n, err := doExec(tbl, q2, args...)
+	n, err := doExec(tbl, query, args...)
 	return n, require.ChainErrorIfExecNotSatisfiedBy(err, req, n)
 }
 
 func doExec(tbl sqlapi.Table, query string, args ...interface{}) (int64, error) {
 	lgr := tbl.Logger()
-	lgr.LogQuery(tbl.Ctx(), query, args...)
+	logQuery(tbl, query, args)
 	n, err := tbl.Execer().Exec(tbl.Ctx(), query, args...)
 	if err != nil {
 		return 0, lgr.LogError(tbl.Ctx(), err)
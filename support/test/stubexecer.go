@@ -82,6 +82,16 @@ func (e StubExecer) With(userItem interface{}) sqlapi.SqlDB {
 	return e
 }
 
+// CheckArgCount has no effect
+func (e StubExecer) CheckArgCount(_ bool) sqlapi.SqlDB {
+	return e
+}
+
+// WithInterceptors has no effect
+func (e StubExecer) WithInterceptors(_ ...sqlapi.Interceptor) sqlapi.SqlDB {
+	return e
+}
+
 func (e StubExecer) UserItem() interface{} {
 	return e.User
 }
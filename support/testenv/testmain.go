@@ -30,6 +30,8 @@ func Shebang(m *testing.M, connectFunc func(lgr tracelog.Logger, logLevel tracel
 	switch os.Getenv("DB_DRIVER") {
 	case "sqlite3":
 		mustUnsetEnv("DB_DRIVER")
+		fallthrough
+	case "sqlite", "duckdb": // modernc.org/sqlite is pure Go; DuckDB runs in-process
 		mustUnsetEnv("DB_URL")
 		mustUnsetEnv("PGHOST")
 		mustUnsetEnv("PGPORT")
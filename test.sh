@@ -46,7 +46,7 @@ DBS=$*
 if [[ -z $1 ]]; then
   DBS="sqlite"
 elif [[ $1 = "all" ]]; then
-  DBS="sqlite mysql postgres pgx"
+  DBS="sqlite modernc duckdb mysql postgres pgx"
 fi
 
 PACKAGES=". ./constraint ./driver"
@@ -95,8 +95,26 @@ for db in $DBS; do
       DB_DRIVER=sqlite3 DB_QUOTE=ansi go test $V $PACKAGES
       ;;
 
+    modernc)
+      unset DB_URL
+      echo "SQLite, pure Go (no quotes)..."
+      go clean -testcache ||:
+      DB_DRIVER=sqlite DB_QUOTE=none go test $V $PACKAGES
+      echo
+      echo "SQLite, pure Go (ANSI)..."
+      go clean -testcache ||:
+      DB_DRIVER=sqlite DB_QUOTE=ansi go test $V $PACKAGES
+      ;;
+
+    duckdb)
+      unset DB_URL
+      echo "DuckDB (ANSI)..."
+      go clean -testcache ||:
+      DB_DRIVER=duckdb DB_QUOTE=ansi go test $V .
+      ;;
+
     *)
-      echo "$db: unrecognised; must be sqlite, mysql, or postgres. Use 'all' for all of these."
+      echo "$db: unrecognised; must be sqlite, modernc, duckdb, mysql, or postgres. Use 'all' for all of these."
       exit 1
       ;;
   esac
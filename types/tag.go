@@ -18,22 +18,79 @@ const TagKey = "sql"
 // Tag stores the parsed data from the tag string in
 // a struct field. These are all optional.
 type Tag struct {
-	Name       string `json:",omitempty" yaml:"name,omitempty"`     // explicit column name
-	Type       string `json:",omitempty" yaml:"type,omitempty"`     // explicit column type (SQL syntax)
-	Default    string `json:",omitempty" yaml:"default,omitempty"`  // default SQL value
-	Prefixed   bool   `json:",omitempty" yaml:"prefixed,omitempty"` // use struct nesting to name the column
-	Primary    bool   `json:",omitempty" yaml:"pk,omitempty"`       // is a primary key
-	Natural    bool   `json:",omitempty" yaml:"nk,omitempty"`       // is a natural key so a unique index will be added automatically
-	Auto       bool   `json:",omitempty" yaml:"auto,omitempty"`     // is auto-incremented
-	Index      string `json:",omitempty" yaml:"index,omitempty"`    // the name of an index
-	Unique     string `json:",omitempty" yaml:"unique,omitempty"`   // the name of a unique index
-	ForeignKey string `json:",omitempty" yaml:"fk,omitempty"`       // relationship to another table
-	OnUpdate   string `json:",omitempty" yaml:"onupdate,omitempty"` // what to do on update (no action, cascade, delete, restrict, set null, set default)
-	OnDelete   string `json:",omitempty" yaml:"ondelete,omitempty"` // what to do on delete
-	Size       int    `json:",omitempty" yaml:"size,omitempty"`     // storage size
-	Encode     string `json:",omitempty" yaml:"encode,omitempty"`   // used for struct types: one of json | text | driver
-	Skip       bool   `json:",omitempty" yaml:"skip,omitempty"`     // ignore the field
-	// TODO Check      string `yaml:"check"` // specify SQL constraint checks
+	Name       string `json:",omitempty" yaml:"name,omitempty"`       // explicit column name
+	Type       string `json:",omitempty" yaml:"-"`                    // explicit column type (SQL syntax)
+	Types      Types  `json:",omitempty" yaml:"-"`                    // explicit column types for particular dialects
+	Default    string `json:",omitempty" yaml:"default,omitempty"`    // default value; see schema.Field.Default
+	DefaultSQL string `json:",omitempty" yaml:"defaultsql,omitempty"` // default raw SQL expression, e.g. CURRENT_TIMESTAMP
+	Prefixed   bool   `json:",omitempty" yaml:"prefixed,omitempty"`   // use struct nesting to name the column
+	Primary    bool   `json:",omitempty" yaml:"pk,omitempty"`         // is a primary key
+	Natural    bool   `json:",omitempty" yaml:"nk,omitempty"`         // is a natural key so a unique index will be added automatically
+	Auto       bool   `json:",omitempty" yaml:"auto,omitempty"`       // is auto-incremented
+	Index      string `json:",omitempty" yaml:"index,omitempty"`      // the name of an index
+	Unique     string `json:",omitempty" yaml:"unique,omitempty"`     // the name of a unique index
+	ForeignKey string `json:",omitempty" yaml:"fk,omitempty"`         // relationship to another table
+	OnUpdate   string `json:",omitempty" yaml:"onupdate,omitempty"`   // what to do on update (no action, cascade, delete, restrict, set null, set default)
+	OnDelete   string `json:",omitempty" yaml:"ondelete,omitempty"`   // what to do on delete
+	Size       int    `json:",omitempty" yaml:"size,omitempty"`       // storage size
+	Encode     string `json:",omitempty" yaml:"encode,omitempty"`     // used for struct types: one of json | text | driver
+	Skip       bool   `json:",omitempty" yaml:"skip,omitempty"`       // ignore the field
+	Check      string `json:",omitempty" yaml:"check,omitempty"`      // SQL check constraint expression
+}
+
+// Types holds explicit column types (SQL syntax) keyed by dialect name.
+type Types map[string]string
+
+// TypeFor gets the explicit column type for a dialect, which can be identified by any of
+// several names, ignoring case. If there is no type specifically for the dialect, the
+// general explicit type is returned, which will be blank if there is none.
+func (tag *Tag) TypeFor(names ...string) string {
+	if tag == nil {
+		return ""
+	}
+	for _, name := range names {
+		for k, v := range tag.Types {
+			if strings.EqualFold(k, name) {
+				return v
+			}
+		}
+	}
+	return tag.Type
+}
+
+// UnmarshalYAML allows the type to be given either as a single string or as a mapping from
+// dialect names to types, e.g. "type: {postgres: jsonb, mysql: json}".
+func (tag *Tag) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain Tag
+	var aux struct {
+		plain `yaml:",inline"`
+		Type  interface{} `yaml:"type,omitempty"`
+	}
+
+	if err := unmarshal(&aux); err != nil {
+		return err
+	}
+
+	*tag = Tag(aux.plain)
+
+	switch t := aux.Type.(type) {
+	case nil:
+	case string:
+		tag.Type = t
+	case map[interface{}]interface{}:
+		tag.Types = make(Types, len(t))
+		for k, v := range t {
+			ks, kok := k.(string)
+			vs, vok := v.(string)
+			if !kok || !vok {
+				return fmt.Errorf("type %v: %v must map a dialect name to an SQL type", k, v)
+			}
+			tag.Types[ks] = vs
+		}
+	default:
+		return fmt.Errorf("type %v must be a string or a mapping from dialect names to SQL types", t)
+	}
+	return nil
 }
 
 func (tag *Tag) ParentReference() (string, string) {
@@ -108,12 +165,24 @@ func (tag *Tag) validate() error {
 		sep = "; "
 	}
 
+	if tag.Default != "" && tag.DefaultSQL != "" {
+		io.WriteString(buf, sep)
+		io.WriteString(buf, "default and defaultsql cannot both be used")
+		sep = "; "
+	}
+
 	if tag.Size < 0 {
 		io.WriteString(buf, sep)
 		fmt.Fprintf(buf, "size cannot be negative (%d)", tag.Size)
 		sep = "; "
 	}
 
+	if tag.Check != "" && !balanced(tag.Check) {
+		io.WriteString(buf, sep)
+		fmt.Fprintf(buf, "check expression %q has unbalanced parentheses or quotes", tag.Check)
+		sep = "; "
+	}
+
 	if !inSet(tag.Encode, "", "json", "text", "driver") {
 		io.WriteString(buf, sep)
 		fmt.Fprintf(buf, "unrecognised encode value %q", tag.Encode)
@@ -153,10 +222,35 @@ func (tag *Tag) validate() error {
 	return nil
 }
 
+// balanced is true if the parentheses in an SQL expression match up, ignoring any within
+// quoted strings, and all the quotes are closed.
+func balanced(expr string) bool {
+	depth := 0
+	var quote rune
+	for _, r := range expr {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+			if depth < 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0 && quote == 0
+}
+
 var zero = Tag{}
 
 func (t *Tag) checkZero() *Tag {
-	if *t == zero {
+	if reflect.DeepEqual(*t, zero) {
 		return nil
 	}
 	return t
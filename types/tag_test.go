@@ -40,6 +40,18 @@ func TestParseTag(t *testing.T) {
 			TagKey + `:"type: varchar"`,
 			&Tag{Type: "varchar"},
 		},
+		{
+			TagKey + `:"type: 'numeric(12,2)', default: 0"`,
+			&Tag{Type: "numeric(12,2)", Default: "0"},
+		},
+		{
+			TagKey + `:"type: {postgres: jsonb, mysql: json}, encode: json"`,
+			&Tag{Types: Types{"postgres": "jsonb", "mysql": "json"}, Encode: "json"},
+		},
+		{
+			TagKey + `:"defaultsql: 'gen_random_uuid()'"`,
+			&Tag{DefaultSQL: "gen_random_uuid()"},
+		},
 		{
 			TagKey + `:"size: 2048"`,
 			&Tag{Size: 2048},
@@ -64,6 +76,14 @@ func TestParseTag(t *testing.T) {
 			TagKey + `:"fk: alpha, onupdate: 'set null', ondelete: 'set default'"`,
 			&Tag{ForeignKey: "alpha", OnUpdate: "set null", OnDelete: "set default"},
 		},
+		{
+			TagKey + `:"check: 'age >= 0'"`,
+			&Tag{Check: "age >= 0"},
+		},
+		{
+			TagKey + `:"check: 'status in (''a'', '')'')'"`,
+			&Tag{Check: "status in ('a', ')')"},
+		},
 	}
 
 	for _, test := range tagTests {
@@ -132,6 +152,22 @@ func TestParseValidation(t *testing.T) {
 			TagKey + `:"encode: foo"`,
 			`unrecognised encode value "foo"`,
 		},
+		{
+			TagKey + `:"default: 1, defaultsql: 'now()'"`,
+			`default and defaultsql cannot both be used`,
+		},
+		{
+			TagKey + `:"type: [a, b]"`,
+			`type [a b] must be a string or a mapping from dialect names to SQL types`,
+		},
+		{
+			TagKey + `:"check: '(age > 0'"`,
+			`check expression "(age > 0" has unbalanced parentheses or quotes`,
+		},
+		{
+			TagKey + `:"check: 'name <> ''x'"`,
+			`check expression "name <> 'x" has unbalanced parentheses or quotes`,
+		},
 	}
 
 	for _, test := range tagTests {
@@ -151,6 +187,11 @@ Id:
 Foo:
   name: fooish
   type: blob
+
+Bar:
+  type:
+    postgres: citext
+    sqlite: text collate nocase
 `
 
 	err := os.WriteFile(file, []byte(yml), 0644)
@@ -158,11 +199,16 @@ Foo:
 
 	tags, err := ReadTagsFile(file)
 	expect.Error(err).ToBeNil(t)
-	expect.Map(tags).ToHaveLength(t, 2)
+	expect.Map(tags).ToHaveLength(t, 3)
 
 	id := tags["Id"]
 	expect.Any(id).ToBe(t, &Tag{Primary: true, Auto: true})
 
 	foo := tags["Foo"]
 	expect.Any(foo).ToBe(t, &Tag{Name: "fooish", Type: "blob"})
+
+	bar := tags["Bar"]
+	expect.Any(bar).ToBe(t, &Tag{Types: Types{"postgres": "citext", "sqlite": "text collate nocase"}})
+	expect.String(bar.TypeFor("Sqlite", "SQLite3")).ToBe(t, "text collate nocase")
+	expect.String(bar.TypeFor("MySQL")).ToBe(t, "")
 }